@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/mcpchecker/kubernetes-extension/pkg/cli"
 	"github.com/mcpchecker/kubernetes-extension/pkg/extension"
 )
 
@@ -14,9 +15,20 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	// Commands run operations directly, without mcpchecker
+	if cli.IsCommand(os.Args[1:]) {
+		code := cli.Main(ctx, os.Args[1:], os.Stdout, os.Stderr)
+		cancel()
+		os.Exit(code)
+	}
+
 	ext := extension.New()
 
-	if err := ext.Run(ctx); err != nil {
+	err := ext.Run(ctx)
+	if closeErr := ext.Close(); closeErr != nil {
+		log.Printf("failed to release task state: %v", closeErr)
+	}
+	if err != nil {
 		log.Fatalf("extension error: %v", err)
 	}
 }
@@ -0,0 +1,421 @@
+// Package cli runs extension operations from the command line, without mcpchecker.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mcpchecker/kubernetes-extension/pkg/extension"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"sigs.k8s.io/yaml"
+)
+
+// Exit codes of the commands.
+const (
+	// ExitOK means every operation succeeded.
+	ExitOK = 0
+	// ExitFailed means an operation ran and failed.
+	ExitFailed = 1
+	// ExitUsage means the command line or an input file is invalid, or the extension
+	// could not be initialized, so no operation ran.
+	ExitUsage = 2
+)
+
+// Output formats.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// defaultPrefix is the step prefix of the extension in task files.
+const defaultPrefix = "kubernetes"
+
+const usage = `Usage:
+  kubernetes-extension                       Serve operations to mcpchecker over JSON-RPC on stdin/stdout
+  kubernetes-extension run <operation> [-f step.yaml] [flags]
+                                             Run a single operation
+  kubernetes-extension run-task <task.yaml> [--phase setup,verify,cleanup] [flags]
+                                             Run the kubernetes steps of a task file
+  kubernetes-extension validate <task.yaml|glob>...
+                                             Check the kubernetes steps of task files without a cluster
+
+Flags:
+`
+
+// IsCommand reports whether args, the command line without the program name, invoke
+// one of the CLI commands rather than the JSON-RPC extension.
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "run", "run-task", "validate", "help", "-h", "-help", "--help":
+		return true
+	}
+	return false
+}
+
+// Main runs the command in args, the command line without the program name, and
+// returns the exit code.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if !IsCommand(args) {
+		printUsage(stderr)
+		return ExitUsage
+	}
+	if args[0] == "validate" {
+		if len(args) < 2 {
+			fmt.Fprintln(stderr, "error: validate requires task files or glob patterns")
+			printUsage(stderr)
+			return ExitUsage
+		}
+		return validate(args[1:], stdout, stderr)
+	}
+	if args[0] != "run" && args[0] != "run-task" {
+		printUsage(stdout)
+		return ExitOK
+	}
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		fmt.Fprintf(stderr, "error: %s requires an operation or task file\n", args[0])
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	command, target := args[0], args[1]
+	opts := newFlags(args[2:])
+	opts.set.SetOutput(stderr)
+	if err := opts.parse(); err != nil {
+		return ExitUsage
+	}
+
+	ext := extension.New()
+	ext.SetLogOutput(stderr)
+	defer func() {
+		if err := ext.Close(); err != nil {
+			fmt.Fprintf(stderr, "failed to release task state: %v\n", err)
+		}
+	}()
+
+	r := &runner{ext: ext, stdout: stdout, format: opts.format}
+
+	prepare := r.prepareRun
+	if command == "run-task" {
+		prepare = r.prepareTask
+	}
+	if err := prepare(target, opts); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitUsage
+	}
+
+	if err := ext.Initialize(opts.config); err != nil {
+		fmt.Fprintf(stderr, "error: failed to initialize extension: %v\n", err)
+		return ExitUsage
+	}
+
+	return r.execute(ctx)
+}
+
+// flags holds the flags shared by the commands.
+type flags struct {
+	set        *flag.FlagSet
+	args       []string
+	file       string
+	workdir    string
+	phases     string
+	format     string
+	kubeconfig string
+	configJSON string
+
+	// config is the extension config built from configJSON and kubeconfig.
+	config map[string]any
+}
+
+// printUsage prints the commands and flags to w.
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+	f := newFlags(nil)
+	f.set.SetOutput(w)
+	f.set.PrintDefaults()
+}
+
+func newFlags(args []string) *flags {
+	f := &flags{set: flag.NewFlagSet("kubernetes-extension", flag.ContinueOnError), args: args}
+	f.set.Usage = func() { printUsage(f.set.Output()) }
+	f.set.StringVar(&f.file, "f", "", "File with the arguments of the operation, or - for stdin (run)")
+	f.set.StringVar(&f.workdir, "workdir", "", "Task directory for relative paths and step outputs (default: directory of the input file)")
+	f.set.StringVar(&f.phases, "phase", "setup,verify,cleanup", "Comma-separated phases to run (run-task)")
+	f.set.StringVar(&f.format, "o", formatText, "Output format: text or json")
+	f.set.StringVar(&f.kubeconfig, "kubeconfig", "", "Path to the kubeconfig (default: ~/.kube/config)")
+	f.set.StringVar(&f.configJSON, "config", "", "Extension config as a JSON object, as in eval.yaml")
+	return f
+}
+
+// parse parses the flags and builds the extension config.
+func (f *flags) parse() error {
+	if err := f.set.Parse(f.args); err != nil {
+		return err
+	}
+	if f.set.NArg() > 0 {
+		return f.fail("unexpected argument %q", f.set.Arg(0))
+	}
+	if f.format != formatText && f.format != formatJSON {
+		return f.fail("invalid output format %q: must be text or json", f.format)
+	}
+
+	f.config = map[string]any{}
+	if f.configJSON != "" {
+		if err := json.Unmarshal([]byte(f.configJSON), &f.config); err != nil {
+			return f.fail("invalid -config JSON: %v", err)
+		}
+	}
+	if f.kubeconfig != "" {
+		f.config["kubeconfig"] = f.kubeconfig
+	}
+	return nil
+}
+
+// fail reports a flag error like the flag package does.
+func (f *flags) fail(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintln(f.set.Output(), err)
+	f.set.Usage()
+	return err
+}
+
+// step is one operation to run.
+type step struct {
+	// phase is the task phase of the step, empty for the run command.
+	phase string
+	// name is the step type as written in the task, e.g. kubernetes.wait.
+	name string
+	// operation is the operation name without prefix, empty for steps of other extensions.
+	operation string
+	args      any
+	// err is why the step cannot run, if its definition is invalid.
+	err error
+}
+
+// stepResult is the outcome of a step.
+type stepResult struct {
+	Phase   string               `json:"phase,omitempty"`
+	Step    string               `json:"step"`
+	Skipped string               `json:"skipped,omitempty"`
+	Result  *sdk.OperationResult `json:"result,omitempty"`
+}
+
+// runner runs steps on the extension and reports their results.
+type runner struct {
+	ext    *extension.Extension
+	stdout io.Writer
+	format string
+
+	// task is the name of the task for run-task, empty for run.
+	task    string
+	workdir string
+	steps   []step
+	// phases are the phases to run, in order.
+	phases []string
+}
+
+// prepareRun reads the arguments of a single operation.
+func (r *runner) prepareRun(operation string, opts *flags) error {
+	operation = strings.TrimPrefix(operation, defaultPrefix+".")
+
+	var args any = map[string]any{}
+	workdir := opts.workdir
+	if opts.file != "" {
+		data, err := readInput(opts.file)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(data, &args); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", opts.file, err)
+		}
+		// Accept a step copied from a task file as well as bare arguments
+		if wrapped, ok := args.(map[string]any); ok && len(wrapped) == 1 {
+			if inner, ok := wrapped[defaultPrefix+"."+operation]; ok {
+				args = inner
+			}
+		}
+		if workdir == "" && opts.file != "-" {
+			workdir = filepath.Dir(opts.file)
+		}
+	}
+
+	var err error
+	r.workdir, err = absDir(workdir)
+	r.steps = []step{{name: defaultPrefix + "." + operation, operation: operation, args: args}}
+	return err
+}
+
+// execute runs the prepared steps and returns the exit code.
+func (r *runner) execute(ctx context.Context) int {
+	var results []stepResult
+	failed := false
+	for _, phase := range r.phasesToRun() {
+		phaseFailed := false
+		for _, s := range r.steps {
+			if s.phase != phase {
+				continue
+			}
+			result := stepResult{Phase: s.phase, Step: s.name}
+			switch {
+			case phaseFailed:
+				result.Skipped = "an earlier step of the phase failed"
+			case failed && phase != phaseCleanup:
+				result.Skipped = "an earlier phase failed"
+			case s.err != nil:
+				result.Result = sdk.Failure(s.err)
+			case s.operation == "":
+				result.Skipped = "not a step of this extension"
+			default:
+				result.Result = r.run(ctx, s)
+			}
+			if result.Result != nil && !result.Result.Success {
+				phaseFailed, failed = true, true
+			}
+			results = append(results, result)
+			if r.format == formatText {
+				printText(r.stdout, result)
+			}
+		}
+	}
+
+	if r.format == formatJSON {
+		printJSON(r.stdout, r.task, results, failed)
+	}
+	if failed {
+		return ExitFailed
+	}
+	return ExitOK
+}
+
+// environ returns the process environment as a map.
+func environ() map[string]string {
+	env := map[string]string{}
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// phasesToRun returns the phases of the prepared steps in order. The run command
+// has a single step without phase.
+func (r *runner) phasesToRun() []string {
+	if r.phases == nil {
+		return []string{""}
+	}
+	return r.phases
+}
+
+// run executes one step on the extension.
+func (r *runner) run(ctx context.Context, s step) *sdk.OperationResult {
+	req := &sdk.OperationRequest{Args: s.args}
+	req.Context.Workdir = r.workdir
+	req.Context.Phase = s.phase
+	// Run locally, the caller's environment plays the part of the task environment
+	req.Context.Env = environ()
+
+	if operations := r.ext.Operations(); !slices.Contains(operations, s.operation) {
+		return sdk.Failure(fmt.Errorf("unknown operation %q, available operations: %s", s.operation, strings.Join(operations, ", ")))
+	}
+	result, err := r.ext.Execute(ctx, s.operation, req)
+	if err != nil {
+		return sdk.Failure(err)
+	}
+	return result
+}
+
+// printText prints a step result for humans: a PASS, FAIL or SKIP line followed by
+// the error and the sorted outputs.
+func printText(w io.Writer, result stepResult) {
+	name := result.Step
+	if result.Phase != "" {
+		name = result.Phase + "/" + name
+	}
+	if result.Result == nil {
+		fmt.Fprintf(w, "SKIP %s: %s\n", name, result.Skipped)
+		return
+	}
+
+	status := "PASS"
+	summary := result.Result.Message
+	if !result.Result.Success {
+		status = "FAIL"
+		if summary == "" {
+			summary = result.Result.Error
+		}
+	}
+	fmt.Fprintf(w, "%s %s: %s\n", status, name, firstLine(summary))
+
+	if !result.Result.Success && result.Result.Error != firstLine(summary) {
+		printField(w, "error", result.Result.Error)
+	}
+	keys := make([]string, 0, len(result.Result.Outputs))
+	for key := range result.Result.Outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		printField(w, key, result.Result.Outputs[key])
+	}
+}
+
+// printField prints an indented key and value, indenting continuation lines further.
+func printField(w io.Writer, key, value string) {
+	fmt.Fprintf(w, "    %s: %s\n", key, strings.ReplaceAll(strings.TrimRight(value, "\n"), "\n", "\n      "))
+}
+
+// printJSON prints all step results as one JSON document. For the run command it is
+// the result of the operation, as mcpchecker would receive it.
+func printJSON(w io.Writer, task string, results []stepResult, failed bool) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if task == "" && len(results) == 1 && results[0].Result != nil {
+		_ = encoder.Encode(results[0].Result)
+		return
+	}
+	_ = encoder.Encode(map[string]any{
+		"task":    task,
+		"success": !failed,
+		"steps":   results,
+	})
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// readInput reads a file, or stdin for -.
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// absDir returns dir as an absolute path, defaulting to the working directory.
+func absDir(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workdir: %w", err)
+	}
+	return abs, nil
+}
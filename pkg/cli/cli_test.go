@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+    namespace: apps
+current-context: dev
+users:
+- name: dev-user
+  user:
+    token: secret-token
+`
+
+const testTask = `kind: Task
+apiVersion: gevals/v1alpha2
+metadata:
+  name: demo
+spec:
+  requires:
+    - extension: kubernetes
+      as: k8s
+  setup:
+    - k8s.getCurrentContext: {}
+  verify:
+    - script:
+        inline: "true"
+    - id: context
+      k8s.getContext:
+        name: ${{ steps.getCurrentContext.context }}
+        expect:
+          namespace: apps
+    - k8s.getContext:
+        name: missing
+    - k8s.listContexts: {}
+  cleanup:
+    - k8s.listContexts: {}
+`
+
+const testTypoTask = `kind: Task
+metadata:
+  name: typos
+spec:
+  setup:
+    - kubernetes.delete:
+        apiVersion: v1
+        kind: Namespace
+        metadata:
+          name: demo
+        ignoreNotfound: true
+        propagationPolicy: Cascade
+    - script:
+        inline: "true"
+  verify:
+    - kubernetes.wiat:
+        apiVersion: v1
+    - kubernetes.wait:
+        apiVersion: v1
+        kind: Namespace
+        metadata:
+          name: ${{ steps.create.name }}
+        condition: Active
+        timout: 30s
+  cleanup:
+    - kubernetes.delete: {}
+      kubernetes.cleanup: {}
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := writeFile(t, dir, "kubeconfig", testKubeconfig)
+	step := writeFile(t, dir, "step.yaml", "name: dev\n")
+	wrappedStep := writeFile(t, dir, "wrapped.yaml", "kubernetes.getContext:\n  name: dev\n")
+	missingStep := writeFile(t, dir, "missing.yaml", "name: missing\n")
+	task := writeFile(t, dir, "task.yaml", testTask)
+	writeFile(t, dir, "typo.yaml", testTypoTask)
+	wrongStep := writeFile(t, dir, "wrong-step.yaml", "name: dev\ntimout: 10s\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "run operation",
+			args:       []string{"run", "getCurrentContext", "--kubeconfig", kubeconfig},
+			wantCode:   ExitOK,
+			wantStdout: []string{"PASS kubernetes.getCurrentContext: Current context: dev", "    context: dev"},
+			wantStderr: []string{"INFO  Current context retrieved context=dev"},
+		},
+		{
+			name:       "run operation with arguments file",
+			args:       []string{"run", "kubernetes.getContext", "-f", step, "--kubeconfig", kubeconfig},
+			wantCode:   ExitOK,
+			wantStdout: []string{"    namespace: apps"},
+		},
+		{
+			name:       "run step copied from a task",
+			args:       []string{"run", "getContext", "-f", wrappedStep, "--kubeconfig", kubeconfig},
+			wantCode:   ExitOK,
+			wantStdout: []string{"    cluster: dev-cluster"},
+		},
+		{
+			name:       "run failing operation",
+			args:       []string{"run", "getContext", "-f", missingStep, "--kubeconfig", kubeconfig},
+			wantCode:   ExitFailed,
+			wantStdout: []string{"FAIL kubernetes.getContext:", "    category: agent"},
+		},
+		{
+			name:       "run unknown operation",
+			args:       []string{"run", "apply", "--kubeconfig", kubeconfig},
+			wantCode:   ExitFailed,
+			wantStdout: []string{`unknown operation "apply", available operations: annotate, authCanI`},
+		},
+		{
+			name:     "run task",
+			args:     []string{"run-task", task, "--kubeconfig", kubeconfig},
+			wantCode: ExitFailed,
+			wantStdout: []string{
+				"PASS setup/k8s.getCurrentContext: Current context: dev",
+				"SKIP verify/script: not a step of this extension",
+				"PASS verify/k8s.getContext: Context dev",
+				"FAIL verify/k8s.getContext:",
+				"SKIP verify/k8s.listContexts: an earlier step of the phase failed",
+				"PASS cleanup/k8s.listContexts:",
+			},
+		},
+		{
+			name:       "run task phase",
+			args:       []string{"run-task", task, "--phase", "cleanup,setup", "--kubeconfig", kubeconfig},
+			wantCode:   ExitOK,
+			wantStdout: []string{"PASS setup/k8s.getCurrentContext", "PASS cleanup/k8s.listContexts"},
+		},
+		{
+			name:       "invalid phase",
+			args:       []string{"run-task", task, "--phase", "build", "--kubeconfig", kubeconfig},
+			wantCode:   ExitUsage,
+			wantStderr: []string{`invalid phase "build"`},
+		},
+		{
+			name:       "missing operation",
+			args:       []string{"run", "--kubeconfig", kubeconfig},
+			wantCode:   ExitUsage,
+			wantStderr: []string{"run requires an operation or task file", "Usage:"},
+		},
+		{
+			name:       "invalid output format",
+			args:       []string{"run", "listContexts", "-o", "yaml", "--kubeconfig", kubeconfig},
+			wantCode:   ExitUsage,
+			wantStderr: []string{`invalid output format "yaml"`},
+		},
+		{
+			name:       "missing kubeconfig",
+			args:       []string{"run", "listContexts", "--kubeconfig", filepath.Join(dir, "missing")},
+			wantCode:   ExitUsage,
+			wantStderr: []string{"failed to initialize extension: kubeconfig not found"},
+		},
+		{
+			name:       "missing arguments file",
+			args:       []string{"run", "listContexts", "-f", filepath.Join(dir, "absent.yaml"), "--kubeconfig", kubeconfig},
+			wantCode:   ExitUsage,
+			wantStderr: []string{"failed to read"},
+		},
+		{
+			name:       "run with unknown argument",
+			args:       []string{"run", "getContext", "-f", wrongStep, "--kubeconfig", kubeconfig},
+			wantCode:   ExitFailed,
+			wantStdout: []string{`FAIL kubernetes.getContext: invalid arguments for getContext:`, `timout: unknown property`},
+		},
+		{
+			name:       "validate valid task",
+			args:       []string{"validate", task},
+			wantCode:   ExitOK,
+			wantStdout: []string{"OK: 5 step(s) in 1 file(s)"},
+		},
+		{
+			name:     "validate glob",
+			args:     []string{"validate", filepath.Join(dir, "t*.yaml")},
+			wantCode: ExitFailed,
+			wantStdout: []string{
+				`typo.yaml: setup step 1 (kubernetes.delete): ignoreNotfound: unknown property (did you mean "ignoreNotFound"?)`,
+				`typo.yaml: setup step 1 (kubernetes.delete): propagationPolicy: must be one of Foreground, Background, Orphan, got "Cascade"`,
+				`typo.yaml: verify step 1 (kubernetes.wiat): unknown operation "wiat" (did you mean "wait"?)`,
+				`typo.yaml: verify step 2 (kubernetes.wait): timout: unknown property (did you mean "timeout"?)`,
+				`typo.yaml: step 1 of cleanup must have exactly one step type`,
+				"5 problem(s) in 2 file(s)",
+			},
+		},
+		{
+			name:       "validate no match",
+			args:       []string{"validate", filepath.Join(dir, "none-*.yaml")},
+			wantCode:   ExitUsage,
+			wantStderr: []string{"no task files match"},
+		},
+		{
+			name:       "validate without patterns",
+			args:       []string{"validate"},
+			wantCode:   ExitUsage,
+			wantStderr: []string{"validate requires task files or glob patterns"},
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantCode:   ExitOK,
+			wantStdout: []string{"kubernetes-extension run <operation>", "-phase string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Main(context.Background(), tt.args, &stdout, &stderr)
+
+			if code != tt.wantCode {
+				t.Errorf("Main() = %d, want %d\nstdout:\n%s\nstderr:\n%s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout does not contain %q:\n%s", want, stdout.String())
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr does not contain %q:\n%s", want, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCommandsJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := writeFile(t, dir, "kubeconfig", testKubeconfig)
+	task := writeFile(t, dir, "task.yaml", testTask)
+
+	var stdout, stderr bytes.Buffer
+	if code := Main(context.Background(), []string{"run", "getCurrentContext", "-o", "json", "--kubeconfig", kubeconfig}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Main() = %d, want %d: %s", code, ExitOK, stderr.String())
+	}
+	var result struct {
+		Success bool              `json:"success"`
+		Outputs map[string]string `json:"outputs"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("run output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if !result.Success || result.Outputs["context"] != "dev" {
+		t.Errorf("run result = %+v, want success with context dev", result)
+	}
+
+	stdout.Reset()
+	if code := Main(context.Background(), []string{"run-task", task, "--phase", "setup", "-o", "json", "--kubeconfig", kubeconfig}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Main() = %d, want %d: %s", code, ExitOK, stderr.String())
+	}
+	var report struct {
+		Task    string       `json:"task"`
+		Success bool         `json:"success"`
+		Steps   []stepResult `json:"steps"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("run-task output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if report.Task != "demo" || !report.Success || len(report.Steps) != 1 || report.Steps[0].Step != "k8s.getCurrentContext" {
+		t.Errorf("run-task report = %+v", report)
+	}
+}
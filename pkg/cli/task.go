@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Task phases, in the order mcpchecker runs them.
+const (
+	phaseSetup   = "setup"
+	phaseVerify  = "verify"
+	phaseCleanup = "cleanup"
+)
+
+var taskPhases = []string{phaseSetup, phaseVerify, phaseCleanup}
+
+// taskFile is the part of an mcpchecker task file the run-task command needs.
+type taskFile struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Requires []struct {
+			Extension string `json:"extension"`
+			As        string `json:"as"`
+		} `json:"requires"`
+		Setup   []map[string]any `json:"setup"`
+		Verify  []map[string]any `json:"verify"`
+		Cleanup []map[string]any `json:"cleanup"`
+	} `json:"spec"`
+}
+
+// prepareTask reads the steps of the selected phases from a task file. Steps of other
+// extensions and step types are reported as skipped.
+func (r *runner) prepareTask(path string, opts *flags) error {
+	data, err := readInput(path)
+	if err != nil {
+		return err
+	}
+	task, err := parseTaskFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	r.phases, err = parsePhases(opts.phases)
+	if err != nil {
+		return err
+	}
+
+	workdir := opts.workdir
+	if workdir == "" {
+		workdir = filepath.Dir(path)
+	}
+	if r.workdir, err = absDir(workdir); err != nil {
+		return err
+	}
+
+	r.task = task.Metadata.Name
+	if r.task == "" {
+		r.task = filepath.Base(path)
+	}
+	r.steps = task.steps(r.phases)
+	return nil
+}
+
+// parseTaskFile parses a task file.
+func parseTaskFile(data []byte) (*taskFile, error) {
+	task := &taskFile{}
+	if err := yaml.Unmarshal(data, task); err != nil {
+		return nil, err
+	}
+	if task.Kind != "Task" {
+		return nil, fmt.Errorf("kind must be Task, got %q", task.Kind)
+	}
+	return task, nil
+}
+
+// parsePhases parses a comma-separated list of phases and returns them in the order
+// mcpchecker runs them.
+func parsePhases(value string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, phase := range strings.Split(value, ",") {
+		phase = strings.TrimSpace(phase)
+		if !slices.Contains(taskPhases, phase) {
+			return nil, fmt.Errorf("invalid phase %q: must be one of %s", phase, strings.Join(taskPhases, ", "))
+		}
+		selected[phase] = true
+	}
+
+	var phases []string
+	for _, phase := range taskPhases {
+		if selected[phase] {
+			phases = append(phases, phase)
+		}
+	}
+	return phases, nil
+}
+
+// prefix returns the step prefix of the extension, which the task can rename with as.
+func (t *taskFile) prefix() string {
+	for _, requirement := range t.Spec.Requires {
+		if requirement.Extension == defaultPrefix && requirement.As != "" {
+			return requirement.As
+		}
+	}
+	return defaultPrefix
+}
+
+// steps returns the steps of the given phases.
+func (t *taskFile) steps(phases []string) []step {
+	byPhase := map[string][]map[string]any{
+		phaseSetup:   t.Spec.Setup,
+		phaseVerify:  t.Spec.Verify,
+		phaseCleanup: t.Spec.Cleanup,
+	}
+	prefix := t.prefix() + "."
+
+	var steps []step
+	for _, phase := range phases {
+		for i, raw := range byPhase[phase] {
+			s := step{phase: phase, name: fmt.Sprintf("step %d", i+1)}
+
+			// A step is a single step type with its arguments, next to an optional id
+			var types []string
+			for key := range raw {
+				if key != "id" {
+					types = append(types, key)
+				}
+			}
+			if len(types) != 1 {
+				slices.Sort(types)
+				s.err = fmt.Errorf("step %d of %s must have exactly one step type, got %v", i+1, phase, types)
+				steps = append(steps, s)
+				continue
+			}
+
+			s.name = types[0]
+			if operation, ok := strings.CutPrefix(s.name, prefix); ok {
+				s.operation = operation
+				s.args = raw[s.name]
+			}
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParsePhases(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "setup,verify,cleanup", want: []string{"setup", "verify", "cleanup"}},
+		{value: "cleanup, setup", want: []string{"setup", "cleanup"}},
+		{value: "verify", want: []string{"verify"}},
+		{value: "verify,", wantErr: true},
+		{value: "prompt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePhases(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePhases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) && !tt.wantErr {
+				t.Errorf("parsePhases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		task   string
+		phases []string
+		want   []string
+	}{
+		{
+			name: "default prefix",
+			task: `kind: Task
+spec:
+  setup:
+    - kubernetes.create: {apiVersion: v1, kind: ConfigMap}
+    - http: {url: http://example.com}
+  verify:
+    - id: ready
+      kubernetes.wait: {condition: Ready}
+`,
+			phases: []string{"setup", "verify"},
+			want: []string{
+				"setup kubernetes.create op=create args=map[apiVersion:v1 kind:ConfigMap]",
+				"setup http op= args=<nil>",
+				"verify kubernetes.wait op=wait args=map[condition:Ready]",
+			},
+		},
+		{
+			name: "renamed extension",
+			task: `kind: Task
+spec:
+  requires:
+    - extension: kubernetes
+      as: cluster
+  cleanup:
+    - cluster.delete: {kind: Namespace}
+    - kubernetes.delete: {kind: Namespace}
+`,
+			phases: []string{"setup", "cleanup"},
+			want: []string{
+				"cleanup cluster.delete op=delete args=map[kind:Namespace]",
+				"cleanup kubernetes.delete op= args=<nil>",
+			},
+		},
+		{
+			name: "only selected phases",
+			task: `kind: Task
+spec:
+  setup:
+    - kubernetes.create: {}
+  verify:
+    - kubernetes.wait: {}
+`,
+			phases: []string{"verify"},
+			want:   []string{"verify kubernetes.wait op=wait args=map[]"},
+		},
+		{
+			name: "several step types",
+			task: `kind: Task
+spec:
+  verify:
+    - kubernetes.wait: {}
+      kubernetes.create: {}
+`,
+			phases: []string{"verify"},
+			want:   []string{"verify step 1 op= args=<nil> err=step 1 of verify must have exactly one step type, got [kubernetes.create kubernetes.wait]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := parseTaskFile([]byte(tt.task))
+			if err != nil {
+				t.Fatalf("parseTaskFile() returned error: %v", err)
+			}
+
+			var got []string
+			for _, s := range task.steps(tt.phases) {
+				line := fmt.Sprintf("%s %s op=%s args=%v", s.phase, s.name, s.operation, s.args)
+				if s.err != nil {
+					line += " err=" + s.err.Error()
+				}
+				got = append(got, line)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("steps =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTaskFileRequiresTask(t *testing.T) {
+	if _, err := parseTaskFile([]byte("kind: Eval\n")); err == nil {
+		t.Errorf("parseTaskFile() accepted an eval file")
+	}
+}
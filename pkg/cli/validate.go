@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/mcpchecker/kubernetes-extension/pkg/extension"
+)
+
+// validate checks the kubernetes steps of every task file matching patterns against
+// the operation schemas, without connecting to a cluster, and returns the exit code.
+func validate(patterns []string, stdout, stderr io.Writer) int {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: invalid pattern %q: %v\n", pattern, err)
+			return ExitUsage
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintf(stderr, "error: no task files match %v\n", patterns)
+		return ExitUsage
+	}
+
+	ext := extension.New()
+	problems, steps := 0, 0
+	for _, path := range paths {
+		report := func(format string, args ...any) {
+			fmt.Fprintf(stdout, "%s: %s\n", path, fmt.Sprintf(format, args...))
+			problems++
+		}
+
+		data, err := readInput(path)
+		if err != nil {
+			report("%v", err)
+			continue
+		}
+		task, err := parseTaskFile(data)
+		if err != nil {
+			report("failed to parse: %v", err)
+			continue
+		}
+
+		index := map[string]int{}
+		for _, s := range task.steps(taskPhases) {
+			index[s.phase]++
+			if s.err != nil {
+				report("%v", s.err)
+				continue
+			}
+			if s.operation == "" {
+				continue
+			}
+			steps++
+			for _, problem := range ext.ValidateStep(s.operation, s.args) {
+				report("%s step %d (%s): %s", s.phase, index[s.phase], s.name, problem)
+			}
+		}
+	}
+
+	if problems > 0 {
+		fmt.Fprintf(stdout, "%d problem(s) in %d file(s)\n", problems, len(paths))
+		return ExitFailed
+	}
+	fmt.Fprintf(stdout, "OK: %d step(s) in %d file(s)\n", steps, len(paths))
+	return ExitOK
+}
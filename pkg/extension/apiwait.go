@@ -0,0 +1,231 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// crdSettleWindow is how long after a CRD was created that creating a resource of an
+// unknown type is retried, giving the API server time to start serving the new type.
+const crdSettleWindow = 30 * time.Second
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// handleWaitForAPI waits until a kind is served by the API server. For custom resources
+// it also waits for the CRD to report Established and NamesAccepted. The discovered
+// resource name replaces the guessed plural for later operations.
+func (e *Extension) handleWaitForAPI(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	apiVersion, _ := args["apiVersion"].(string)
+	kind, _ := args["kind"].(string)
+	if apiVersion == "" {
+		return failure(fmt.Errorf("apiVersion is required")), nil
+	}
+	if kind == "" {
+		return failure(fmt.Errorf("kind is required")), nil
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return failure(fmt.Errorf("invalid apiVersion: %w", err)), nil
+	}
+	gvk := gv.WithKind(kind)
+
+	timeoutStr, _ := args["timeout"].(string)
+	if timeoutStr == "" {
+		timeoutStr = "60s"
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+	}
+
+	e.LogInfo(ctx, "Waiting for API", map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"timeout":    timeoutStr,
+	})
+
+	var lastStatus string
+	var resource metav1.APIResource
+	err = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		found, discoverErr := e.discoverResource(ctx, gvk)
+		if discoverErr != nil {
+			lastStatus = fmt.Sprintf("NotServed (%v)", discoverErr)
+			return false, nil
+		}
+		resource = found
+
+		// Only CRDs report readiness; kinds of built-in and aggregated APIs are ready once served
+		if gvk.Group == "" {
+			return true, nil
+		}
+		crd, crdErr := e.findCRD(ctx, gvk)
+		if crdErr != nil {
+			lastStatus = fmt.Sprintf("CRDLookupFailed (%v)", crdErr)
+			return false, nil
+		}
+		if crd == nil {
+			return true, nil
+		}
+		for _, condition := range []string{"Established", "NamesAccepted"} {
+			if status, _ := findCondition(crd, condition); status != "True" {
+				lastStatus = fmt.Sprintf("%s=%s", condition, status)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+
+	if err != nil {
+		e.LogError(ctx, "API wait timed out", map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"lastStatus": lastStatus,
+		})
+		return failureWithCategory(categoryAgent,
+			fmt.Sprintf("API %s %s not available", apiVersion, kind),
+			fmt.Errorf("timed out waiting for %s in %s: last status was %s", kind, apiVersion, lastStatus),
+		), nil
+	}
+
+	e.LogInfo(ctx, "API available", map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"resource":   resource.Name,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%s %s is served as %s", apiVersion, kind, resource.Name),
+		map[string]string{
+			"resource":   resource.Name,
+			"namespaced": fmt.Sprintf("%t", resource.Namespaced),
+		},
+	), nil
+}
+
+// discoverResource looks up the resource serving gvk through discovery and remembers
+// its name, so later operations use it instead of the guessed plural.
+func (e *Extension) discoverResource(ctx context.Context, gvk schema.GroupVersionKind) (metav1.APIResource, error) {
+	resources, err := e.client.ServerResources(ctx, gvk.GroupVersion().String())
+	if err != nil {
+		return metav1.APIResource{}, err
+	}
+
+	for _, resource := range resources {
+		// Skip subresources such as deployments/scale, which share the kind
+		if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+			e.recordMapping(gvk, gvk.GroupVersion().WithResource(resource.Name))
+			return resource, nil
+		}
+	}
+	return metav1.APIResource{}, fmt.Errorf("kind %s not found in %s", gvk.Kind, gvk.GroupVersion())
+}
+
+// findCRD returns the CRD defining gvk, or nil if the kind is not defined by a CRD.
+func (e *Extension) findCRD(ctx context.Context, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	crds, err := e.client.List(ctx, crdGVR, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		if group == gvk.Group && kind == gvk.Kind {
+			return crd, nil
+		}
+	}
+	return nil, nil
+}
+
+// recordMapping remembers the resource discovered for gvk.
+func (e *Extension) recordMapping(gvk schema.GroupVersionKind, gvr schema.GroupVersionResource) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.mappings == nil {
+		e.mappings = make(map[schema.GroupVersionKind]schema.GroupVersionResource)
+	}
+	e.mappings[gvk] = gvr
+}
+
+// gvrFor returns the resource for gvk, preferring a discovered mapping over the guessed plural.
+func (e *Extension) gvrFor(gvk schema.GroupVersionKind) schema.GroupVersionResource {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if gvr, ok := e.mappings[gvk]; ok {
+		return gvr
+	}
+	return gvkToGVR(gvk)
+}
+
+// resourceFor returns the resource for a parsed resource reference.
+func (e *Extension) resourceFor(ref *resourceRef) (schema.GroupVersionResource, error) {
+	gvr, err := ref.gvr()
+	if err != nil {
+		return gvr, err
+	}
+	gv := gvr.GroupVersion()
+	return e.gvrFor(gv.WithKind(ref.kind)), nil
+}
+
+// recordCRDInstall notes that a CRD was just created, opening the settle window.
+func (e *Extension) recordCRDInstall() {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.crdInstalledAt = time.Now()
+}
+
+// crdSettleDeadline returns when the settle window of the latest CRD install ends.
+// The zero time is returned if no CRD was created yet.
+func (e *Extension) crdSettleDeadline() time.Time {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.crdInstalledAt.IsZero() {
+		return time.Time{}
+	}
+	return e.crdInstalledAt.Add(crdSettleWindow)
+}
+
+// isUnknownResource reports whether err means the API server does not serve the
+// resource type at all, as opposed to a missing object or namespace.
+func isUnknownResource(err error) bool {
+	if meta.IsNoMatchError(err) {
+		return true
+	}
+	if !apierrors.IsNotFound(err) {
+		return false
+	}
+	// A missing namespace or object names it in the details; a missing type does not
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		details := status.Status().Details
+		return details == nil || details.Name == ""
+	}
+	return true
+}
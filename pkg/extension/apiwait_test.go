@@ -0,0 +1,268 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// widgetResources is the discovery response for a CRD whose plural cannot be guessed from its kind.
+func widgetResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	if groupVersion != "example.com/v1" {
+		return nil, fmt.Errorf("unknown group version %s", groupVersion)
+	}
+	return []metav1.APIResource{
+		{Name: "widgetz/status", Kind: "Widget", Namespaced: true},
+		{Name: "widgetz", Kind: "Widget", Namespaced: true},
+	}, nil
+}
+
+// widgetCRDs returns a List function serving a Widget CRD with the given condition status.
+func widgetCRDs(status string) func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{
+			Object: map[string]any{
+				"metadata": map[string]any{"name": "widgetz.example.com"},
+				"spec": map[string]any{
+					"group": "example.com",
+					"names": map[string]any{"kind": "Widget", "plural": "widgetz"},
+				},
+				"status": map[string]any{
+					"conditions": []any{
+						map[string]any{"type": "NamesAccepted", "status": "True"},
+						map[string]any{"type": "Established", "status": status},
+					},
+				},
+			},
+		}}}, nil
+	}
+}
+
+func TestHandleWaitForAPI(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           any
+		client         *mockClient
+		wantSuccess    bool
+		wantResource   string
+		wantNamespaced string
+	}{
+		{
+			name: "established CRD",
+			args: map[string]any{"apiVersion": "example.com/v1", "kind": "Widget", "timeout": "1s"},
+			client: &mockClient{
+				serverResourcesFn: widgetResources,
+				listFn:            widgetCRDs("True"),
+			},
+			wantSuccess:    true,
+			wantResource:   "widgetz",
+			wantNamespaced: "true",
+		},
+		{
+			name: "CRD not established",
+			args: map[string]any{"apiVersion": "example.com/v1", "kind": "Widget", "timeout": "1s"},
+			client: &mockClient{
+				serverResourcesFn: widgetResources,
+				listFn:            widgetCRDs("False"),
+			},
+			wantSuccess: false,
+		},
+		{
+			name: "built-in kind",
+			args: map[string]any{"apiVersion": "v1", "kind": "Namespace", "timeout": "1s"},
+			client: &mockClient{
+				serverResourcesFn: func(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+					return []metav1.APIResource{{Name: "namespaces", Kind: "Namespace"}}, nil
+				},
+			},
+			wantSuccess:    true,
+			wantResource:   "namespaces",
+			wantNamespaced: "false",
+		},
+		{
+			name:        "kind not served",
+			args:        map[string]any{"apiVersion": "example.com/v2", "kind": "Widget", "timeout": "1s"},
+			client:      &mockClient{serverResourcesFn: widgetResources},
+			wantSuccess: false,
+		},
+		{
+			name:        "missing kind",
+			args:        map[string]any{"apiVersion": "example.com/v1"},
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleWaitForAPI(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleWaitForAPI() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleWaitForAPI() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+			if got := result.Outputs["resource"]; got != tt.wantResource {
+				t.Errorf("resource output = %q, want %q", got, tt.wantResource)
+			}
+			if got := result.Outputs["namespaced"]; got != tt.wantNamespaced {
+				t.Errorf("namespaced output = %q, want %q", got, tt.wantNamespaced)
+			}
+		})
+	}
+}
+
+func TestWaitForAPIRecordsMapping(t *testing.T) {
+	var deleted schema.GroupVersionResource
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client: &mockClient{
+			serverResourcesFn: widgetResources,
+			listFn:            widgetCRDs("True"),
+			deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+				deleted = gvr
+				return nil
+			},
+		},
+	}
+
+	waitReq := &sdk.OperationRequest{Args: map[string]any{"apiVersion": "example.com/v1", "kind": "Widget"}}
+	if result, _ := ext.handleWaitForAPI(context.Background(), waitReq); !result.Success {
+		t.Fatalf("handleWaitForAPI() failed: %s", result.Error)
+	}
+
+	deleteReq := &sdk.OperationRequest{Args: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"name": "w", "namespace": "default"},
+	}}
+	if result, _ := ext.handleDelete(context.Background(), deleteReq); !result.Success {
+		t.Fatalf("handleDelete() failed: %s", result.Error)
+	}
+	if deleted.Resource != "widgetz" {
+		t.Errorf("delete used resource %q, want the discovered widgetz", deleted.Resource)
+	}
+}
+
+func TestCreateRetriesAfterCRDInstall(t *testing.T) {
+	widgetGR := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+	tests := []struct {
+		name           string
+		crdInstalledAt time.Time
+		wantSuccess    bool
+		wantResource   string
+	}{
+		{
+			name:           "CRD installed recently",
+			crdInstalledAt: time.Now(),
+			wantSuccess:    true,
+			wantResource:   "widgetz",
+		},
+		{
+			name:        "no CRD installed",
+			wantSuccess: false,
+		},
+		{
+			name:           "settle window passed",
+			crdInstalledAt: time.Now().Add(-2 * crdSettleWindow),
+			wantSuccess:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created schema.GroupVersionResource
+			ext := &Extension{
+				Extension:      sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				crdInstalledAt: tt.crdInstalledAt,
+				client: &mockClient{
+					serverResourcesFn: widgetResources,
+					createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+						if gvr.Resource != "widgetz" {
+							return nil, apierrors.NewNotFound(widgetGR, "")
+						}
+						created = gvr
+						return obj, nil
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata":   map[string]any{"name": "w", "namespace": "default"},
+			}}
+			result, err := ext.handleCreate(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleCreate() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleCreate() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if tt.wantSuccess && created.Resource != tt.wantResource {
+				t.Errorf("created resource = %q, want %q", created.Resource, tt.wantResource)
+			}
+		})
+	}
+}
+
+func TestCreateCRDOpensSettleWindow(t *testing.T) {
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client:    &mockClient{},
+	}
+
+	req := &sdk.OperationRequest{Args: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "widgetz.example.com"},
+	}}
+	if result, _ := ext.handleCreate(context.Background(), req); !result.Success {
+		t.Fatalf("handleCreate() failed: %s", result.Error)
+	}
+	if ext.crdSettleDeadline().Before(time.Now()) {
+		t.Errorf("creating a CRD did not open the settle window")
+	}
+}
+
+func TestIsUnknownResource(t *testing.T) {
+	gr := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "resource type not found", err: apierrors.NewNotFound(gr, ""), want: true},
+		{name: "object not found", err: apierrors.NewNotFound(gr, "w"), want: false},
+		{name: "no kind match", err: &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Widget"}}, want: true},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "w", fmt.Errorf("denied")), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnknownResource(tt.err); got != tt.want {
+				t.Errorf("isUnknownResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
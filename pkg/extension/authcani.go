@@ -9,12 +9,12 @@ import (
 
 func (e *Extension) handleAuthCanI(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
 	}
 
 	args, ok := req.Args.(map[string]any)
 	if !ok {
-		return sdk.Failure(fmt.Errorf("args must be an object")), nil
+		return failure(fmt.Errorf("args must be an object")), nil
 	}
 
 	verb, _ := args["verb"].(string)
@@ -25,13 +25,13 @@ func (e *Extension) handleAuthCanI(ctx context.Context, req *sdk.OperationReques
 	resourceName, _ := args["resourceName"].(string)
 
 	if verb == "" {
-		return sdk.Failure(fmt.Errorf("verb is required")), nil
+		return failure(fmt.Errorf("verb is required")), nil
 	}
 	if resource == "" {
-		return sdk.Failure(fmt.Errorf("resource is required")), nil
+		return failure(fmt.Errorf("resource is required")), nil
 	}
 	if as == "" {
-		return sdk.Failure(fmt.Errorf("as is required")), nil
+		return failure(fmt.Errorf("as is required")), nil
 	}
 
 	e.LogInfo(ctx, "Checking permissions", map[string]any{
@@ -48,7 +48,7 @@ func (e *Extension) handleAuthCanI(ctx context.Context, req *sdk.OperationReques
 		e.LogError(ctx, "Failed to check permissions", map[string]any{
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to check permissions: %w", err)), nil
+		return failure(fmt.Errorf("failed to check permissions: %w", err)), nil
 	}
 
 	e.LogInfo(ctx, "Permission check completed", map[string]any{
@@ -60,17 +60,17 @@ func (e *Extension) handleAuthCanI(ctx context.Context, req *sdk.OperationReques
 	if expectArg, hasExpect := args["expect"]; hasExpect {
 		expect, ok := expectArg.(map[string]any)
 		if !ok {
-			return sdk.Failure(fmt.Errorf("expect must be an object")), nil
+			return failure(fmt.Errorf("expect must be an object")), nil
 		}
 
 		if expectedAllowed, hasAllowed := expect["allowed"]; hasAllowed {
 			expectedBool, ok := expectedAllowed.(bool)
 			if !ok {
-				return sdk.Failure(fmt.Errorf("expect.allowed must be a boolean")), nil
+				return failure(fmt.Errorf("expect.allowed must be a boolean")), nil
 			}
 
 			if allowed != expectedBool {
-				return sdk.FailureWithMessage(
+				return failureWithCategory(categoryAgent,
 					fmt.Sprintf("permission check failed: expected allowed=%v but got allowed=%v", expectedBool, allowed),
 					fmt.Errorf("permission expectation not met"),
 				), nil
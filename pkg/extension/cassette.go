@@ -0,0 +1,659 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+// Cassette modes selected by the cassette section of the extension config.
+const (
+	// cassetteRecord sends calls to the cluster and writes them to the cassette.
+	cassetteRecord = "record"
+	// cassetteReplay answers calls from the cassette without a cluster.
+	cassetteReplay = "replay"
+)
+
+// Methods of recorded values the extension generates itself instead of reading them
+// from the cluster. A replay uses the recorded values, so the names derived from them
+// match the recorded calls.
+const (
+	// cassetteRunID records the run ID, which tasks can use in names through run.id.
+	cassetteRunID = "runid"
+	// cassetteNameSuffix records the random suffix of a name, such as that of a namespace.
+	cassetteNameSuffix = "namesuffix"
+)
+
+// cassetteConfig is the cassette section of the extension config.
+type cassetteConfig struct {
+	mode string
+	path string
+}
+
+// parseCassetteConfig reads the cassette section of the extension config. It returns
+// nil if there is none, so operations talk to the cluster as usual.
+func parseCassetteConfig(config any) (*cassetteConfig, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	settings, ok := config.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cassette must be an object")
+	}
+
+	c := &cassetteConfig{}
+	c.mode, _ = settings["mode"].(string)
+	if c.mode != cassetteRecord && c.mode != cassetteReplay {
+		return nil, fmt.Errorf("cassette.mode must be %s or %s", cassetteRecord, cassetteReplay)
+	}
+	c.path, _ = settings["path"].(string)
+	if c.path == "" {
+		return nil, fmt.Errorf("cassette.path is required")
+	}
+	return c, nil
+}
+
+// cassette is the file format of recorded API traffic: the calls the extension made
+// through its ResourceClient and what they returned, in order.
+type cassette struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+// cassetteRequest describes a ResourceClient call. During replay, calls are matched on
+// all fields except the body, so objects may carry generated or time-dependent values.
+type cassetteRequest struct {
+	Method        string             `json:"method"`
+	Group         string             `json:"group,omitempty"`
+	Version       string             `json:"version,omitempty"`
+	Resource      string             `json:"resource,omitempty"`
+	Subresource   string             `json:"subresource,omitempty"`
+	Namespace     string             `json:"namespace,omitempty"`
+	Name          string             `json:"name,omitempty"`
+	LabelSelector string             `json:"labelSelector,omitempty"`
+	FieldSelector string             `json:"fieldSelector,omitempty"`
+	PatchType     string             `json:"patchType,omitempty"`
+	Proxy         *ProxyRequest      `json:"proxy,omitempty"`
+	Access        *accessRequest     `json:"access,omitempty"`
+	ViewConfig    *ViewConfigOptions `json:"viewConfig,omitempty"`
+	// Body is the object sent by create and update, or the patch.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// accessRequest holds the arguments of CheckAccess.
+type accessRequest struct {
+	User         string `json:"user"`
+	Verb         string `json:"verb"`
+	Resource     string `json:"resource"`
+	APIGroup     string `json:"apiGroup,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	ResourceName string `json:"resourceName,omitempty"`
+}
+
+// cassetteResponse holds what a ResourceClient call returned. Only the fields of the
+// method are set.
+type cassetteResponse struct {
+	// Object is the returned object, or list for list calls.
+	Object    json.RawMessage      `json:"object,omitempty"`
+	Resources []metav1.APIResource `json:"resources,omitempty"`
+	Proxy     *ProxyResponse       `json:"proxy,omitempty"`
+	Allowed   bool                 `json:"allowed,omitempty"`
+	Reason    string               `json:"reason,omitempty"`
+	Contexts  []ContextInfo        `json:"contexts,omitempty"`
+	// Text is the current context name or the rendered kubeconfig.
+	Text  string         `json:"text,omitempty"`
+	Error *cassetteError `json:"error,omitempty"`
+}
+
+// cassetteError is a recorded error. API errors keep their status, so they are
+// classified and retried the same way when replayed.
+type cassetteError struct {
+	Status  *metav1.Status `json:"status,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+func newCassetteError(err error) *cassetteError {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		s := status.Status()
+		return &cassetteError{Status: &s}
+	}
+	return &cassetteError{Message: err.Error()}
+}
+
+func (e *cassetteError) err() error {
+	if e == nil {
+		return nil
+	}
+	if e.Status != nil {
+		return &apierrors.StatusError{ErrStatus: *e.Status}
+	}
+	return errors.New(e.Message)
+}
+
+// newResourceRequest describes a call on a resource.
+func newResourceRequest(method string, gvr schema.GroupVersionResource, name, namespace string, subresources []string) cassetteRequest {
+	return cassetteRequest{
+		Method:      method,
+		Group:       gvr.Group,
+		Version:     gvr.Version,
+		Resource:    gvr.Resource,
+		Subresource: strings.Join(subresources, "/"),
+		Namespace:   namespace,
+		Name:        name,
+	}
+}
+
+// key identifies requests that are answered from the same recorded responses.
+func (r cassetteRequest) key() string {
+	r.Body = nil
+	key, _ := json.Marshal(r)
+	return string(key)
+}
+
+// String describes the request in error messages, e.g. "get apps/v1 deployments demo/web".
+func (r cassetteRequest) String() string {
+	parts := []string{r.Method}
+	if r.Resource != "" {
+		resource := schema.GroupVersion{Group: r.Group, Version: r.Version}.String() + " " + r.Resource
+		if r.Subresource != "" {
+			resource += "/" + r.Subresource
+		}
+		parts = append(parts, resource)
+	}
+	if r.Name != "" || r.Namespace != "" {
+		parts = append(parts, strings.TrimPrefix(r.Namespace+"/"+r.Name, "/"))
+	}
+	if r.LabelSelector != "" {
+		parts = append(parts, "labelSelector="+r.LabelSelector)
+	}
+	if r.FieldSelector != "" {
+		parts = append(parts, "fieldSelector="+r.FieldSelector)
+	}
+	if r.Proxy != nil {
+		parts = append(parts, fmt.Sprintf("%s %s/%s/%s%s", r.Proxy.Method, r.Proxy.Resource, r.Proxy.Namespace, r.Proxy.Name, r.Proxy.Path))
+	}
+	if r.Access != nil {
+		parts = append(parts, fmt.Sprintf("%s %s as %s", r.Access.Verb, r.Access.Resource, r.Access.User))
+	}
+	return strings.Join(parts, " ")
+}
+
+// encodeObject encodes an object or list for a cassette.
+func encodeObject(content map[string]any) json.RawMessage {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// decodeObject decodes a recorded object, with whole numbers as int64 like the
+// dynamic client returns them.
+func decodeObject(data json.RawMessage) (map[string]any, error) {
+	content := map[string]any{}
+	if len(data) == 0 {
+		return content, nil
+	}
+	if err := utiljson.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("invalid recorded object: %w", err)
+	}
+	return content, nil
+}
+
+// cassetteRecorder writes recorded interactions to a cassette file.
+type cassetteRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newCassetteRecorder starts a new cassette at path, replacing an existing one.
+func newCassetteRecorder(path string) (*cassetteRecorder, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to write cassette: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if _, err := file.WriteString("interactions:\n"); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return &cassetteRecorder{file: file}, nil
+}
+
+// record appends an interaction to the cassette. Each interaction is written as it
+// happens, so the cassette is complete even if the extension is stopped without notice.
+func (r *cassetteRecorder) record(req cassetteRequest, resp cassetteResponse) error {
+	data, err := yaml.Marshal([]interaction{{Request: req, Response: resp}})
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return fmt.Errorf("failed to write cassette: recorder is closed")
+	}
+	if _, err := r.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Close closes the cassette file. Interactions recorded afterwards fail.
+func (r *cassetteRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// recordValue records a value generated by the extension under method.
+func (r *cassetteRecorder) recordValue(method, value string) error {
+	return r.record(cassetteRequest{Method: method}, cassetteResponse{Text: value})
+}
+
+// encodeSecret encodes a Secret, or a list of Secrets, for a cassette with its data
+// redacted. The object passed in is left unchanged.
+func encodeSecret(content map[string]any) json.RawMessage {
+	redacted := runtime.DeepCopyJSON(content)
+	redactSecret(redacted)
+	if items, ok := redacted["items"].([]any); ok {
+		for _, item := range items {
+			if obj, ok := item.(map[string]any); ok {
+				redactSecret(obj)
+			}
+		}
+	}
+	return encodeObject(redacted)
+}
+
+// recordingClient passes calls to a ResourceClient and records them in a cassette.
+type recordingClient struct {
+	client   ResourceClient
+	recorder *cassetteRecorder
+}
+
+func newRecordingClient(client ResourceClient, recorder *cassetteRecorder) *recordingClient {
+	return &recordingClient{client: client, recorder: recorder}
+}
+
+// record records a call and its error, and returns the error of the call, or the
+// error writing the cassette.
+func (c *recordingClient) record(req cassetteRequest, resp cassetteResponse, err error) error {
+	if err != nil {
+		resp = cassetteResponse{Error: newCassetteError(err)}
+	}
+	if recordErr := c.recorder.record(req, resp); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+// encode encodes an object or list of gvr for the cassette. The data of Secrets is
+// redacted, so cassettes can be committed without leaking it.
+func (c *recordingClient) encode(gvr schema.GroupVersionResource, content map[string]any) json.RawMessage {
+	if isSecrets(gvr) {
+		return encodeSecret(content)
+	}
+	return encodeObject(content)
+}
+
+// recordObject records a call on gvr returning an object.
+func (c *recordingClient) recordObject(gvr schema.GroupVersionResource, req cassetteRequest, obj *unstructured.Unstructured, err error) (*unstructured.Unstructured, error) {
+	resp := cassetteResponse{}
+	if obj != nil {
+		resp.Object = c.encode(gvr, obj.Object)
+	}
+	if err := c.record(req, resp, err); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (c *recordingClient) Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	req := newResourceRequest("create", gvr, obj.GetName(), namespace, nil)
+	req.Body = c.encode(gvr, obj.Object)
+	created, err := c.client.Create(ctx, gvr, obj, namespace)
+	return c.recordObject(gvr, req, created, err)
+}
+
+func (c *recordingClient) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	req := newResourceRequest("update", gvr, obj.GetName(), namespace, subresources)
+	req.Body = c.encode(gvr, obj.Object)
+	updated, err := c.client.Update(ctx, gvr, obj, namespace, subresources...)
+	return c.recordObject(gvr, req, updated, err)
+}
+
+func (c *recordingClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	req := newResourceRequest("patch", gvr, name, namespace, subresources)
+	req.PatchType = string(patchType)
+	if json.Valid(data) {
+		req.Body = data
+		if isSecrets(gvr) {
+			req.Body = redactSecretPatch(data)
+		}
+	}
+	patched, err := c.client.Patch(ctx, gvr, name, namespace, patchType, data, subresources...)
+	return c.recordObject(gvr, req, patched, err)
+}
+
+func (c *recordingClient) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	obj, err := c.client.Get(ctx, gvr, name, namespace, subresources...)
+	return c.recordObject(gvr, newResourceRequest("get", gvr, name, namespace, subresources), obj, err)
+}
+
+func (c *recordingClient) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	req := newResourceRequest("list", gvr, "", namespace, nil)
+	req.LabelSelector, req.FieldSelector = opts.LabelSelector, opts.FieldSelector
+
+	list, err := c.client.List(ctx, gvr, namespace, opts)
+	resp := cassetteResponse{}
+	if list != nil {
+		resp.Object = c.encode(gvr, list.UnstructuredContent())
+	}
+	if err := c.record(req, resp, err); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *recordingClient) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+	err := c.client.Delete(ctx, gvr, name, namespace, opts)
+	return c.record(newResourceRequest("delete", gvr, name, namespace, nil), cassetteResponse{}, err)
+}
+
+func (c *recordingClient) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	req := newResourceRequest("deletecollection", gvr, "", namespace, nil)
+	req.LabelSelector, req.FieldSelector = listOpts.LabelSelector, listOpts.FieldSelector
+	err := c.client.DeleteCollection(ctx, gvr, namespace, opts, listOpts)
+	return c.record(req, cassetteResponse{}, err)
+}
+
+func (c *recordingClient) ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	gv, _ := schema.ParseGroupVersion(groupVersion)
+	resources, err := c.client.ServerResources(ctx, groupVersion)
+	req := cassetteRequest{Method: "serverresources", Group: gv.Group, Version: gv.Version}
+	if err := c.record(req, cassetteResponse{Resources: resources}, err); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (c *recordingClient) Proxy(ctx context.Context, proxyReq ProxyRequest) (*ProxyResponse, error) {
+	resp, err := c.client.Proxy(ctx, proxyReq)
+	if err := c.record(cassetteRequest{Method: "proxy", Proxy: &proxyReq}, cassetteResponse{Proxy: resp}, err); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *recordingClient) CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
+	req := cassetteRequest{Method: "checkaccess", Access: &accessRequest{
+		User: user, Verb: verb, Resource: resource, APIGroup: apiGroup, Namespace: namespace, ResourceName: resourceName,
+	}}
+	allowed, reason, err := c.client.CheckAccess(ctx, user, verb, resource, apiGroup, namespace, resourceName)
+	if err := c.record(req, cassetteResponse{Allowed: allowed, Reason: reason}, err); err != nil {
+		return false, "", err
+	}
+	return allowed, reason, nil
+}
+
+func (c *recordingClient) ListContexts(ctx context.Context) ([]ContextInfo, error) {
+	contexts, err := c.client.ListContexts(ctx)
+	if err := c.record(cassetteRequest{Method: "listcontexts"}, cassetteResponse{Contexts: contexts}, err); err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
+
+func (c *recordingClient) GetCurrentContext(ctx context.Context) (string, error) {
+	name, err := c.client.GetCurrentContext(ctx)
+	if err := c.record(cassetteRequest{Method: "getcurrentcontext"}, cassetteResponse{Text: name}, err); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (c *recordingClient) ViewConfig(ctx context.Context, opts ViewConfigOptions) (string, error) {
+	config, err := c.client.ViewConfig(ctx, opts)
+	recorded := config
+	if opts.Raw && err == nil {
+		// Credentials are never written to the cassette, so raw output is replayed redacted
+		recorded = redactKubeconfig(config)
+	}
+	if err := c.record(cassetteRequest{Method: "viewconfig", ViewConfig: &opts}, cassetteResponse{Text: recorded}, err); err != nil {
+		return "", err
+	}
+	return config, nil
+}
+
+func (c *recordingClient) KubeconfigPath() string {
+	return c.client.KubeconfigPath()
+}
+
+func (c *recordingClient) WithKubeconfig(path string) ResourceClient {
+	return newRecordingClient(c.client.WithKubeconfig(path), c.recorder)
+}
+
+// cassettePlayer serves recorded responses. Each distinct request is answered with
+// its recorded responses in order, and then with the last one again, so polling
+// operations see the final recorded state for as long as they ask.
+type cassettePlayer struct {
+	mu   sync.Mutex
+	path string
+	// responses holds the recorded responses of each request key, in order.
+	responses map[string][]cassetteResponse
+	// served counts the responses served for each request key.
+	served map[string]int
+}
+
+// loadCassette reads a cassette file for replay.
+func loadCassette(path string) (*cassettePlayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	p := &cassettePlayer{path: path, responses: map[string][]cassetteResponse{}, served: map[string]int{}}
+	for _, i := range c.Interactions {
+		key := i.Request.key()
+		p.responses[key] = append(p.responses[key], i.Response)
+	}
+	return p, nil
+}
+
+// play returns the next recorded response to req.
+func (p *cassettePlayer) play(req cassetteRequest) (cassetteResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := req.key()
+	responses := p.responses[key]
+	if len(responses) == 0 {
+		return cassetteResponse{}, fmt.Errorf("no recorded response for %s in cassette %s", req, p.path)
+	}
+	i := min(p.served[key], len(responses)-1)
+	p.served[key]++
+	return responses[i], nil
+}
+
+// replayingClient answers every call from a cassette instead of a cluster.
+type replayingClient struct {
+	player         *cassettePlayer
+	kubeconfigPath string
+}
+
+func newReplayingClient(player *cassettePlayer, kubeconfigPath string) *replayingClient {
+	return &replayingClient{player: player, kubeconfigPath: kubeconfigPath}
+}
+
+// play returns the next recorded response to req, or the recorded error.
+func (c *replayingClient) play(req cassetteRequest) (cassetteResponse, error) {
+	resp, err := c.player.play(req)
+	if err != nil {
+		return resp, err
+	}
+	return resp, resp.Error.err()
+}
+
+// playObject returns the recorded object of a call returning one.
+func (c *replayingClient) playObject(req cassetteRequest) (*unstructured.Unstructured, error) {
+	resp, err := c.play(req)
+	if err != nil {
+		return nil, err
+	}
+	content, err := decodeObject(resp.Object)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+func (c *replayingClient) Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	return c.playObject(newResourceRequest("create", gvr, obj.GetName(), namespace, nil))
+}
+
+func (c *replayingClient) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.playObject(newResourceRequest("update", gvr, obj.GetName(), namespace, subresources))
+}
+
+func (c *replayingClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	req := newResourceRequest("patch", gvr, name, namespace, subresources)
+	req.PatchType = string(patchType)
+	return c.playObject(req)
+}
+
+func (c *replayingClient) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.playObject(newResourceRequest("get", gvr, name, namespace, subresources))
+}
+
+func (c *replayingClient) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	req := newResourceRequest("list", gvr, "", namespace, nil)
+	req.LabelSelector, req.FieldSelector = opts.LabelSelector, opts.FieldSelector
+	resp, err := c.play(req)
+	if err != nil {
+		return nil, err
+	}
+	content, err := decodeObject(resp.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{Object: content}
+	items, _ := content["items"].([]any)
+	delete(content, "items")
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+		}
+	}
+	return list, nil
+}
+
+func (c *replayingClient) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+	_, err := c.play(newResourceRequest("delete", gvr, name, namespace, nil))
+	return err
+}
+
+func (c *replayingClient) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	req := newResourceRequest("deletecollection", gvr, "", namespace, nil)
+	req.LabelSelector, req.FieldSelector = listOpts.LabelSelector, listOpts.FieldSelector
+	_, err := c.play(req)
+	return err
+}
+
+func (c *replayingClient) ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	gv, _ := schema.ParseGroupVersion(groupVersion)
+	resp, err := c.play(cassetteRequest{Method: "serverresources", Group: gv.Group, Version: gv.Version})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+func (c *replayingClient) Proxy(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+	resp, err := c.play(cassetteRequest{Method: "proxy", Proxy: &req})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Proxy == nil {
+		return &ProxyResponse{}, nil
+	}
+	return resp.Proxy, nil
+}
+
+func (c *replayingClient) CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
+	resp, err := c.play(cassetteRequest{Method: "checkaccess", Access: &accessRequest{
+		User: user, Verb: verb, Resource: resource, APIGroup: apiGroup, Namespace: namespace, ResourceName: resourceName,
+	}})
+	if err != nil {
+		return false, "", err
+	}
+	return resp.Allowed, resp.Reason, nil
+}
+
+func (c *replayingClient) ListContexts(ctx context.Context) ([]ContextInfo, error) {
+	resp, err := c.play(cassetteRequest{Method: "listcontexts"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Contexts, nil
+}
+
+func (c *replayingClient) GetCurrentContext(ctx context.Context) (string, error) {
+	resp, err := c.play(cassetteRequest{Method: "getcurrentcontext"})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *replayingClient) ViewConfig(ctx context.Context, opts ViewConfigOptions) (string, error) {
+	resp, err := c.play(cassetteRequest{Method: "viewconfig", ViewConfig: &opts})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *replayingClient) KubeconfigPath() string {
+	return c.kubeconfigPath
+}
+
+func (c *replayingClient) WithKubeconfig(path string) ResourceClient {
+	return newReplayingClient(c.player, path)
+}
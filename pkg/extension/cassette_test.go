@@ -0,0 +1,376 @@
+package extension
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestParseCassetteConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  any
+		want    *cassetteConfig
+		wantErr string
+	}{
+		{name: "absent"},
+		{
+			name:   "record",
+			config: map[string]any{"mode": "record", "path": "verify.yaml"},
+			want:   &cassetteConfig{mode: cassetteRecord, path: "verify.yaml"},
+		},
+		{
+			name:   "replay",
+			config: map[string]any{"mode": "replay", "path": "verify.yaml"},
+			want:   &cassetteConfig{mode: cassetteReplay, path: "verify.yaml"},
+		},
+		{name: "not an object", config: "record", wantErr: "cassette must be an object"},
+		{name: "invalid mode", config: map[string]any{"mode": "play", "path": "verify.yaml"}, wantErr: "cassette.mode must be record or replay"},
+		{name: "missing path", config: map[string]any{"mode": "replay"}, wantErr: "cassette.path is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCassetteConfig(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseCassetteConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCassetteConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCassetteConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// cassetteCalls makes the same calls on client for recording and replaying, and
+// returns their results.
+func cassetteCalls(t *testing.T, client ResourceClient) []any {
+	t.Helper()
+	ctx := context.Background()
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	var results []any
+	add := func(values ...any) {
+		for _, value := range values {
+			if err, ok := value.(error); ok {
+				value = err.Error()
+			}
+			results = append(results, value)
+		}
+	}
+
+	// Polling the same object sees every recorded state, then the last one again
+	for range 3 {
+		obj, err := client.Get(ctx, podsGVR, "web", "demo")
+		add(obj, err)
+	}
+	_, err := client.Get(ctx, podsGVR, "gone", "demo")
+	add(apierrors.IsNotFound(err), err)
+
+	scale, err := client.Get(ctx, deploymentsGVR, "web", "demo", "scale")
+	add(scale, err)
+	list, err := client.List(ctx, podsGVR, "demo", metav1.ListOptions{LabelSelector: "app=web"})
+	add(list, err)
+	patched, err := client.Patch(ctx, podsGVR, "web", "demo", types.MergePatchType, []byte(`{"metadata":{"labels":{"tier":"front"}}}`))
+	add(patched, err)
+	add(client.Delete(ctx, podsGVR, "web", "demo", metav1.DeleteOptions{}))
+
+	resources, err := client.ServerResources(ctx, "apps/v1")
+	add(resources, err)
+	resp, err := client.Proxy(ctx, ProxyRequest{Resource: "services", Namespace: "demo", Name: "web", Path: "/healthz"})
+	add(resp, err)
+	allowed, reason, err := client.CheckAccess(ctx, "alice", "get", "pods", "", "demo", "")
+	add(allowed, reason, err)
+	current, err := client.GetCurrentContext(ctx)
+	add(current, err)
+	return results
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	polls := 0
+	mock := &mockClient{
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			if name == "gone" {
+				return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+			}
+			polls++
+			ready := "False"
+			if polls > 1 {
+				ready = "True"
+			}
+			return &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]any{"name": name, "namespace": namespace, "generation": int64(polls)},
+				"status":     map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": ready}}},
+			}}, nil
+		},
+		getSubresourceFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+			return &unstructured.Unstructured{Object: map[string]any{
+				"kind": "Scale",
+				"spec": map[string]any{"replicas": int64(3)},
+			}}, nil
+		},
+		listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			return &unstructured.UnstructuredList{
+				Object: map[string]any{"apiVersion": "v1", "kind": "PodList", "metadata": map[string]any{"resourceVersion": "42"}},
+				Items: []unstructured.Unstructured{
+					{Object: map[string]any{"kind": "Pod", "metadata": map[string]any{"name": "web-1"}}},
+					{Object: map[string]any{"kind": "Pod", "metadata": map[string]any{"name": "web-2"}}},
+				},
+			}, nil
+		},
+		patchFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+			return &unstructured.Unstructured{Object: map[string]any{
+				"kind":     "Pod",
+				"metadata": map[string]any{"name": name, "labels": map[string]any{"tier": "front"}},
+			}}, nil
+		},
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, name, nil)
+		},
+		serverResourcesFn: func(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+			return []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}}, nil
+		},
+		proxyFn: func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+			return &ProxyResponse{StatusCode: 200, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("ok")}, nil
+		},
+		checkAccessFn: func(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
+			return true, "RBAC: allowed", nil
+		},
+		getCurrentContextFn: func(ctx context.Context) (string, error) {
+			return "dev", nil
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "cassettes", "verify.yaml")
+	recorder, err := newCassetteRecorder(path)
+	if err != nil {
+		t.Fatalf("newCassetteRecorder() error = %v", err)
+	}
+	recorded := cassetteCalls(t, newRecordingClient(mock, recorder))
+
+	player, err := loadCassette(path)
+	if err != nil {
+		t.Fatalf("loadCassette() error = %v", err)
+	}
+	replayed := cassetteCalls(t, newReplayingClient(player, ""))
+
+	if len(replayed) != len(recorded) {
+		t.Fatalf("replayed %d results, recorded %d", len(replayed), len(recorded))
+	}
+	for i := range recorded {
+		if !reflect.DeepEqual(replayed[i], recorded[i]) {
+			t.Errorf("result %d replayed as %#v, recorded %#v", i, replayed[i], recorded[i])
+		}
+	}
+	if polls != 3 {
+		t.Errorf("recording polled the cluster %d times, want 3", polls)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"method: patch", "patchType: application/merge-patch+json", "tier: front", "reason: Conflict"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("cassette does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestReplayUnrecordedRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.yaml")
+	if _, err := newCassetteRecorder(path); err != nil {
+		t.Fatal(err)
+	}
+	player, err := loadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newReplayingClient(player, "")
+	_, err = client.Get(context.Background(), schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "web", "demo")
+	want := "no recorded response for get apps/v1 deployments demo/web in cassette " + path
+	if err == nil || err.Error() != want {
+		t.Errorf("Get() error = %v, want %q", err, want)
+	}
+}
+
+func TestInitializeWithReplayedCassette(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "verify.yaml")
+	recorder, err := newCassetteRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording := newRecordingClient(&mockClient{
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			return &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]any{"name": name, "namespace": namespace},
+				"status":     map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": "True"}}},
+			}}, nil
+		},
+	}, recorder)
+	if _, err := recording.Get(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "web", "demo"); err != nil {
+		t.Fatal(err)
+	}
+
+	ext := New()
+	config := map[string]any{
+		"kubeconfig": filepath.Join(dir, "missing-kubeconfig"),
+		"cassette":   map[string]any{"mode": "replay", "path": path},
+	}
+	if err := ext.Initialize(config); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	req := &sdk.OperationRequest{Args: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "web", "namespace": "demo"},
+		"condition":  "Ready",
+		"timeout":    "5s",
+	}}
+	req.Context.Workdir = dir
+	ext.SetLogOutput(&strings.Builder{})
+	result, err := ext.Execute(context.Background(), "wait", req)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !result.Success {
+		t.Errorf("wait failed on the replayed cassette: %s", result.Error)
+	}
+}
+
+func TestCassetteRedactsSecrets(t *testing.T) {
+	secret := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]any{"name": "db", "namespace": "demo"},
+			"data":       map[string]any{"password": "aHVudGVyMg=="},
+			"stringData": map[string]any{"token": "plain-token"},
+		}}
+	}
+	mock := &mockClient{
+		createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+			return secret(), nil
+		},
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			return secret(), nil
+		},
+		listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			return &unstructured.UnstructuredList{Object: map[string]any{"kind": "SecretList"}, Items: []unstructured.Unstructured{*secret()}}, nil
+		},
+		patchFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+			return secret(), nil
+		},
+		viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
+			return testKubeconfig, nil
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	recorder, err := newCassetteRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newRecordingClient(mock, recorder)
+	ctx := context.Background()
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	created, err := client.Create(ctx, secrets, secret(), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(ctx, secrets, "db", "demo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.List(ctx, secrets, "demo", metav1.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Patch(ctx, secrets, "db", "demo", types.MergePatchType, []byte(`{"stringData":{"token":"new-token"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Patch(ctx, secrets, "db", "demo", types.JSONPatchType, []byte(`[{"op":"replace","path":"/data/password","value":"bmV3"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ViewConfig(ctx, ViewConfigOptions{Raw: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the cassette is redacted, not what the caller gets
+	if password, _, _ := unstructured.NestedString(created.Object, "data", "password"); password != "aHVudGVyMg==" {
+		t.Errorf("created Secret has password %q, want it unchanged", password)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"aHVudGVyMg==", "plain-token", "new-token", "bmV3", "dev-token", "prod-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "password: REDACTED") {
+		t.Errorf("cassette does not contain the redacted password:\n%s", data)
+	}
+}
+
+func TestCassetteReplaysGeneratedNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "namespace.yaml")
+	run := func(mode string) *sdk.OperationResult {
+		ext := New()
+		ext.SetLogOutput(&strings.Builder{})
+		config := map[string]any{
+			"kubeconfig": filepath.Join(dir, "missing-kubeconfig"),
+			"cassette":   map[string]any{"mode": mode, "path": path},
+		}
+		if mode == cassetteRecord {
+			config["backend"] = backendFake
+		}
+		if err := ext.Initialize(config); err != nil {
+			t.Fatalf("Initialize() in %s mode error = %v", mode, err)
+		}
+		defer ext.Close()
+		return execute(t, ext, "createNamespace", map[string]any{})
+	}
+
+	recorded := run(cassetteRecord)
+	if !recorded.Success {
+		t.Fatalf("createNamespace failed while recording: %s", recorded.Error)
+	}
+	replayed := run(cassetteReplay)
+	if !replayed.Success {
+		t.Fatalf("createNamespace failed on replay: %s", replayed.Error)
+	}
+	if replayed.Outputs["name"] != recorded.Outputs["name"] {
+		t.Errorf("replayed namespace %q, recorded %q", replayed.Outputs["name"], recorded.Outputs["name"])
+	}
+}
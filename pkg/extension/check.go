@@ -0,0 +1,88 @@
+package extension
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// objectCheck is the state wait and consistently check on objects: either a condition
+// in status.conditions with an expected status, or the value found at a JSONPath.
+type objectCheck struct {
+	// condition and status describe a condition check.
+	condition string
+	status    string
+
+	// expression, path and value describe a JSONPath check. Without a value, the
+	// check passes as soon as the path yields a non-empty result.
+	expression string
+	path       *jsonpath.JSONPath
+	value      string
+	hasValue   bool
+}
+
+// parseObjectCheck reads the condition and status, or the jsonPath and value arguments.
+func parseObjectCheck(args map[string]any) (*objectCheck, error) {
+	condition, _ := args["condition"].(string)
+	expression, _ := args["jsonPath"].(string)
+
+	switch {
+	case condition != "" && expression != "":
+		return nil, fmt.Errorf("condition and jsonPath cannot be combined")
+	case condition != "":
+		status, _ := args["status"].(string)
+		if status == "" {
+			status = "True"
+		}
+		return &objectCheck{condition: condition, status: status}, nil
+	case expression != "":
+		// Like kubectl, accept the expression with or without the surrounding braces
+		if !strings.HasPrefix(expression, "{") {
+			expression = "{" + expression + "}"
+		}
+		path := jsonpath.New("check").AllowMissingKeys(true)
+		if err := path.Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid jsonPath %q: %w", expression, err)
+		}
+		check := &objectCheck{expression: expression, path: path}
+		if value, ok := args["value"]; ok {
+			check.value = fmt.Sprint(value)
+			check.hasValue = true
+		}
+		return check, nil
+	default:
+		return nil, fmt.Errorf("condition or jsonPath is required")
+	}
+}
+
+// evaluate returns the current state of obj for the check and whether it passes.
+func (c *objectCheck) evaluate(obj *unstructured.Unstructured) (string, bool) {
+	if c.path == nil {
+		current, found := findCondition(obj, c.condition)
+		return current, found && current == c.status
+	}
+
+	var buf bytes.Buffer
+	if err := c.path.Execute(&buf, obj.Object); err != nil {
+		return fmt.Sprintf("JSONPathError (%v)", err), false
+	}
+	current := buf.String()
+	if !c.hasValue {
+		return current, current != ""
+	}
+	return current, current == c.value
+}
+
+// String describes the check for messages, as Type=Status or {.path}=value.
+func (c *objectCheck) String() string {
+	if c.path == nil {
+		return c.condition + "=" + c.status
+	}
+	if !c.hasValue {
+		return c.expression
+	}
+	return c.expression + "=" + c.value
+}
@@ -0,0 +1,105 @@
+package extension
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestObjectCheck(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"replicas": int64(2)},
+		"status": map[string]any{
+			"phase": "Running",
+			"conditions": []any{
+				map[string]any{"type": "Available", "status": "True"},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantErr     bool
+		wantString  string
+		wantCurrent string
+		wantMet     bool
+	}{
+		{
+			name:        "condition with default status",
+			args:        map[string]any{"condition": "Available"},
+			wantString:  "Available=True",
+			wantCurrent: "True",
+			wantMet:     true,
+		},
+		{
+			name:        "condition with other status",
+			args:        map[string]any{"condition": "Available", "status": "False"},
+			wantString:  "Available=False",
+			wantCurrent: "True",
+			wantMet:     false,
+		},
+		{
+			name:        "missing condition",
+			args:        map[string]any{"condition": "Progressing"},
+			wantString:  "Progressing=True",
+			wantCurrent: "ConditionNotFound",
+			wantMet:     false,
+		},
+		{
+			name:        "jsonPath with value",
+			args:        map[string]any{"jsonPath": "{.status.phase}", "value": "Running"},
+			wantString:  "{.status.phase}=Running",
+			wantCurrent: "Running",
+			wantMet:     true,
+		},
+		{
+			name:        "jsonPath without braces and numeric value",
+			args:        map[string]any{"jsonPath": ".spec.replicas", "value": float64(2)},
+			wantString:  "{.spec.replicas}=2",
+			wantCurrent: "2",
+			wantMet:     true,
+		},
+		{
+			name:        "jsonPath existence",
+			args:        map[string]any{"jsonPath": "{.status.loadBalancer.ingress}"},
+			wantString:  "{.status.loadBalancer.ingress}",
+			wantCurrent: "",
+			wantMet:     false,
+		},
+		{
+			name:    "condition and jsonPath",
+			args:    map[string]any{"condition": "Available", "jsonPath": "{.status.phase}"},
+			wantErr: true,
+		},
+		{
+			name:    "neither condition nor jsonPath",
+			args:    map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid jsonPath",
+			args:    map[string]any{"jsonPath": "{.status[}"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := parseObjectCheck(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseObjectCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := check.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			current, met := check.evaluate(deployment)
+			if current != tt.wantCurrent || met != tt.wantMet {
+				t.Errorf("evaluate() = (%q, %v), want (%q, %v)", current, met, tt.wantCurrent, tt.wantMet)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+// handleCleanup releases everything the extension created on behalf of the task,
+// such as an isolated kubeconfig copy or namespaces from createNamespace.
+// It is meant to run in the task's cleanup phase.
+func (e *Extension) handleCleanup(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	state := e.releaseTask(req)
+
+	e.LogInfo(ctx, "Cleaning up task state", map[string]any{
+		"kubeconfig": state.kubeconfigPath,
+		"namespaces": state.namespaces,
+	})
+
+	removed := 0
+	var errs []error
+
+	if state.kubeconfigPath != "" {
+		if err := os.Remove(state.kubeconfigPath); err != nil && !os.IsNotExist(err) {
+			e.LogError(ctx, "Failed to remove isolated kubeconfig", map[string]any{
+				"path":  state.kubeconfigPath,
+				"error": err.Error(),
+			})
+			errs = append(errs, fmt.Errorf("failed to remove isolated kubeconfig: %w", err))
+		} else {
+			removed++
+		}
+	}
+
+	if len(state.namespaces) > 0 && e.client == nil {
+		errs = append(errs, errClientNotInitialized)
+	} else {
+		for _, name := range state.namespaces {
+			if err := e.deleteNamespace(ctx, name); err != nil {
+				e.LogError(ctx, "Failed to delete task namespace", map[string]any{
+					"name":  name,
+					"error": err.Error(),
+				})
+				errs = append(errs, fmt.Errorf("failed to delete namespace %s: %w", name, err))
+				continue
+			}
+			removed++
+		}
+	}
+
+	if len(errs) > 0 {
+		return failure(errors.Join(errs...)), nil
+	}
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Released %d task resource(s)", removed),
+		map[string]string{
+			"removed": fmt.Sprintf("%d", removed),
+		},
+	), nil
+}
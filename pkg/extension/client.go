@@ -3,12 +3,18 @@ package extension
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"sort"
+	"strings"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	"k8s.io/client-go/tools/clientcmd"
@@ -21,17 +27,44 @@ type ResourceClient interface {
 	// Create creates a Kubernetes resource and returns the created object.
 	Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error)
 
-	// Get retrieves a Kubernetes resource by name.
-	Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error)
+	// Update replaces an existing Kubernetes resource and returns the updated object.
+	// The object must carry the resourceVersion it was read at. When subresources are
+	// given, the named subresource is updated instead.
+	Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error)
+
+	// Patch applies a patch of the given type to a Kubernetes resource and returns the
+	// patched object.
+	Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error)
+
+	// Get retrieves a Kubernetes resource by name. When subresources are given, the
+	// named subresource, such as scale, is retrieved instead.
+	Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error)
+
+	// List returns the resources matching opts. For namespaced resources an empty
+	// namespace lists across all namespaces.
+	List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
 
 	// Delete removes a Kubernetes resource.
 	Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error
 
+	// DeleteCollection removes the resources matching listOpts. For namespaced resources
+	// an empty namespace is not supported by the API server.
+	DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+
+	// ServerResources returns the resources the API server serves for a group version,
+	// as reported by discovery. Returns a NotFound error if the group version is not served.
+	ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error)
+
+	// Proxy sends an HTTP request to a service or pod through the proxy subresource of
+	// the API server and returns the response of the backend.
+	Proxy(ctx context.Context, req ProxyRequest) (*ProxyResponse, error)
+
 	// CheckAccess checks if a user can perform an action on a resource.
 	CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error)
 
 	// ListContexts returns all contexts from the kubeconfig sorted by name.
-	// Each context includes its name, cluster, user, namespace, and whether it's the current context.
+	// Each context includes its name, cluster, user, namespace, and whether it's the current context,
+	// along with the server URL and CA presence of the referenced cluster.
 	ListContexts(ctx context.Context) ([]ContextInfo, error)
 
 	// GetCurrentContext returns the current context name from the kubeconfig.
@@ -39,14 +72,60 @@ type ResourceClient interface {
 	GetCurrentContext(ctx context.Context) (string, error)
 
 	// ViewConfig returns the kubeconfig as YAML.
-	// When minify is true, only the current context and its dependencies are included.
-	ViewConfig(ctx context.Context, minify bool) (string, error)
+	// Credentials are redacted unless opts.Raw is set.
+	ViewConfig(ctx context.Context, opts ViewConfigOptions) (string, error)
+
+	// KubeconfigPath returns the path of the kubeconfig file read by the kubeconfig operations.
+	KubeconfigPath() string
+
+	// WithKubeconfig returns a client whose kubeconfig operations read from path instead.
+	// Resource operations keep using the cluster connection of the original client.
+	WithKubeconfig(path string) ResourceClient
+}
+
+// maxProxyBodySize limits how much of a proxied response body is read.
+const maxProxyBodySize = 1 << 20
+
+// ViewConfigOptions controls how ViewConfig renders the kubeconfig.
+type ViewConfigOptions struct {
+	// Minify keeps only the current context and its dependencies.
+	Minify bool `json:"minify,omitempty"`
+	// Flatten inlines certificate and key files referenced by the kubeconfig.
+	Flatten bool `json:"flatten,omitempty"`
+	// Raw disables redaction of tokens, keys, passwords and other credentials.
+	Raw bool `json:"raw,omitempty"`
+}
+
+// ProxyRequest describes an HTTP request sent through the API server proxy subresource.
+type ProxyRequest struct {
+	// Resource is services or pods.
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Scheme is http or https. Empty lets the API server use http.
+	Scheme string `json:"scheme,omitempty"`
+	// Port is a port name or number. Empty uses the first port of the service or pod.
+	Port    string            `json:"port,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ProxyResponse is the response of the backend to a ProxyRequest.
+type ProxyResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
 }
 
 // dynamicClientAdapter adapts the Kubernetes dynamic client to the ResourceClient interface.
 type dynamicClientAdapter struct {
-	client       dynamic.Interface
-	authzClient  authorizationv1client.AuthorizationV1Interface
+	client          dynamic.Interface
+	authzClient     authorizationv1client.AuthorizationV1Interface
+	discoveryClient discovery.DiscoveryInterface
+	// httpClient and host send raw requests, such as proxied ones, to the API server.
+	httpClient     *http.Client
+	host           string
 	kubeconfigPath string
 }
 
@@ -57,11 +136,32 @@ func (a *dynamicClientAdapter) Create(ctx context.Context, gvr schema.GroupVersi
 	return a.client.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
 }
 
-func (a *dynamicClientAdapter) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+func (a *dynamicClientAdapter) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	if namespace != "" {
+		return a.client.Resource(gvr).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{}, subresources...)
+	}
+	return a.client.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{}, subresources...)
+}
+
+func (a *dynamicClientAdapter) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	if namespace != "" {
+		return a.client.Resource(gvr).Namespace(namespace).Patch(ctx, name, patchType, data, metav1.PatchOptions{}, subresources...)
+	}
+	return a.client.Resource(gvr).Patch(ctx, name, patchType, data, metav1.PatchOptions{}, subresources...)
+}
+
+func (a *dynamicClientAdapter) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	if namespace != "" {
+		return a.client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}, subresources...)
+	}
+	return a.client.Resource(gvr).Get(ctx, name, metav1.GetOptions{}, subresources...)
+}
+
+func (a *dynamicClientAdapter) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
 	if namespace != "" {
-		return a.client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		return a.client.Resource(gvr).Namespace(namespace).List(ctx, opts)
 	}
-	return a.client.Resource(gvr).Get(ctx, name, metav1.GetOptions{})
+	return a.client.Resource(gvr).List(ctx, opts)
 }
 
 func (a *dynamicClientAdapter) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
@@ -71,6 +171,60 @@ func (a *dynamicClientAdapter) Delete(ctx context.Context, gvr schema.GroupVersi
 	return a.client.Resource(gvr).Delete(ctx, name, opts)
 }
 
+func (a *dynamicClientAdapter) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	if namespace != "" {
+		return a.client.Resource(gvr).Namespace(namespace).DeleteCollection(ctx, opts, listOpts)
+	}
+	return a.client.Resource(gvr).DeleteCollection(ctx, opts, listOpts)
+}
+
+func (a *dynamicClientAdapter) ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	list, err := a.discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return nil, err
+	}
+	return list.APIResources, nil
+}
+
+func (a *dynamicClientAdapter) Proxy(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+	target := req.Name
+	if req.Scheme != "" {
+		target = req.Scheme + ":" + target
+	}
+	if req.Port != "" {
+		target += ":" + req.Port
+	}
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/%s/%s/proxy/%s",
+		strings.TrimSuffix(a.host, "/"),
+		url.PathEscape(req.Namespace), req.Resource, url.PathEscape(target),
+		strings.TrimPrefix(req.Path, "/"),
+	)
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proxy request: %w", err)
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	return &ProxyResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
 func (a *dynamicClientAdapter) CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
 	sar := &authorizationv1.SubjectAccessReview{
 		Spec: authorizationv1.SubjectAccessReviewSpec{
@@ -101,13 +255,18 @@ func (a *dynamicClientAdapter) ListContexts(ctx context.Context) ([]ContextInfo,
 
 	var contexts []ContextInfo
 	for name, context := range config.Contexts {
-		contexts = append(contexts, ContextInfo{
+		info := ContextInfo{
 			Name:      name,
 			Cluster:   context.Cluster,
 			User:      context.AuthInfo,
 			Namespace: context.Namespace,
 			IsCurrent: name == config.CurrentContext,
-		})
+		}
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			info.Server = cluster.Server
+			info.HasCertificateAuthority = cluster.CertificateAuthority != "" || len(cluster.CertificateAuthorityData) > 0
+		}
+		contexts = append(contexts, info)
 	}
 
 	// Sort contexts by name for deterministic output
@@ -127,7 +286,7 @@ func (a *dynamicClientAdapter) GetCurrentContext(ctx context.Context) (string, e
 	return config.CurrentContext, nil
 }
 
-func (a *dynamicClientAdapter) ViewConfig(ctx context.Context, minify bool) (string, error) {
+func (a *dynamicClientAdapter) ViewConfig(ctx context.Context, opts ViewConfigOptions) (string, error) {
 	// Load the full config
 	rawConfig, err := clientcmd.LoadFromFile(a.kubeconfigPath)
 	if err != nil {
@@ -135,49 +294,22 @@ func (a *dynamicClientAdapter) ViewConfig(ctx context.Context, minify bool) (str
 	}
 
 	// Apply minification if requested
-	if minify {
-		// Get current context
-		currentContext := rawConfig.CurrentContext
-		if currentContext == "" {
-			return "", fmt.Errorf("no current context set in kubeconfig")
-		}
-
-		// Create minified config with only current context and its dependencies
-		currentCtx, exists := rawConfig.Contexts[currentContext]
-		if !exists {
-			return "", fmt.Errorf("current context %q not found in kubeconfig", currentContext)
-		}
-
-		minifiedConfig := clientcmdapi.NewConfig()
-		minifiedConfig.CurrentContext = currentContext
-		minifiedConfig.Contexts = map[string]*clientcmdapi.Context{
-			currentContext: currentCtx,
-		}
-
-		// Add the cluster referenced by current context
-		if currentCtx.Cluster == "" {
-			return "", fmt.Errorf("current context %q has no cluster", currentContext)
-		}
-		if cluster, exists := rawConfig.Clusters[currentCtx.Cluster]; exists {
-			minifiedConfig.Clusters = map[string]*clientcmdapi.Cluster{
-				currentCtx.Cluster: cluster,
-			}
-		} else {
-			return "", fmt.Errorf("cluster %q not found in kubeconfig", currentCtx.Cluster)
+	if opts.Minify {
+		rawConfig, err = minifyConfig(rawConfig, rawConfig.CurrentContext)
+		if err != nil {
+			return "", err
 		}
+	}
 
-		// Add the user referenced by current context (optional)
-		if currentCtx.AuthInfo != "" {
-			authInfo, exists := rawConfig.AuthInfos[currentCtx.AuthInfo]
-			if !exists {
-				return "", fmt.Errorf("user %q not found in kubeconfig", currentCtx.AuthInfo)
-			}
-			minifiedConfig.AuthInfos = map[string]*clientcmdapi.AuthInfo{
-				currentCtx.AuthInfo: authInfo,
-			}
+	// Inline referenced files before redaction so their content is redacted too
+	if opts.Flatten {
+		if err := clientcmdapi.FlattenConfig(rawConfig); err != nil {
+			return "", fmt.Errorf("failed to flatten kubeconfig: %w", err)
 		}
+	}
 
-		rawConfig = minifiedConfig
+	if !opts.Raw {
+		redactConfig(rawConfig)
 	}
 
 	// Convert to YAML
@@ -188,3 +320,57 @@ func (a *dynamicClientAdapter) ViewConfig(ctx context.Context, minify bool) (str
 
 	return string(yamlBytes), nil
 }
+
+func (a *dynamicClientAdapter) KubeconfigPath() string {
+	return a.kubeconfigPath
+}
+
+func (a *dynamicClientAdapter) WithKubeconfig(path string) ResourceClient {
+	scoped := *a
+	scoped.kubeconfigPath = path
+	return &scoped
+}
+
+// minifyConfig returns a copy of config containing only the named context and the
+// cluster and user it references. The returned config has that context set as current.
+func minifyConfig(config *clientcmdapi.Config, contextName string) (*clientcmdapi.Config, error) {
+	if contextName == "" {
+		return nil, fmt.Errorf("no current context set in kubeconfig")
+	}
+
+	context, exists := config.Contexts[contextName]
+	if !exists {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	minified := clientcmdapi.NewConfig()
+	minified.CurrentContext = contextName
+	minified.Contexts = map[string]*clientcmdapi.Context{
+		contextName: context,
+	}
+
+	// Add the cluster referenced by the context
+	if context.Cluster == "" {
+		return nil, fmt.Errorf("context %q has no cluster", contextName)
+	}
+	cluster, exists := config.Clusters[context.Cluster]
+	if !exists {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", context.Cluster)
+	}
+	minified.Clusters = map[string]*clientcmdapi.Cluster{
+		context.Cluster: cluster,
+	}
+
+	// Add the user referenced by the context (optional)
+	if context.AuthInfo != "" {
+		authInfo, exists := config.AuthInfos[context.AuthInfo]
+		if !exists {
+			return nil, fmt.Errorf("user %q not found in kubeconfig", context.AuthInfo)
+		}
+		minified.AuthInfos = map[string]*clientcmdapi.AuthInfo{
+			context.AuthInfo: authInfo,
+		}
+	}
+
+	return minified, nil
+}
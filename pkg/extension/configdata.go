@@ -0,0 +1,346 @@
+package extension
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Secret types accepted by createSecret, mirroring `kubectl create secret`.
+const (
+	secretTypeGeneric        = "generic"
+	secretTypeTLS            = "tls"
+	secretTypeDockerRegistry = "docker-registry"
+)
+
+// handleCreateConfigMap creates a ConfigMap from files, directories, literals and env files,
+// like `kubectl create configmap`. Content that is not valid UTF-8 is stored in binaryData.
+func (e *Extension) handleCreateConfigMap(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	opts, err := parseCreateOptions(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	obj, err := newDataObject("ConfigMap", args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	entries, err := collectData(req, args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	data := map[string]any{}
+	binaryData := map[string]any{}
+	for key, value := range entries {
+		if utf8.Valid(value) {
+			data[key] = string(value)
+		} else {
+			binaryData[key] = base64.StdEncoding.EncodeToString(value)
+		}
+	}
+	if len(data) > 0 {
+		obj.Object["data"] = data
+	}
+	if len(binaryData) > 0 {
+		obj.Object["binaryData"] = binaryData
+	}
+
+	return e.createObject(ctx, req, obj, opts), nil
+}
+
+// handleCreateSecret creates a Secret like `kubectl create secret`. Generic secrets are
+// built from files, directories, literals and env files; tls secrets from a certificate
+// and key file; docker-registry secrets from registry credentials.
+func (e *Extension) handleCreateSecret(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	opts, err := parseCreateOptions(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	obj, err := newDataObject("Secret", args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	secretType, _ := args["type"].(string)
+	if secretType == "" {
+		secretType = secretTypeGeneric
+	}
+
+	var entries map[string][]byte
+	switch secretType {
+	case secretTypeGeneric:
+		obj.Object["type"] = "Opaque"
+		entries, err = collectData(req, args)
+	case secretTypeTLS:
+		obj.Object["type"] = "kubernetes.io/tls"
+		entries, err = tlsData(req, args)
+	case secretTypeDockerRegistry:
+		obj.Object["type"] = "kubernetes.io/dockerconfigjson"
+		entries, err = dockerConfigData(args)
+	default:
+		err = fmt.Errorf("invalid type %q: must be one of generic, tls, docker-registry", secretType)
+	}
+	if err != nil {
+		return failure(err), nil
+	}
+
+	data := make(map[string]any, len(entries))
+	for key, value := range entries {
+		data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	obj.Object["data"] = data
+
+	return e.createObject(ctx, req, obj, opts), nil
+}
+
+// newDataObject returns a v1 object of the given kind carrying the metadata from args.
+func newDataObject(kind string, args map[string]any) (*unstructured.Unstructured, error) {
+	metadata, ok := args["metadata"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("metadata is required")
+	}
+	name, _ := metadata["name"].(string)
+	generateName, _ := metadata["generateName"].(string)
+	if name == "" && generateName == "" {
+		return nil, fmt.Errorf("metadata.name or metadata.generateName is required")
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   metadata,
+	}}, nil
+}
+
+// collectData reads the fromFile, fromLiteral and fromEnvFile arguments into a map of keys
+// to content. Relative paths are resolved against the task directory.
+func collectData(req *sdk.OperationRequest, args map[string]any) (map[string][]byte, error) {
+	entries := make(map[string][]byte)
+	add := func(key string, value []byte, source string) error {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("invalid key %q from %s: %s", key, source, strings.Join(errs, ", "))
+		}
+		if _, exists := entries[key]; exists {
+			return fmt.Errorf("duplicate key %q from %s", key, source)
+		}
+		entries[key] = value
+		return nil
+	}
+
+	files, err := stringListArg(args, "fromFile")
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range files {
+		key, path, hasKey := strings.Cut(spec, "=")
+		if !hasKey {
+			path, key = spec, ""
+		}
+		path = resolveTaskPath(req, path)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", spec, err)
+		}
+		if !info.IsDir() {
+			if key == "" {
+				key = filepath.Base(path)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", spec, err)
+			}
+			if err := add(key, content, spec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if hasKey {
+			return nil, fmt.Errorf("cannot give a key name for directory %s", spec)
+		}
+		// Like kubectl, only regular files directly inside the directory are used
+		dirEntries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", spec, err)
+		}
+		for _, entry := range dirEntries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(path, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+			}
+			if err := add(entry.Name(), content, spec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	literals, err := stringListArg(args, "fromLiteral")
+	if err != nil {
+		return nil, err
+	}
+	for _, literal := range literals {
+		key, value, ok := strings.Cut(literal, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid literal %q: expected key=value", literal)
+		}
+		if err := add(key, []byte(value), "fromLiteral"); err != nil {
+			return nil, err
+		}
+	}
+
+	envFiles, err := stringListArg(args, "fromEnvFile")
+	if err != nil {
+		return nil, err
+	}
+	for _, envFile := range envFiles {
+		content, err := os.ReadFile(resolveTaskPath(req, envFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %w", envFile, err)
+		}
+		vars, err := parseEnvFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env file %s: %w", envFile, err)
+		}
+		for _, kv := range vars {
+			if err := add(kv[0], []byte(kv[1]), envFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// parseEnvFile parses KEY=VALUE lines, skipping blank lines and # comments.
+func parseEnvFile(content []byte) ([][2]string, error) {
+	var vars [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		vars = append(vars, [2]string{strings.TrimSpace(key), value})
+	}
+	return vars, scanner.Err()
+}
+
+// tlsData reads the cert and key files of a tls secret.
+func tlsData(req *sdk.OperationRequest, args map[string]any) (map[string][]byte, error) {
+	certPath, _ := args["cert"].(string)
+	keyPath, _ := args["key"].(string)
+	if certPath == "" || keyPath == "" {
+		return nil, fmt.Errorf("cert and key are required for tls secrets")
+	}
+
+	cert, err := os.ReadFile(resolveTaskPath(req, certPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cert: %w", err)
+	}
+	key, err := os.ReadFile(resolveTaskPath(req, keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+
+	return map[string][]byte{
+		"tls.crt": cert,
+		"tls.key": key,
+	}, nil
+}
+
+// dockerConfigData builds the .dockerconfigjson entry of a docker-registry secret.
+func dockerConfigData(args map[string]any) (map[string][]byte, error) {
+	server, _ := args["dockerServer"].(string)
+	username, _ := args["dockerUsername"].(string)
+	password, _ := args["dockerPassword"].(string)
+	email, _ := args["dockerEmail"].(string)
+
+	if server == "" {
+		server = "https://index.docker.io/v1/"
+	}
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("dockerUsername and dockerPassword are required for docker-registry secrets")
+	}
+
+	entry := map[string]string{
+		"username": username,
+		"password": password,
+		"auth":     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+	if email != "" {
+		entry["email"] = email
+	}
+
+	config, err := json.Marshal(map[string]any{
+		"auths": map[string]any{server: entry},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal docker config: %w", err)
+	}
+
+	return map[string][]byte{".dockerconfigjson": config}, nil
+}
+
+// stringListArg returns args[key] as a list of strings. A single string is accepted as a one-item list.
+func stringListArg(args map[string]any, key string) ([]string, error) {
+	value, ok := args[key]
+	if !ok {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok {
+		return []string{s}, nil
+	}
+	items, ok := toStringSlice(value)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of strings", key)
+	}
+	return items, nil
+}
+
+// resolveTaskPath resolves a path relative to the task directory.
+func resolveTaskPath(req *sdk.OperationRequest, path string) string {
+	if filepath.IsAbs(path) || req.Context.Workdir == "" {
+		return path
+	}
+	return filepath.Join(req.Context.Workdir, path)
+}
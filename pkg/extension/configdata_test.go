@@ -0,0 +1,271 @@
+package extension
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/protocol"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// writeTaskFiles creates files relative to a new task directory and returns the directory.
+func writeTaskFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestHandleCreateConfigMap(t *testing.T) {
+	workdir := writeTaskFiles(t, map[string]string{
+		"app.properties":   "mode=prod\n",
+		"conf/nginx.conf":  "server {}\n",
+		"conf/mime.types":  "types {}\n",
+		"settings.env":     "# comment\nLOG_LEVEL=debug\n\nWORKERS=4\n",
+		"logo.bin":         "\xff\xfe\x00",
+		"conf/sub/ignored": "nested files are skipped",
+	})
+
+	tests := []struct {
+		name           string
+		args           any
+		wantSuccess    bool
+		wantData       map[string]string
+		wantBinaryKeys []string
+	}{
+		{
+			name: "files, directories, literals and env files",
+			args: map[string]any{
+				"metadata":    map[string]any{"name": "app-config", "namespace": "default"},
+				"fromFile":    []any{"app.properties", "custom.conf=conf/nginx.conf", "conf"},
+				"fromLiteral": []any{"greeting=hello=world"},
+				"fromEnvFile": []any{"settings.env"},
+			},
+			wantSuccess: true,
+			wantData: map[string]string{
+				"app.properties": "mode=prod\n",
+				"custom.conf":    "server {}\n",
+				"nginx.conf":     "server {}\n",
+				"mime.types":     "types {}\n",
+				"greeting":       "hello=world",
+				"LOG_LEVEL":      "debug",
+				"WORKERS":        "4",
+			},
+		},
+		{
+			name: "binary content goes to binaryData",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "assets"},
+				"fromFile": []any{"logo.bin"},
+			},
+			wantSuccess:    true,
+			wantData:       map[string]string{},
+			wantBinaryKeys: []string{"logo.bin"},
+		},
+		{
+			name: "duplicate keys",
+			args: map[string]any{
+				"metadata":    map[string]any{"name": "dup"},
+				"fromFile":    []any{"app.properties"},
+				"fromLiteral": []any{"app.properties=x"},
+			},
+			wantSuccess: false,
+		},
+		{
+			name: "missing file",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "missing"},
+				"fromFile": []any{"nope.txt"},
+			},
+			wantSuccess: false,
+		},
+		{
+			name: "invalid literal",
+			args: map[string]any{
+				"metadata":    map[string]any{"name": "bad"},
+				"fromLiteral": []any{"no-separator"},
+			},
+			wantSuccess: false,
+		},
+		{
+			name:        "missing name",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default"}},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *unstructured.Unstructured
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client: &mockClient{
+					createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+						if gvr.Resource != "configmaps" {
+							t.Errorf("create resource = %q, want configmaps", gvr.Resource)
+						}
+						created = obj
+						return obj, nil
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args, Context: protocol.ExecuteContext{Workdir: workdir}}
+			result, err := ext.handleCreateConfigMap(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleCreateConfigMap() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleCreateConfigMap() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+
+			data, _, _ := unstructured.NestedStringMap(created.Object, "data")
+			if len(data) != len(tt.wantData) {
+				t.Errorf("data keys = %v, want %v", data, tt.wantData)
+			}
+			for key, want := range tt.wantData {
+				if data[key] != want {
+					t.Errorf("data[%s] = %q, want %q", key, data[key], want)
+				}
+			}
+			binaryData, _, _ := unstructured.NestedStringMap(created.Object, "binaryData")
+			for _, key := range tt.wantBinaryKeys {
+				if _, ok := binaryData[key]; !ok {
+					t.Errorf("binaryData missing %q", key)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleCreateSecret(t *testing.T) {
+	workdir := writeTaskFiles(t, map[string]string{
+		"tls.crt":  "CERT",
+		"tls.key":  "KEY",
+		"password": "s3cret",
+	})
+
+	tests := []struct {
+		name        string
+		args        any
+		wantSuccess bool
+		wantType    string
+		wantData    map[string]string
+	}{
+		{
+			name: "generic from file and literal",
+			args: map[string]any{
+				"metadata":    map[string]any{"name": "db", "namespace": "default"},
+				"fromFile":    []any{"password"},
+				"fromLiteral": []any{"username=admin"},
+			},
+			wantSuccess: true,
+			wantType:    "Opaque",
+			wantData:    map[string]string{"password": "s3cret", "username": "admin"},
+		},
+		{
+			name: "tls",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "web-tls"},
+				"type":     "tls",
+				"cert":     "tls.crt",
+				"key":      "tls.key",
+			},
+			wantSuccess: true,
+			wantType:    "kubernetes.io/tls",
+			wantData:    map[string]string{"tls.crt": "CERT", "tls.key": "KEY"},
+		},
+		{
+			name: "docker registry",
+			args: map[string]any{
+				"metadata":       map[string]any{"name": "pull-secret"},
+				"type":           "docker-registry",
+				"dockerServer":   "registry.example.com",
+				"dockerUsername": "bot",
+				"dockerPassword": "token",
+			},
+			wantSuccess: true,
+			wantType:    "kubernetes.io/dockerconfigjson",
+		},
+		{
+			name: "tls without key",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "web-tls"},
+				"type":     "tls",
+				"cert":     "tls.crt",
+			},
+			wantSuccess: false,
+		},
+		{
+			name: "unknown type",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "x"},
+				"type":     "ssh",
+			},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *unstructured.Unstructured
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client: &mockClient{
+					createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+						created = obj
+						return obj, nil
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args, Context: protocol.ExecuteContext{Workdir: workdir}}
+			result, err := ext.handleCreateSecret(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleCreateSecret() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleCreateSecret() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+
+			if got, _, _ := unstructured.NestedString(created.Object, "type"); got != tt.wantType {
+				t.Errorf("type = %q, want %q", got, tt.wantType)
+			}
+			data, _, _ := unstructured.NestedStringMap(created.Object, "data")
+			for key, want := range tt.wantData {
+				decoded, err := base64.StdEncoding.DecodeString(data[key])
+				if err != nil || string(decoded) != want {
+					t.Errorf("data[%s] = %q, want base64 of %q", key, data[key], want)
+				}
+			}
+			if tt.wantType == "kubernetes.io/dockerconfigjson" {
+				decoded, _ := base64.StdEncoding.DecodeString(data[".dockerconfigjson"])
+				if !strings.Contains(string(decoded), `"registry.example.com"`) {
+					t.Errorf(".dockerconfigjson = %s, want registry.example.com auth", decoded)
+				}
+			}
+		})
+	}
+}
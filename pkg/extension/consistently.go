@@ -0,0 +1,206 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// handleConsistently checks that a condition keeps holding on a resource, the resources
+// matching a selector or a list of resources for a whole window. It samples the check at
+// an interval and fails on the first sample that violates it, reporting what changed.
+func (e *Extension) handleConsistently(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	check, err := parseObjectCheck(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	targets, err := parseWaitTargets(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	mode, minCount, err := parseWaitMode(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	durationStr, _ := args["duration"].(string)
+	if durationStr == "" {
+		durationStr = "10s"
+	}
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid duration format: %w", err)), nil
+	}
+
+	intervalStr, _ := args["interval"].(string)
+	if intervalStr == "" {
+		intervalStr = "1s"
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid interval format: %w", err)), nil
+	}
+	if interval <= 0 {
+		return failure(fmt.Errorf("interval must be positive")), nil
+	}
+
+	e.LogInfo(ctx, "Checking condition consistently", map[string]any{
+		"targets":  targets.String(),
+		"check":    check.String(),
+		"duration": durationStr,
+		"interval": intervalStr,
+	})
+
+	start := time.Now()
+	deadline := start.Add(duration)
+	var previous []targetObservation
+	samples := 0
+	for {
+		observations, err := e.observe(ctx, targets, check)
+		if err != nil {
+			e.LogError(ctx, "Consistency check failed", map[string]any{
+				"targets": targets.String(),
+				"error":   err.Error(),
+			})
+			return failure(err), nil
+		}
+
+		// A sample that could not be read neither confirms nor violates the check
+		if readErr := transientError(observations); readErr != nil {
+			e.LogWarn(ctx, "Skipping sample after read error", map[string]any{
+				"targets": targets.String(),
+				"error":   readErr.Error(),
+			})
+		} else {
+			samples++
+			if !satisfied(observations, mode, minCount) {
+				return e.violation(ctx, targets, check, time.Since(start), previous, observations, samples), nil
+			}
+			previous = observations
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		timer := time.NewTimer(min(interval, remaining))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return failureWithCategory(categoryInfra,
+				fmt.Sprintf("Consistency check of %s interrupted", targets),
+				fmt.Errorf("interrupted after %s of %s: %w", time.Since(start).Round(time.Second), duration, ctx.Err()),
+			), nil
+		case <-timer.C:
+		}
+	}
+
+	if samples == 0 {
+		return failureWithCategory(categoryInfra,
+			fmt.Sprintf("Could not check %s", targets),
+			fmt.Errorf("no sample of %s could be read within %s", targets, duration),
+		), nil
+	}
+
+	e.LogInfo(ctx, "Condition held", map[string]any{
+		"targets": targets.String(),
+		"check":   check.String(),
+		"samples": samples,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%s kept condition %s for %s", targets, check, duration),
+		map[string]string{
+			"samples": fmt.Sprintf("%d", samples),
+		},
+	), nil
+}
+
+// violation returns the failure for a sample that violates the check, describing
+// what changed since the previous sample.
+func (e *Extension) violation(ctx context.Context, targets *waitTargets, check *objectCheck, elapsed time.Duration, previous, current []targetObservation, samples int) *sdk.OperationResult {
+	changes := describeChanges(previous, current)
+	if previous == nil {
+		changes = []string{"not met at the first sample"}
+		for _, o := range current {
+			if !o.met {
+				changes = append(changes, fmt.Sprintf("%s: %s", o.name, o.status))
+			}
+		}
+	}
+
+	e.LogError(ctx, "Condition violated", map[string]any{
+		"targets": targets.String(),
+		"check":   check.String(),
+		"after":   elapsed.Round(time.Millisecond).String(),
+		"changes": strings.Join(changes, "; "),
+	})
+
+	result := failureWithCategory(categoryAgent,
+		fmt.Sprintf("Condition %s violated on %s after %s", check, targets, elapsed.Round(time.Second)),
+		errors.New(strings.Join(append([]string{fmt.Sprintf("condition %s no longer held after %s:", check, elapsed.Round(time.Millisecond))}, changes...), "\n")),
+	)
+	result.Outputs["violatedAfter"] = elapsed.Round(time.Millisecond).String()
+	result.Outputs["changes"] = strings.Join(changes, "; ")
+	result.Outputs["objects"] = observationsJSON(current)
+	result.Outputs["samples"] = fmt.Sprintf("%d", samples)
+	return result
+}
+
+// describeChanges lists the objects whose state differs between two samples,
+// including objects that appeared or disappeared.
+func describeChanges(previous, current []targetObservation) []string {
+	before := make(map[string]string, len(previous))
+	for _, o := range previous {
+		before[o.name] = o.status
+	}
+
+	var changes []string
+	seen := make(map[string]bool, len(current))
+	for _, o := range current {
+		seen[o.name] = true
+		old, existed := before[o.name]
+		switch {
+		case !existed:
+			changes = append(changes, fmt.Sprintf("%s: appeared with %s", o.name, o.status))
+		case old != o.status:
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", o.name, old, o.status))
+		}
+	}
+	for _, name := range sortedKeys(before) {
+		if !seen[name] {
+			changes = append(changes, fmt.Sprintf("%s: disappeared", name))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// transientError returns the first error reading an object that is not a missing object.
+// A missing object counts as a state of the sample, not as a failure to take it.
+func transientError(observations []targetObservation) error {
+	for _, o := range observations {
+		if o.err != nil && !apierrors.IsNotFound(o.err) {
+			return o.err
+		}
+	}
+	return nil
+}
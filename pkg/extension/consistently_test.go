@@ -0,0 +1,181 @@
+package extension
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// readyPodSequence returns a Get function serving pod web with the given Ready statuses,
+// one per call, repeating the last one. An empty status makes the pod missing.
+func readyPodSequence(statuses ...string) func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+	calls := 0
+	return func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		if status == "" {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+		}
+		pod := podWithReady(name, status)
+		return &pod, nil
+	}
+}
+
+func TestHandleConsistently(t *testing.T) {
+	podArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+			"condition":  "Ready",
+			"duration":   "300ms",
+			"interval":   "100ms",
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		client       *mockClient
+		wantSuccess  bool
+		wantChanges  string
+		wantCategory string
+	}{
+		{
+			name:        "condition holds",
+			args:        podArgs(nil),
+			client:      &mockClient{getFn: readyPodSequence("True")},
+			wantSuccess: true,
+		},
+		{
+			name:         "condition stops holding",
+			args:         podArgs(nil),
+			client:       &mockClient{getFn: readyPodSequence("True", "True", "False")},
+			wantSuccess:  false,
+			wantChanges:  "Pod default/web: True -> False",
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "condition not met at start",
+			args:         podArgs(nil),
+			client:       &mockClient{getFn: readyPodSequence("False")},
+			wantSuccess:  false,
+			wantChanges:  "not met at the first sample; Pod default/web: False",
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "object deleted",
+			args:         podArgs(nil),
+			client:       &mockClient{getFn: readyPodSequence("True", "")},
+			wantSuccess:  false,
+			wantChanges:  "Pod default/web: True -> NotFound",
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "replicas stay unchanged",
+			args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web", "namespace": "default"},
+				"jsonPath":   "{.spec.replicas}",
+				"value":      "1",
+				"duration":   "300ms",
+				"interval":   "100ms",
+			},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					return &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"replicas": int64(1)}}}, nil
+				},
+			},
+			wantSuccess: true,
+		},
+		{
+			name: "unready pod appears in selection",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]any{"namespace": "default"},
+				"selector":   "app=web",
+				"condition":  "Ready",
+				"duration":   "300ms",
+				"interval":   "100ms",
+			},
+			client: &mockClient{
+				listFn: func() func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+					calls := 0
+					return func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+						calls++
+						pods := []unstructured.Unstructured{podWithReady("web-1", "True")}
+						if calls > 1 {
+							pods = append(pods, podWithReady("web-2", "False"))
+						}
+						return &unstructured.UnstructuredList{Items: pods}, nil
+					}
+				}(),
+			},
+			wantSuccess:  false,
+			wantChanges:  "Pod default/web-2: appeared with False",
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "object never readable",
+			args: podArgs(nil),
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					return nil, apierrors.NewServiceUnavailable("down")
+				},
+			},
+			wantSuccess:  false,
+			wantCategory: categoryInfra,
+		},
+		{
+			name:         "invalid interval",
+			args:         podArgs(map[string]any{"interval": "0s"}),
+			client:       &mockClient{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleConsistently(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleConsistently() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleConsistently() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if tt.wantChanges != "" {
+				if got := result.Outputs["changes"]; got != tt.wantChanges {
+					t.Errorf("changes output = %q, want %q", got, tt.wantChanges)
+				}
+				if !strings.Contains(result.Error, "no longer held after") {
+					t.Errorf("error %q does not report when the condition stopped holding", result.Error)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
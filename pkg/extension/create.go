@@ -3,61 +3,238 @@ package extension
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 )
 
+// ifExists policies for create when the resource already exists.
+const (
+	ifExistsFail    = "fail"
+	ifExistsIgnore  = "ignore"
+	ifExistsReplace = "replace"
+	ifExistsUpdate  = "update"
+)
+
+// createOptionKeys are create arguments that control the operation and are not part of the manifest.
+var createOptionKeys = []string{"ifExists", "timeout"}
+
+// createOptions controls how createObject handles an existing resource.
+type createOptions struct {
+	// ifExists is one of the ifExists* policies.
+	ifExists string
+	// timeout bounds the wait for the old resource to disappear when replacing.
+	timeout time.Duration
+}
+
+// parseCreateOptions extracts the create options from operation arguments.
+func parseCreateOptions(args map[string]any) (createOptions, error) {
+	opts := createOptions{ifExists: ifExistsFail, timeout: 60 * time.Second}
+
+	if ifExists, ok := args["ifExists"].(string); ok && ifExists != "" {
+		switch ifExists {
+		case ifExistsFail, ifExistsIgnore, ifExistsReplace, ifExistsUpdate:
+			opts.ifExists = ifExists
+		default:
+			return opts, fmt.Errorf("invalid ifExists %q: must be one of fail, ignore, replace, update", ifExists)
+		}
+	}
+
+	if timeoutStr, ok := args["timeout"].(string); ok && timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid timeout format: %w", err)
+		}
+		opts.timeout = timeout
+	}
+
+	return opts, nil
+}
+
 func (e *Extension) handleCreate(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
 	}
 
 	// Args is the resource spec as a map
 	resourceSpec, ok := req.Args.(map[string]any)
 	if !ok {
-		return sdk.Failure(fmt.Errorf("args must be a resource spec object")), nil
+		return failure(fmt.Errorf("args must be a resource spec object")), nil
 	}
 
-	obj := &unstructured.Unstructured{Object: resourceSpec}
+	opts, err := parseCreateOptions(resourceSpec)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	manifest := make(map[string]any, len(resourceSpec))
+	for key, value := range resourceSpec {
+		manifest[key] = value
+	}
+	for _, key := range createOptionKeys {
+		delete(manifest, key)
+	}
+
+	return e.createObject(ctx, req, &unstructured.Unstructured{Object: manifest}, opts), nil
+}
 
+// createObject creates obj, applying opts.ifExists when the resource already exists,
+// and returns the operation result with the created object's name, namespace, uid,
+// resourceVersion and the action taken as outputs. Objects it creates are recorded as
+// owned by the task that issued req.
+func (e *Extension) createObject(ctx context.Context, req *sdk.OperationRequest, obj *unstructured.Unstructured, opts createOptions) *sdk.OperationResult {
 	gvk := obj.GroupVersionKind()
 	if gvk.Kind == "" {
-		return sdk.Failure(fmt.Errorf("kind is required")), nil
+		return failure(fmt.Errorf("kind is required"))
 	}
 
-	gvr := gvkToGVR(gvk)
 	namespace := obj.GetNamespace()
 
 	e.LogInfo(ctx, "Creating resource", map[string]any{
-		"kind":      gvk.Kind,
-		"name":      obj.GetName(),
-		"namespace": namespace,
+		"kind":         gvk.Kind,
+		"name":         obj.GetName(),
+		"generateName": obj.GetGenerateName(),
+		"namespace":    namespace,
+		"ifExists":     opts.ifExists,
 	})
 
-	result, err := e.client.Create(ctx, gvr, obj, namespace)
+	action := "created"
+	result, err := e.createWithSettle(ctx, gvk, obj)
+	if apierrors.IsAlreadyExists(err) && opts.ifExists != ifExistsFail {
+		gvr := e.gvrFor(gvk)
+		e.LogInfo(ctx, "Resource already exists", map[string]any{
+			"kind":     gvk.Kind,
+			"name":     obj.GetName(),
+			"ifExists": opts.ifExists,
+		})
+
+		switch opts.ifExists {
+		case ifExistsIgnore:
+			action = "unchanged"
+			result, err = e.client.Get(ctx, gvr, obj.GetName(), namespace)
+		case ifExistsReplace:
+			action = "replaced"
+			result, err = e.replaceObject(ctx, gvr, obj, opts.timeout)
+		case ifExistsUpdate:
+			action = "updated"
+			result, err = e.updateObject(ctx, gvr, obj)
+		}
+	}
 	if err != nil {
 		e.LogError(ctx, "Failed to create resource", map[string]any{
 			"kind":  gvk.Kind,
 			"name":  obj.GetName(),
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to create resource: %w", err)), nil
+		return failure(fmt.Errorf("failed to create resource: %w", err))
+	}
+
+	if gvk.Group == crdGVR.Group && gvk.Kind == "CustomResourceDefinition" {
+		e.recordCRDInstall()
+	}
+	if action == "created" || action == "replaced" {
+		e.recordCreated(req, result.GetUID())
 	}
 
 	e.LogInfo(ctx, "Resource created successfully", map[string]any{
-		"kind": gvk.Kind,
-		"name": result.GetName(),
-		"uid":  string(result.GetUID()),
+		"kind":   gvk.Kind,
+		"name":   result.GetName(),
+		"uid":    string(result.GetUID()),
+		"action": action,
 	})
 
+	verb := map[string]string{
+		"created":   "Created",
+		"unchanged": "Kept existing",
+		"replaced":  "Replaced",
+		"updated":   "Updated",
+	}[action]
+
 	return sdk.SuccessWithOutputs(
-		fmt.Sprintf("Created %s/%s", gvk.Kind, result.GetName()),
+		fmt.Sprintf("%s %s/%s", verb, gvk.Kind, result.GetName()),
 		map[string]string{
 			"name":            result.GetName(),
 			"namespace":       result.GetNamespace(),
 			"uid":             string(result.GetUID()),
 			"resourceVersion": result.GetResourceVersion(),
+			"action":          action,
 		},
-	), nil
+	)
+}
+
+// createWithSettle creates obj. If a CRD was created recently and the API server does
+// not serve the kind yet, the mapping is rediscovered and the create retried until
+// the settle window ends.
+func (e *Extension) createWithSettle(ctx context.Context, gvk schema.GroupVersionKind, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	result, err := e.client.Create(ctx, e.gvrFor(gvk), obj, obj.GetNamespace())
+	if !isUnknownResource(err) || time.Now().After(e.crdSettleDeadline()) {
+		return result, err
+	}
+
+	e.LogInfo(ctx, "Resource type not served yet, retrying after CRD install", map[string]any{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+	})
+
+	timeout := time.Until(e.crdSettleDeadline())
+	pollErr := wait.PollUntilContextTimeout(ctx, time.Second, timeout, false, func(ctx context.Context) (bool, error) {
+		// The guessed plural may be wrong for the new kind, so look it up once it is served
+		_, _ = e.discoverResource(ctx, gvk)
+		result, err = e.client.Create(ctx, e.gvrFor(gvk), obj, obj.GetNamespace())
+		return !isUnknownResource(err), nil
+	})
+	if pollErr != nil && err == nil {
+		return nil, pollErr
+	}
+	return result, err
+}
+
+// replaceObject deletes the existing resource, waits until it is gone and creates obj.
+func (e *Extension) replaceObject(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, timeout time.Duration) (*unstructured.Unstructured, error) {
+	name, namespace := obj.GetName(), obj.GetNamespace()
+
+	propagation := metav1.DeletePropagationForeground
+	err := e.client.Delete(ctx, gvr, name, namespace, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to delete existing resource: %w", err)
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		_, getErr := e.client.Get(ctx, gvr, name, namespace)
+		if apierrors.IsNotFound(getErr) {
+			return true, nil
+		}
+		return false, nil // Keep polling while the resource still exists or on transient errors
+	})
+	if err != nil {
+		return nil, fmt.Errorf("timed out waiting for existing resource to be deleted: %w", err)
+	}
+
+	return e.client.Create(ctx, gvr, obj, namespace)
+}
+
+// updateObject updates the existing resource to match obj, retrying on conflicts.
+func (e *Extension) updateObject(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	var result *unstructured.Unstructured
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := e.client.Get(ctx, gvr, obj.GetName(), obj.GetNamespace())
+		if err != nil {
+			return err
+		}
+
+		desired := obj.DeepCopy()
+		desired.SetResourceVersion(existing.GetResourceVersion())
+
+		result, err = e.client.Update(ctx, gvr, desired, obj.GetNamespace())
+		return err
+	})
+	return result, err
 }
@@ -6,8 +6,11 @@ import (
 	"testing"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestHandleCreate(t *testing.T) {
@@ -16,6 +19,9 @@ func TestHandleCreate(t *testing.T) {
 		args        any
 		client      *mockClient
 		wantSuccess bool
+		wantName    string
+		wantAction  string
+		wantOwned   bool
 	}{
 		{
 			name: "successful create",
@@ -36,6 +42,7 @@ func TestHandleCreate(t *testing.T) {
 				},
 			},
 			wantSuccess: true,
+			wantOwned:   true,
 		},
 		{
 			name:        "invalid args type",
@@ -59,6 +66,70 @@ func TestHandleCreate(t *testing.T) {
 			},
 			wantSuccess: false,
 		},
+		{
+			name: "generateName returns generated name",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"generateName": "settings-", "namespace": "default"},
+			},
+			client: &mockClient{
+				createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+					result := obj.DeepCopy()
+					result.SetName(obj.GetGenerateName() + "x7k2p")
+					return result, nil
+				},
+			},
+			wantSuccess: true,
+			wantName:    "settings-x7k2p",
+			wantAction:  "created",
+		},
+		{
+			name:        "already exists fails by default",
+			args:        configMapArgs(nil),
+			client:      existingConfigMapClient(t, nil),
+			wantSuccess: false,
+		},
+		{
+			name:        "already exists ignored",
+			args:        configMapArgs(map[string]any{"ifExists": "ignore"}),
+			client:      existingConfigMapClient(t, nil),
+			wantSuccess: true,
+			wantName:    "test-cm",
+			wantAction:  "unchanged",
+		},
+		{
+			name: "already exists updated",
+			args: configMapArgs(map[string]any{"ifExists": "update"}),
+			client: existingConfigMapClient(t, &mockClient{
+				updateFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+					if obj.GetResourceVersion() != "41" {
+						t.Errorf("update resourceVersion = %q, want 41", obj.GetResourceVersion())
+					}
+					result := obj.DeepCopy()
+					result.SetResourceVersion("42")
+					return result, nil
+				},
+			}),
+			wantSuccess: true,
+			wantName:    "test-cm",
+			wantAction:  "updated",
+		},
+		{
+			name:        "already exists replaced",
+			args:        configMapArgs(map[string]any{"ifExists": "replace", "timeout": "5s"}),
+			client:      replaceableConfigMapClient(t),
+			wantSuccess: true,
+			wantName:    "test-cm",
+			wantAction:  "replaced",
+			wantOwned:   true,
+		},
+		{
+			name:        "invalid ifExists",
+			args:        configMapArgs(map[string]any{"ifExists": "merge"}),
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -77,6 +148,80 @@ func TestHandleCreate(t *testing.T) {
 			if result.Success != tt.wantSuccess {
 				t.Errorf("handleCreate() success = %v, want %v", result.Success, tt.wantSuccess)
 			}
+			if tt.wantName != "" && result.Outputs["name"] != tt.wantName {
+				t.Errorf("handleCreate() name = %q, want %q", result.Outputs["name"], tt.wantName)
+			}
+			if tt.wantAction != "" && result.Outputs["action"] != tt.wantAction {
+				t.Errorf("handleCreate() action = %q, want %q", result.Outputs["action"], tt.wantAction)
+			}
+			if owned := ext.isCreated(types.UID(result.Outputs["uid"])); owned != tt.wantOwned {
+				t.Errorf("created object owned = %v, want %v", owned, tt.wantOwned)
+			}
 		})
 	}
 }
+
+func configMapArgs(extra map[string]any) map[string]any {
+	args := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "test-cm", "namespace": "default"},
+		"data":       map[string]any{"key": "value"},
+	}
+	for key, value := range extra {
+		args[key] = value
+	}
+	return args
+}
+
+// existingConfigMapClient returns a client on which test-cm already exists.
+// Create fails with AlreadyExists and Get returns the existing object.
+func existingConfigMapClient(t *testing.T, base *mockClient) *mockClient {
+	if base == nil {
+		base = &mockClient{}
+	}
+	base.createFn = func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+		for _, key := range createOptionKeys {
+			if _, ok := obj.Object[key]; ok {
+				t.Errorf("create sent option %q to the API server", key)
+			}
+		}
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, obj.GetName())
+	}
+	base.getFn = func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+		existing := &unstructured.Unstructured{}
+		existing.SetAPIVersion("v1")
+		existing.SetKind("ConfigMap")
+		existing.SetName(name)
+		existing.SetNamespace(namespace)
+		existing.SetUID("existing-uid")
+		existing.SetResourceVersion("41")
+		return existing, nil
+	}
+	return base
+}
+
+// replaceableConfigMapClient returns a client on which test-cm exists until it is deleted.
+func replaceableConfigMapClient(t *testing.T) *mockClient {
+	exists := true
+	return &mockClient{
+		createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+			if exists {
+				return nil, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, obj.GetName())
+			}
+			result := obj.DeepCopy()
+			result.SetUID("replaced-uid")
+			return result, nil
+		},
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			exists = false
+			return nil
+		},
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			if exists {
+				return &unstructured.Unstructured{}, nil
+			}
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+		},
+	}
+}
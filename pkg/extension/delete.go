@@ -2,33 +2,119 @@ package extension
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// parseDeleteOptions reads the propagationPolicy, gracePeriodSeconds and preconditions
+// arguments. The propagation policy defaults to Foreground.
+func parseDeleteOptions(args map[string]any) (metav1.DeleteOptions, error) {
+	propagation := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	if value, ok := args["propagationPolicy"]; ok {
+		policy, _ := value.(string)
+		switch metav1.DeletionPropagation(policy) {
+		case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+			propagation = metav1.DeletionPropagation(policy)
+		default:
+			return opts, fmt.Errorf("invalid propagationPolicy %v: must be one of Foreground, Background, Orphan", value)
+		}
+	}
+
+	if value, ok := args["gracePeriodSeconds"]; ok {
+		seconds, ok := toInt64(value)
+		if !ok || seconds < 0 {
+			return opts, fmt.Errorf("gracePeriodSeconds must be a non-negative integer")
+		}
+		opts.GracePeriodSeconds = &seconds
+	}
+
+	if value, ok := args["preconditions"]; ok {
+		preconditions, ok := value.(map[string]any)
+		if !ok {
+			return opts, fmt.Errorf("preconditions must be an object")
+		}
+		opts.Preconditions = &metav1.Preconditions{}
+		for key, raw := range preconditions {
+			str, ok := raw.(string)
+			if !ok || str == "" {
+				return opts, fmt.Errorf("preconditions.%s must be a non-empty string", key)
+			}
+			switch key {
+			case "uid":
+				uid := types.UID(str)
+				opts.Preconditions.UID = &uid
+			case "resourceVersion":
+				opts.Preconditions.ResourceVersion = &str
+			default:
+				return opts, fmt.Errorf("unknown precondition %q: must be uid or resourceVersion", key)
+			}
+		}
+		if opts.Preconditions.UID == nil && opts.Preconditions.ResourceVersion == nil {
+			return opts, fmt.Errorf("preconditions must set uid or resourceVersion")
+		}
+	}
+
+	return opts, nil
+}
+
 func (e *Extension) handleDelete(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
 	}
 
 	args, ok := req.Args.(map[string]any)
 	if !ok {
-		return sdk.Failure(fmt.Errorf("args must be an object")), nil
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	if _, ok := args["selector"]; ok {
+		return e.deleteSelected(ctx, args), nil
+	}
+	if _, ok := args["deleteCollection"]; ok {
+		return failure(fmt.Errorf("deleteCollection requires selector")), nil
 	}
 
 	ref, err := parseResourceRef(args)
 	if err != nil {
-		return sdk.Failure(err), nil
+		return failure(err), nil
 	}
 
 	ignoreNotFound, _ := args["ignoreNotFound"].(bool)
 
-	gvr, err := ref.gvr()
+	deleteOpts, err := parseDeleteOptions(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	force, _ := args["force"].(bool)
+	forceAfter := 10 * time.Second
+	if value, ok := args["forceAfter"].(string); ok && value != "" {
+		forceAfter, err = time.ParseDuration(value)
+		if err != nil {
+			return failure(fmt.Errorf("invalid forceAfter format: %w", err)), nil
+		}
+	}
+	// Check the safety rules before deleting anything, so a refused force leaves the object alone
+	if force {
+		allowUnowned, _ := args["allowUnowned"].(bool)
+		if err := e.checkForceAllowed(ctx, ref, allowUnowned); err != nil {
+			return failure(err), nil
+		}
+	}
+
+	gvr, err := e.resourceFor(ref)
 	if err != nil {
-		return sdk.Failure(err), nil
+		return failure(err), nil
 	}
 
 	e.LogInfo(ctx, "Deleting resource", map[string]any{
@@ -36,13 +122,9 @@ func (e *Extension) handleDelete(ctx context.Context, req *sdk.OperationRequest)
 		"name":           ref.name,
 		"namespace":      ref.namespace,
 		"ignoreNotFound": ignoreNotFound,
+		"propagation":    *deleteOpts.PropagationPolicy,
 	})
 
-	propagation := metav1.DeletePropagationForeground
-	deleteOpts := metav1.DeleteOptions{
-		PropagationPolicy: &propagation,
-	}
-
 	err = e.client.Delete(ctx, gvr, ref.name, ref.namespace, deleteOpts)
 	if err != nil {
 		if ignoreNotFound && apierrors.IsNotFound(err) {
@@ -52,12 +134,28 @@ func (e *Extension) handleDelete(ctx context.Context, req *sdk.OperationRequest)
 			})
 			return sdk.Success(fmt.Sprintf("%s/%s not found (ignored)", ref.kind, ref.name)), nil
 		}
+		// The API server reports failed preconditions as conflicts
+		if deleteOpts.Preconditions != nil && apierrors.IsConflict(err) {
+			e.LogWarn(ctx, "Resource does not match delete preconditions", map[string]any{
+				"kind":  ref.kind,
+				"name":  ref.name,
+				"error": err.Error(),
+			})
+			return failureWithCategory(categoryAgent,
+				fmt.Sprintf("%s/%s was not deleted because it no longer matches the preconditions", ref.kind, ref.name),
+				err,
+			), nil
+		}
 		e.LogError(ctx, "Failed to delete resource", map[string]any{
 			"kind":  ref.kind,
 			"name":  ref.name,
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to delete resource: %w", err)), nil
+		return failure(fmt.Errorf("failed to delete resource: %w", err)), nil
+	}
+
+	if force {
+		return e.forceDeletion(ctx, gvr, ref, forceAfter), nil
 	}
 
 	e.LogInfo(ctx, "Resource deleted successfully", map[string]any{
@@ -67,3 +165,183 @@ func (e *Extension) handleDelete(ctx context.Context, req *sdk.OperationRequest)
 
 	return sdk.Success(fmt.Sprintf("Deleted %s/%s", ref.kind, ref.name)), nil
 }
+
+// forceDeletion waits up to forceAfter for a deleted object to go away and, if it is
+// still there, removes its finalizers and waits for it again.
+func (e *Extension) forceDeletion(ctx context.Context, gvr schema.GroupVersionResource, ref *resourceRef, forceAfter time.Duration) *sdk.OperationResult {
+	gone, err := e.waitForDeletion(ctx, gvr, ref, forceAfter)
+	if err != nil {
+		return failure(fmt.Errorf("failed to wait for deletion of %s: %w", refName(ref), err))
+	}
+	if gone {
+		e.LogInfo(ctx, "Resource deleted successfully", map[string]any{
+			"kind": ref.kind,
+			"name": ref.name,
+		})
+		return sdk.SuccessWithOutputs(
+			fmt.Sprintf("Deleted %s/%s", ref.kind, ref.name),
+			map[string]string{"forced": "false", "finalizersRemoved": ""},
+		)
+	}
+
+	removed, err := e.removeFinalizers(ctx, gvr, ref)
+	if err != nil && !apierrors.IsNotFound(err) {
+		e.LogError(ctx, "Failed to remove finalizers", map[string]any{
+			"resource": refName(ref),
+			"error":    err.Error(),
+		})
+		return failure(err)
+	}
+	e.LogWarn(ctx, "Removed finalizers of stuck resource", map[string]any{
+		"resource":   refName(ref),
+		"after":      forceAfter.String(),
+		"finalizers": removed,
+	})
+
+	gone, err = e.waitForDeletion(ctx, gvr, ref, forceAfter)
+	if err != nil {
+		return failure(fmt.Errorf("failed to wait for deletion of %s: %w", refName(ref), err))
+	}
+	if !gone {
+		return failureWithCategory(categoryInfra,
+			fmt.Sprintf("%s still exists after removing its finalizers", refName(ref)),
+			fmt.Errorf("%s was not deleted within %s after removing finalizers %s", refName(ref), forceAfter, strings.Join(removed, ",")),
+		)
+	}
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Deleted %s/%s after removing %d finalizer(s)", ref.kind, ref.name, len(removed)),
+		map[string]string{"forced": "true", "finalizersRemoved": strings.Join(removed, ",")},
+	)
+}
+
+// deleteSelected deletes the resources matching a selector, refusing to delete more than
+// maxCount of them. By default only the listed resources are deleted, each with a UID
+// precondition, so resources created or relabelled after the list are never deleted. With
+// deleteCollection, they are removed with a single DeleteCollection request instead, falling
+// back to deleting the listed resources where the API server does not support it, for
+// example across namespaces. With dryRun, it only reports what would be deleted.
+func (e *Extension) deleteSelected(ctx context.Context, args map[string]any) *sdk.OperationResult {
+	for _, key := range []string{"force", "preconditions"} {
+		if _, ok := args[key]; ok {
+			return failure(fmt.Errorf("%s cannot be combined with selector", key))
+		}
+	}
+
+	deleteOpts, err := parseDeleteOptions(args)
+	if err != nil {
+		return failure(err)
+	}
+
+	value, ok := args["maxCount"]
+	if !ok {
+		return failure(fmt.Errorf("maxCount is required when deleting by selector"))
+	}
+	maxCount, ok := toInt64(value)
+	if !ok || maxCount < 1 {
+		return failure(fmt.Errorf("maxCount must be a positive integer"))
+	}
+	dryRun, _ := args["dryRun"].(bool)
+	deleteCollection, _ := args["deleteCollection"].(bool)
+
+	targets, err := parseWaitTargets(args)
+	if err != nil {
+		return failure(err)
+	}
+
+	refs, err := e.resolveTargets(ctx, targets)
+	if err != nil {
+		e.LogError(ctx, "Failed to list resources to delete", map[string]any{
+			"targets": targets.String(),
+			"error":   err.Error(),
+		})
+		return failure(err)
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, refName(ref))
+	}
+	namesJSON, _ := json.Marshal(names)
+	outputs := map[string]string{
+		"count":     fmt.Sprintf("%d", len(refs)),
+		"resources": string(namesJSON),
+		"dryRun":    fmt.Sprintf("%t", dryRun),
+	}
+
+	if int64(len(refs)) > maxCount {
+		err := fmt.Errorf("%s: %d resources exceed maxCount %d: %s", targets, len(refs), maxCount, strings.Join(names, ", "))
+		result := failureWithCategory(categoryTask, fmt.Sprintf("Refusing to delete %d resources", len(refs)), err)
+		for key, value := range outputs {
+			result.Outputs[key] = value
+		}
+		return result
+	}
+	if dryRun {
+		return sdk.SuccessWithOutputs(fmt.Sprintf("Would delete %d resource(s): %s", len(refs), targets), outputs)
+	}
+	if len(refs) == 0 {
+		return sdk.SuccessWithOutputs(fmt.Sprintf("No resources to delete: %s", targets), outputs)
+	}
+
+	gvr, err := e.resourceFor(refs[0])
+	if err != nil {
+		return failure(err)
+	}
+
+	e.LogInfo(ctx, "Deleting resources", map[string]any{
+		"targets":          targets.String(),
+		"resources":        names,
+		"propagation":      *deleteOpts.PropagationPolicy,
+		"deleteCollection": deleteCollection,
+	})
+
+	if deleteCollection {
+		err = e.client.DeleteCollection(ctx, gvr, targets.namespace, deleteOpts, metav1.ListOptions{
+			LabelSelector: targets.labelSelector,
+			FieldSelector: targets.fieldSelector,
+		})
+		if apierrors.IsMethodNotSupported(err) {
+			e.LogInfo(ctx, "Delete collection not supported, deleting resources one by one", map[string]any{
+				"targets": targets.String(),
+			})
+			err = e.deleteEach(ctx, gvr, refs, deleteOpts)
+		}
+	} else {
+		err = e.deleteEach(ctx, gvr, refs, deleteOpts)
+	}
+	if err != nil {
+		e.LogError(ctx, "Failed to delete resources", map[string]any{
+			"targets": targets.String(),
+			"error":   err.Error(),
+		})
+		return failure(fmt.Errorf("failed to delete %s: %w", targets, err))
+	}
+
+	return sdk.SuccessWithOutputs(fmt.Sprintf("Deleted %d resource(s): %s", len(refs), targets), outputs)
+}
+
+// deleteEach deletes the referenced resources, skipping those that are already gone.
+// Resources with a UID are deleted only while their name still refers to that object;
+// a conflict means it was deleted and recreated, so the listed object is gone too.
+func (e *Extension) deleteEach(ctx context.Context, gvr schema.GroupVersionResource, refs []*resourceRef, opts metav1.DeleteOptions) error {
+	for _, ref := range refs {
+		refOpts := opts
+		if ref.uid != "" {
+			uid := ref.uid
+			refOpts.Preconditions = &metav1.Preconditions{UID: &uid}
+		}
+		err := e.client.Delete(ctx, gvr, ref.name, ref.namespace, refOpts)
+		if apierrors.IsConflict(err) && ref.uid != "" {
+			e.LogInfo(ctx, "Resource was replaced after listing, not deleting it", map[string]any{
+				"resource": refName(ref),
+				"uid":      string(ref.uid),
+			})
+			continue
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s: %w", refName(ref), err)
+		}
+	}
+	return nil
+}
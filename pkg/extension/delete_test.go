@@ -2,12 +2,16 @@ package extension
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestHandleDelete(t *testing.T) {
@@ -59,6 +63,17 @@ func TestHandleDelete(t *testing.T) {
 			},
 			wantSuccess: false,
 		},
+		{
+			name: "deleteCollection without selector",
+			args: map[string]any{
+				"apiVersion":       "v1",
+				"kind":             "ConfigMap",
+				"metadata":         map[string]any{"name": "test-cm"},
+				"deleteCollection": true,
+			},
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -80,3 +95,349 @@ func TestHandleDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleDeleteOptions(t *testing.T) {
+	deleteArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		deleteErr    error
+		wantSuccess  bool
+		wantOpts     string
+		wantCategory string
+	}{
+		{
+			name:        "defaults to foreground propagation",
+			args:        deleteArgs(nil),
+			wantSuccess: true,
+			wantOpts:    "propagation=Foreground grace=<nil> uid=<nil> resourceVersion=<nil>",
+		},
+		{
+			name: "all options",
+			args: deleteArgs(map[string]any{
+				"propagationPolicy":  "Orphan",
+				"gracePeriodSeconds": float64(0),
+				"preconditions":      map[string]any{"uid": "1234", "resourceVersion": "42"},
+			}),
+			wantSuccess: true,
+			wantOpts:    "propagation=Orphan grace=0 uid=1234 resourceVersion=42",
+		},
+		{
+			name:         "unknown propagation policy",
+			args:         deleteArgs(map[string]any{"propagationPolicy": "Cascade"}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "negative grace period",
+			args:         deleteArgs(map[string]any{"gracePeriodSeconds": float64(-1)}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "fractional grace period",
+			args:         deleteArgs(map[string]any{"gracePeriodSeconds": 1.5}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "unknown precondition",
+			args:         deleteArgs(map[string]any{"preconditions": map[string]any{"name": "web"}}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "empty preconditions",
+			args:         deleteArgs(map[string]any{"preconditions": map[string]any{}}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "precondition not met",
+			args:         deleteArgs(map[string]any{"preconditions": map[string]any{"uid": "1234"}}),
+			deleteErr:    apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "web", fmt.Errorf("the UID in the precondition (1234) does not match the UID in record (5678)")),
+			wantSuccess:  false,
+			wantOpts:     "propagation=Foreground grace=<nil> uid=1234 resourceVersion=<nil>",
+			wantCategory: categoryAgent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOpts string
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client: &mockClient{
+					deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+						gotOpts = describeDeleteOptions(opts)
+						return tt.deleteErr
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleDelete(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleDelete() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleDelete() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if gotOpts != tt.wantOpts {
+				t.Errorf("delete options = %q, want %q", gotOpts, tt.wantOpts)
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+// describeDeleteOptions formats the options handleDelete sets for comparison.
+func describeDeleteOptions(opts metav1.DeleteOptions) string {
+	deref := func(v any) string {
+		switch p := v.(type) {
+		case *metav1.DeletionPropagation:
+			if p != nil {
+				return string(*p)
+			}
+		case *int64:
+			if p != nil {
+				return fmt.Sprint(*p)
+			}
+		case *string:
+			if p != nil {
+				return *p
+			}
+		case *types.UID:
+			if p != nil {
+				return string(*p)
+			}
+		}
+		return "<nil>"
+	}
+	var uid *types.UID
+	var resourceVersion *string
+	if opts.Preconditions != nil {
+		uid, resourceVersion = opts.Preconditions.UID, opts.Preconditions.ResourceVersion
+	}
+	return fmt.Sprintf("propagation=%s grace=%s uid=%s resourceVersion=%s",
+		deref(opts.PropagationPolicy), deref(opts.GracePeriodSeconds), deref(uid), deref(resourceVersion))
+}
+
+func TestHandleDeleteSelector(t *testing.T) {
+	configMaps := func(names ...string) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{}
+		for _, name := range names {
+			item := unstructured.Unstructured{}
+			item.SetName(name)
+			item.SetNamespace("default")
+			item.SetUID(types.UID("uid-" + name))
+			list.Items = append(list.Items, item)
+		}
+		return list
+	}
+	selectorArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"namespace": "default"},
+			"selector":   "task=foo",
+			"maxCount":   float64(5),
+		}
+		for key, value := range extra {
+			if value == nil {
+				delete(args, key)
+				continue
+			}
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name              string
+		args              map[string]any
+		items             []string
+		deleteErrs        map[string]error
+		collectionErr     error
+		wantSuccess       bool
+		wantCollection    bool
+		wantDeleted       []string
+		wantOutputs       map[string]string
+		wantCategory      string
+		wantErrorContains string
+	}{
+		{
+			name:        "delete listed resources",
+			args:        selectorArgs(nil),
+			items:       []string{"a", "b"},
+			wantSuccess: true,
+			wantDeleted: []string{"default/a uid=uid-a", "default/b uid=uid-b"},
+			wantOutputs: map[string]string{"count": "2", "resources": `["ConfigMap default/a","ConfigMap default/b"]`, "dryRun": "false"},
+		},
+		{
+			name:  "resources gone or replaced after listing",
+			args:  selectorArgs(nil),
+			items: []string{"a", "b"},
+			deleteErrs: map[string]error{
+				"a": apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "a"),
+				"b": apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "b", fmt.Errorf("uid mismatch")),
+			},
+			wantSuccess: true,
+			wantDeleted: []string{"default/a uid=uid-a", "default/b uid=uid-b"},
+		},
+		{
+			name:           "delete collection",
+			args:           selectorArgs(map[string]any{"deleteCollection": true}),
+			items:          []string{"a", "b"},
+			wantSuccess:    true,
+			wantCollection: true,
+			wantOutputs:    map[string]string{"count": "2", "resources": `["ConfigMap default/a","ConfigMap default/b"]`, "dryRun": "false"},
+		},
+		{
+			name:           "delete collection not supported",
+			args:           selectorArgs(map[string]any{"deleteCollection": true}),
+			items:          []string{"a", "b"},
+			collectionErr:  apierrors.NewMethodNotSupported(schema.GroupResource{Resource: "configmaps"}, "deletecollection"),
+			wantSuccess:    true,
+			wantCollection: true,
+			wantDeleted:    []string{"default/a uid=uid-a", "default/b uid=uid-b"},
+		},
+		{
+			name:           "delete collection fails",
+			args:           selectorArgs(map[string]any{"deleteCollection": true}),
+			items:          []string{"a"},
+			collectionErr:  apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", fmt.Errorf("denied")),
+			wantSuccess:    false,
+			wantCollection: true,
+			wantCategory:   categoryTask,
+		},
+		{
+			name:        "delete collection dry run",
+			args:        selectorArgs(map[string]any{"deleteCollection": true, "dryRun": true}),
+			items:       []string{"a"},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "1", "dryRun": "true"},
+		},
+		{
+			name:        "dry run",
+			args:        selectorArgs(map[string]any{"dryRun": true}),
+			items:       []string{"a"},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "1", "resources": `["ConfigMap default/a"]`, "dryRun": "true"},
+		},
+		{
+			name:        "nothing matches",
+			args:        selectorArgs(nil),
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "0", "resources": `[]`},
+		},
+		{
+			name:              "more than maxCount",
+			args:              selectorArgs(map[string]any{"maxCount": float64(1)}),
+			items:             []string{"a", "b"},
+			wantSuccess:       false,
+			wantOutputs:       map[string]string{"count": "2"},
+			wantCategory:      categoryTask,
+			wantErrorContains: "2 resources exceed maxCount 1",
+		},
+		{
+			name:         "maxCount is required",
+			args:         selectorArgs(map[string]any{"maxCount": nil}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "force is not supported",
+			args:         selectorArgs(map[string]any{"force": true}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "delete fails",
+			args:         selectorArgs(nil),
+			items:        []string{"a", "b"},
+			deleteErrs:   map[string]error{"a": apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "a", fmt.Errorf("denied"))},
+			wantSuccess:  false,
+			wantDeleted:  []string{"default/a uid=uid-a"},
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var collection bool
+			var deleted []string
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client: &mockClient{
+					listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+						if opts.LabelSelector != "task=foo" {
+							return nil, fmt.Errorf("unexpected selector %q", opts.LabelSelector)
+						}
+						return configMaps(tt.items...), nil
+					},
+					deleteCollectionFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+						if namespace != "default" || listOpts.LabelSelector != "task=foo" {
+							return fmt.Errorf("unexpected delete collection in %q with %q", namespace, listOpts.LabelSelector)
+						}
+						collection = true
+						return tt.collectionErr
+					},
+					deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+						var uid types.UID
+						if opts.Preconditions != nil && opts.Preconditions.UID != nil {
+							uid = *opts.Preconditions.UID
+						}
+						deleted = append(deleted, fmt.Sprintf("%s/%s uid=%s", namespace, name, uid))
+						return tt.deleteErrs[name]
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleDelete(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleDelete() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleDelete() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if collection != tt.wantCollection {
+				t.Errorf("delete collection called = %v, want %v", collection, tt.wantCollection)
+			}
+			if fmt.Sprint(deleted) != fmt.Sprint(tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+			if tt.wantErrorContains != "" && !strings.Contains(result.Error, tt.wantErrorContains) {
+				t.Errorf("error %q does not contain %q", result.Error, tt.wantErrorContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	serviceGVR       = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	endpointSliceGVR = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+)
+
+// serviceEndpoints is what the EndpointSlices of a service report.
+type serviceEndpoints struct {
+	ready    int
+	notReady int
+	// ports lists the served ports as name:port, or port for unnamed ports.
+	ports []string
+	// pods lists the pods behind ready endpoints.
+	pods []string
+}
+
+// endpointExpectation is what the endpoints of a service must satisfy.
+type endpointExpectation struct {
+	minReady int64
+	ports    []string
+	pods     []string
+}
+
+// handleServiceEndpoints checks the ready endpoints of a service, as reported by its
+// EndpointSlices, and explains why a service has no usable endpoints.
+func (e *Extension) handleServiceEndpoints(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	namespace, name := "", ""
+	if metadata, ok := args["metadata"].(map[string]any); ok {
+		namespace, _ = metadata["namespace"].(string)
+		name, _ = metadata["name"].(string)
+	}
+	if name == "" {
+		return failure(fmt.Errorf("metadata.name is required")), nil
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	want := endpointExpectation{minReady: 1}
+	if value, ok := args["minReady"]; ok {
+		n, ok := toInt64(value)
+		if !ok || n < 0 {
+			return failure(fmt.Errorf("minReady must be a non-negative integer")), nil
+		}
+		want.minReady = n
+	}
+	if value, ok := args["ports"]; ok {
+		ports, ok := toPortList(value)
+		if !ok {
+			return failure(fmt.Errorf("ports must be a list of port names or numbers")), nil
+		}
+		want.ports = ports
+	}
+	if value, ok := args["pods"]; ok {
+		pods, ok := toStringSlice(value)
+		if !ok {
+			return failure(fmt.Errorf("pods must be a list of pod names")), nil
+		}
+		want.pods = pods
+	}
+
+	timeoutStr, _ := args["timeout"].(string)
+	timeout := time.Duration(0)
+	if timeoutStr != "" {
+		var err error
+		timeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+		}
+	}
+
+	e.LogInfo(ctx, "Checking service endpoints", map[string]any{
+		"namespace": namespace,
+		"name":      name,
+		"minReady":  want.minReady,
+		"timeout":   timeoutStr,
+	})
+
+	var service *corev1.Service
+	var endpoints *serviceEndpoints
+	var mismatches []string
+	var lastErr error
+	poll := func(ctx context.Context) (bool, error) {
+		service, endpoints, lastErr = e.readServiceEndpoints(ctx, namespace, name)
+		if lastErr != nil {
+			// The service may not have been created yet
+			if apierrors.IsNotFound(lastErr) {
+				return false, nil
+			}
+			return false, lastErr
+		}
+		mismatches = want.check(endpoints)
+		return len(mismatches) == 0, nil
+	}
+
+	if timeout > 0 {
+		_ = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, poll)
+	} else {
+		_, _ = poll(ctx)
+	}
+
+	if lastErr != nil {
+		e.LogError(ctx, "Failed to read service endpoints", map[string]any{
+			"namespace": namespace,
+			"name":      name,
+			"error":     lastErr.Error(),
+		})
+		return failure(lastErr), nil
+	}
+
+	outputs := endpointOutputs(endpoints)
+	if len(mismatches) > 0 {
+		reasons, err := e.diagnoseEndpoints(ctx, service, endpoints)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("could not diagnose: %v", err))
+		}
+		e.LogError(ctx, "Service endpoints do not meet expectations", map[string]any{
+			"namespace":  namespace,
+			"name":       name,
+			"mismatches": strings.Join(mismatches, "; "),
+			"reasons":    strings.Join(reasons, "; "),
+		})
+
+		result := failureWithCategory(categoryAgent,
+			fmt.Sprintf("Service %s/%s endpoints do not meet expectations", namespace, name),
+			errors.New(strings.Join(append(mismatches, reasons...), "\n")),
+		)
+		for key, value := range outputs {
+			result.Outputs[key] = value
+		}
+		result.Outputs["reasons"] = strings.Join(reasons, "; ")
+		return result, nil
+	}
+
+	e.LogInfo(ctx, "Service endpoints ready", map[string]any{
+		"namespace": namespace,
+		"name":      name,
+		"ready":     endpoints.ready,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Service %s/%s has %d ready endpoint(s)", namespace, name, endpoints.ready),
+		outputs,
+	), nil
+}
+
+// readServiceEndpoints returns the service and a summary of its EndpointSlices.
+func (e *Extension) readServiceEndpoints(ctx context.Context, namespace, name string) (*corev1.Service, *serviceEndpoints, error) {
+	obj, err := e.client.Get(ctx, serviceGVR, name, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	var service corev1.Service
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &service); err != nil {
+		return nil, nil, fmt.Errorf("failed to read service %s: %w", name, err)
+	}
+
+	list, err := e.client.List(ctx, endpointSliceGVR, namespace, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + name,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list EndpointSlices of service %s: %w", name, err)
+	}
+
+	summary := &serviceEndpoints{}
+	ports := make(map[string]bool)
+	pods := make(map[string]bool)
+	for i := range list.Items {
+		var slice discoveryv1.EndpointSlice
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &slice); err != nil {
+			return nil, nil, fmt.Errorf("failed to read EndpointSlice %s: %w", list.Items[i].GetName(), err)
+		}
+		for _, port := range slice.Ports {
+			ports[formatEndpointPort(port)] = true
+		}
+		for _, endpoint := range slice.Endpoints {
+			// A missing ready condition means the endpoint is ready
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				summary.notReady++
+				continue
+			}
+			summary.ready++
+			if ref := endpoint.TargetRef; ref != nil && ref.Kind == "Pod" {
+				pods[ref.Name] = true
+			}
+		}
+	}
+	summary.ports = sortedKeys(ports)
+	summary.pods = sortedKeys(pods)
+	return &service, summary, nil
+}
+
+// check returns the expectations the endpoints do not meet.
+func (w endpointExpectation) check(endpoints *serviceEndpoints) []string {
+	var result expectationResult
+	if int64(endpoints.ready) < w.minReady {
+		result.addf("expected at least %d ready endpoint(s) but got %d (%d not ready)", w.minReady, endpoints.ready, endpoints.notReady)
+	}
+	for _, port := range w.ports {
+		if !hasEndpointPort(endpoints.ports, port) {
+			result.addf("expected port %s but the endpoints serve [%s]", port, strings.Join(endpoints.ports, ", "))
+		}
+	}
+	for _, pod := range w.pods {
+		if !slices.Contains(endpoints.pods, pod) {
+			result.addf("expected pod %s behind a ready endpoint but got [%s]", pod, strings.Join(endpoints.pods, ", "))
+		}
+	}
+	return result.mismatches
+}
+
+// diagnoseEndpoints explains why a service lacks ready endpoints: it has no selector,
+// its selector matches no pods, the matching pods are not ready, or the target ports
+// of the service are not declared by the pods.
+func (e *Extension) diagnoseEndpoints(ctx context.Context, service *corev1.Service, endpoints *serviceEndpoints) ([]string, error) {
+	if len(service.Spec.Selector) == 0 {
+		return []string{"the service has no selector, so its endpoints are not managed by Kubernetes"}, nil
+	}
+
+	selector := labels.SelectorFromSet(service.Spec.Selector).String()
+	list, err := e.client.List(ctx, podGVR, service.Namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return []string{fmt.Sprintf("the selector %s matches no pods in namespace %s", selector, service.Namespace)}, nil
+	}
+
+	var reasons []string
+	pods := make([]corev1.Pod, 0, len(list.Items))
+	var notReady []string
+	for i := range list.Items {
+		pod, err := toPod(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, *pod)
+		if !isPodReady(pod) {
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", pod.Name, pod.Status.Phase))
+		}
+	}
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		reasons = append(reasons, fmt.Sprintf("%d of %d pod(s) matching %s are not ready: %s", len(notReady), len(pods), selector, strings.Join(notReady, ", ")))
+	}
+
+	for _, port := range service.Spec.Ports {
+		if port.TargetPort.Type != intstr.String {
+			continue
+		}
+		for _, pod := range pods {
+			if !declaresPort(&pod, port.TargetPort.StrVal) {
+				reasons = append(reasons, fmt.Sprintf("service port %s targets port name %q, which pod %s does not declare", servicePortName(port), port.TargetPort.StrVal, pod.Name))
+			}
+		}
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, fmt.Sprintf("all %d pod(s) matching %s are ready and declare the target ports", len(pods), selector))
+	}
+	return reasons, nil
+}
+
+// endpointOutputs returns the outputs describing endpoints.
+func endpointOutputs(endpoints *serviceEndpoints) map[string]string {
+	outputs := map[string]string{
+		"ready":    "0",
+		"notReady": "0",
+		"ports":    "[]",
+		"pods":     "[]",
+	}
+	if endpoints == nil {
+		return outputs
+	}
+	portsJSON, _ := json.Marshal(endpoints.ports)
+	podsJSON, _ := json.Marshal(endpoints.pods)
+	outputs["ready"] = fmt.Sprintf("%d", endpoints.ready)
+	outputs["notReady"] = fmt.Sprintf("%d", endpoints.notReady)
+	outputs["ports"] = string(portsJSON)
+	outputs["pods"] = string(podsJSON)
+	return outputs
+}
+
+// formatEndpointPort renders an EndpointSlice port as name:port, or port when unnamed.
+func formatEndpointPort(port discoveryv1.EndpointPort) string {
+	number := ""
+	if port.Port != nil {
+		number = fmt.Sprintf("%d", *port.Port)
+	}
+	if port.Name == nil || *port.Name == "" {
+		return number
+	}
+	return *port.Name + ":" + number
+}
+
+// hasEndpointPort reports whether ports, as rendered by formatEndpointPort, contain
+// a port with the given name or number.
+func hasEndpointPort(ports []string, want string) bool {
+	for _, port := range ports {
+		name, number, found := strings.Cut(port, ":")
+		if !found {
+			number = name
+		}
+		if name == want || number == want {
+			return true
+		}
+	}
+	return false
+}
+
+// toPortList converts a JSON array of port names and numbers to strings.
+func toPortList(v any) ([]string, bool) {
+	items, ok := v.([]any)
+	if !ok {
+		return toStringSlice(v)
+	}
+	ports := make([]string, 0, len(items))
+	for _, item := range items {
+		if n, ok := toInt64(item); ok {
+			ports = append(ports, fmt.Sprintf("%d", n))
+		} else if name, ok := item.(string); ok {
+			ports = append(ports, name)
+		} else {
+			return nil, false
+		}
+	}
+	return ports, true
+}
+
+// isPodReady reports whether the Ready condition of pod is True.
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// declaresPort reports whether a container of pod declares a port with the given name.
+func declaresPort(pod *corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// servicePortName names a service port for messages.
+func servicePortName(port corev1.ServicePort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return fmt.Sprintf("%d", port.Port)
+}
@@ -0,0 +1,234 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// webService returns service web in default selecting app=web, with port http
+// targeting the given port.
+func webService(targetPort any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]any{"name": "web", "namespace": "default"},
+		"spec": map[string]any{
+			"selector": map[string]any{"app": "web"},
+			"ports": []any{
+				map[string]any{"name": "http", "port": int64(80), "targetPort": targetPort},
+			},
+		},
+	}}
+}
+
+// endpointSlice returns an EndpointSlice of service web serving port http:8080 with
+// one endpoint per pod, ready as given.
+func endpointSlice(ready map[string]bool) unstructured.Unstructured {
+	endpoints := []any{}
+	for _, pod := range sortedKeys(ready) {
+		endpoints = append(endpoints, map[string]any{
+			"addresses":  []any{"10.0.0.1"},
+			"conditions": map[string]any{"ready": ready[pod]},
+			"targetRef":  map[string]any{"kind": "Pod", "name": pod, "namespace": "default"},
+		})
+	}
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion":  "discovery.k8s.io/v1",
+		"kind":        "EndpointSlice",
+		"metadata":    map[string]any{"name": "web-abc", "namespace": "default", "labels": map[string]any{"kubernetes.io/service-name": "web"}},
+		"addressType": "IPv4",
+		"endpoints":   endpoints,
+		"ports":       []any{map[string]any{"name": "http", "port": int64(8080), "protocol": "TCP"}},
+	}}
+}
+
+// webPod returns pod name in default labeled app=web, declaring container port
+// portName and with the given Ready condition status.
+func webPod(name, portName, ready string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": "default", "labels": map[string]any{"app": "web"}},
+		"spec": map[string]any{
+			"containers": []any{map[string]any{
+				"name":  "web",
+				"image": "nginx",
+				"ports": []any{map[string]any{"name": portName, "containerPort": int64(8080)}},
+			}},
+		},
+		"status": map[string]any{
+			"phase":      "Running",
+			"conditions": []any{map[string]any{"type": "Ready", "status": ready}},
+		},
+	}}
+}
+
+// endpointsClient returns a client serving service, the given EndpointSlices and pods.
+func endpointsClient(service *unstructured.Unstructured, slices []unstructured.Unstructured, pods ...unstructured.Unstructured) *mockClient {
+	return &mockClient{
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			if service == nil {
+				return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+			}
+			return service, nil
+		},
+		listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			switch gvr.Resource {
+			case "endpointslices":
+				if opts.LabelSelector != "kubernetes.io/service-name=web" {
+					return nil, fmt.Errorf("unexpected selector %q", opts.LabelSelector)
+				}
+				return &unstructured.UnstructuredList{Items: slices}, nil
+			case "pods":
+				if opts.LabelSelector != "app=web" {
+					return nil, fmt.Errorf("unexpected selector %q", opts.LabelSelector)
+				}
+				return &unstructured.UnstructuredList{Items: pods}, nil
+			}
+			return nil, fmt.Errorf("unexpected list of %s", gvr.Resource)
+		},
+	}
+}
+
+func TestHandleServiceEndpoints(t *testing.T) {
+	serviceArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"metadata": map[string]any{"name": "web", "namespace": "default"},
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		client       *mockClient
+		wantSuccess  bool
+		wantErrors   []string
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name: "ready endpoints",
+			args: serviceArgs(map[string]any{
+				"minReady": float64(2),
+				"ports":    []any{"http", float64(8080)},
+				"pods":     []any{"web-1"},
+			}),
+			client:      endpointsClient(webService("http"), []unstructured.Unstructured{endpointSlice(map[string]bool{"web-1": true, "web-2": true})}),
+			wantSuccess: true,
+			wantOutputs: map[string]string{"ready": "2", "notReady": "0", "ports": `["http:8080"]`, "pods": `["web-1","web-2"]`},
+		},
+		{
+			name:         "selector matches no pods",
+			args:         serviceArgs(nil),
+			client:       endpointsClient(webService("http"), nil),
+			wantSuccess:  false,
+			wantErrors:   []string{"expected at least 1 ready endpoint(s) but got 0", "the selector app=web matches no pods in namespace default"},
+			wantOutputs:  map[string]string{"ready": "0"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "pods not ready",
+			args: serviceArgs(nil),
+			client: endpointsClient(webService("http"),
+				[]unstructured.Unstructured{endpointSlice(map[string]bool{"web-1": false})},
+				webPod("web-1", "http", "False"),
+			),
+			wantSuccess: false,
+			wantErrors:  []string{"(1 not ready)", "1 of 1 pod(s) matching app=web are not ready: web-1 (Running)"},
+			wantOutputs: map[string]string{"notReady": "1"},
+		},
+		{
+			name: "target port name not declared",
+			args: serviceArgs(nil),
+			client: endpointsClient(webService("web"), nil,
+				webPod("web-1", "http", "True"),
+			),
+			wantSuccess: false,
+			wantErrors:  []string{`service port http targets port name "web", which pod web-1 does not declare`},
+		},
+		{
+			name:        "missing expected port",
+			args:        serviceArgs(map[string]any{"ports": []any{"metrics"}}),
+			client:      endpointsClient(webService("http"), []unstructured.Unstructured{endpointSlice(map[string]bool{"web-1": true})}, webPod("web-1", "http", "True")),
+			wantSuccess: false,
+			wantErrors:  []string{"expected port metrics but the endpoints serve [http:8080]"},
+		},
+		{
+			name:        "missing expected pod",
+			args:        serviceArgs(map[string]any{"pods": []any{"web-2"}}),
+			client:      endpointsClient(webService("http"), []unstructured.Unstructured{endpointSlice(map[string]bool{"web-1": true})}, webPod("web-1", "http", "True")),
+			wantSuccess: false,
+			wantErrors:  []string{"expected pod web-2 behind a ready endpoint but got [web-1]"},
+		},
+		{
+			name: "service without selector",
+			args: serviceArgs(nil),
+			client: endpointsClient(&unstructured.Unstructured{Object: map[string]any{
+				"metadata": map[string]any{"name": "web", "namespace": "default"},
+				"spec":     map[string]any{"ports": []any{map[string]any{"port": int64(80)}}},
+			}}, nil),
+			wantSuccess: false,
+			wantErrors:  []string{"the service has no selector"},
+		},
+		{
+			name:         "missing service",
+			args:         serviceArgs(nil),
+			client:       endpointsClient(nil, nil),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "invalid ports",
+			args:         serviceArgs(map[string]any{"ports": []any{true}}),
+			client:       &mockClient{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleServiceEndpoints(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleServiceEndpoints() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleServiceEndpoints() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package extension
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseExpect returns the expect object from operation arguments.
+// It returns nil when the arguments contain no expectations.
+func parseExpect(args map[string]any) (map[string]any, error) {
+	expectArg, hasExpect := args["expect"]
+	if !hasExpect {
+		return nil, nil
+	}
+	expect, ok := expectArg.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expect must be an object")
+	}
+	return expect, nil
+}
+
+// expectationResult collects mismatches between expected and actual values.
+type expectationResult struct {
+	mismatches []string
+}
+
+// failed reports whether any expectation was not met.
+func (r *expectationResult) failed() bool {
+	return len(r.mismatches) > 0
+}
+
+// String joins all mismatches into a single message.
+func (r *expectationResult) String() string {
+	return strings.Join(r.mismatches, "; ")
+}
+
+// addf records a mismatch.
+func (r *expectationResult) addf(format string, args ...any) {
+	r.mismatches = append(r.mismatches, fmt.Sprintf(format, args...))
+}
+
+// checkString compares expect[key], when present, against actual.
+func (r *expectationResult) checkString(expect map[string]any, key, actual string) error {
+	want, ok := expect[key]
+	if !ok {
+		return nil
+	}
+	wantStr, ok := want.(string)
+	if !ok {
+		return fmt.Errorf("expect.%s must be a string", key)
+	}
+	if wantStr != actual {
+		r.addf("expected %s=%q but got %q", key, wantStr, actual)
+	}
+	return nil
+}
+
+// checkBool compares expect[key], when present, against actual.
+func (r *expectationResult) checkBool(expect map[string]any, key string, actual bool) error {
+	want, ok := expect[key]
+	if !ok {
+		return nil
+	}
+	wantBool, ok := want.(bool)
+	if !ok {
+		return fmt.Errorf("expect.%s must be a boolean", key)
+	}
+	if wantBool != actual {
+		r.addf("expected %s=%v but got %v", key, wantBool, actual)
+	}
+	return nil
+}
+
+// checkInt compares expect[key], when present, against actual.
+func (r *expectationResult) checkInt(expect map[string]any, key string, actual int64) error {
+	want, ok := expect[key]
+	if !ok {
+		return nil
+	}
+	wantInt, ok := toInt64(want)
+	if !ok {
+		return fmt.Errorf("expect.%s must be an integer", key)
+	}
+	if wantInt != actual {
+		r.addf("expected %s=%d but got %d", key, wantInt, actual)
+	}
+	return nil
+}
+
+// toInt64 converts a JSON number to int64. Arguments decoded from JSON carry
+// numbers as float64, so integral floats are accepted as well.
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		if n != float64(int64(n)) {
+			return 0, false
+		}
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// toStringSlice converts a JSON array of strings to a string slice.
+func toStringSlice(v any) ([]string, bool) {
+	switch items := v.(type) {
+	case []string:
+		return items, true
+	case []any:
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, s)
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
@@ -3,13 +3,21 @@ package extension
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -17,11 +25,37 @@ import (
 type Extension struct {
 	*sdk.Extension
 	client ResourceClient
+	// runID identifies this extension run on the objects it owns.
+	runID string
+	// suffixes returns the random suffixes of generated names, if set. Cassettes set it
+	// to record the suffixes or to replay the recorded ones.
+	suffixes func(length int) (string, error)
+	// recorder writes the cassette being recorded, if any.
+	recorder *cassetteRecorder
+
+	stateMu sync.Mutex
+	tasks   map[string]*taskState
+	// mappings holds resources discovered for kinds whose plural cannot be guessed.
+	mappings map[schema.GroupVersionKind]schema.GroupVersionResource
+	// crdInstalledAt is when a CustomResourceDefinition was last created.
+	crdInstalledAt time.Time
+
+	// operations holds the wrapped handler of each registered operation by name.
+	operations map[string]sdk.OperationHandler
+	// schemas holds the parameter schema of each registered operation by name.
+	schemas map[string]*jsonschema.Schema
+	// logOutput receives log messages as text instead of the JSON-RPC connection, if set.
+	logOutput io.Writer
+	logMu     sync.Mutex
 }
 
 // New creates a new Kubernetes extension
 func New() *Extension {
-	ext := &Extension{}
+	ext := &Extension{
+		runID:      time.Now().UTC().Format("20060102-150405") + "-" + rand.String(5),
+		operations: make(map[string]sdk.OperationHandler),
+		schemas:    make(map[string]*jsonschema.Schema),
+	}
 	ext.Extension = sdk.NewExtension(
 		sdk.ExtensionInfo{
 			Name:        "kubernetes",
@@ -42,6 +76,41 @@ func (e *Extension) handleInitialize(config map[string]any) error {
 		kubeconfigPath = path
 	}
 
+	runID, _ := config["runId"].(string)
+	if runID != "" {
+		e.runID = runID
+	}
+
+	retryPolicy, err := parseRetryPolicy(config["retry"])
+	if err != nil {
+		return fmt.Errorf("invalid retry config: %w", err)
+	}
+
+	cassette, err := parseCassetteConfig(config["cassette"])
+	if err != nil {
+		return fmt.Errorf("invalid cassette config: %w", err)
+	}
+
+	backend := backendCluster
+	if value, ok := config["backend"]; ok {
+		if backend, ok = value.(string); !ok || (backend != backendCluster && backend != backendFake) {
+			return fmt.Errorf("invalid backend: must be %s or %s", backendCluster, backendFake)
+		}
+	}
+
+	fixtures := ""
+	if value, ok := config["fixtures"]; ok {
+		if fixtures, ok = value.(string); !ok {
+			return fmt.Errorf("invalid fixtures: must be a directory path")
+		}
+		if backend != backendFake {
+			return fmt.Errorf("fixtures requires backend: %s", backendFake)
+		}
+	}
+	if backend == backendFake && cassette != nil && cassette.mode == cassetteReplay {
+		return fmt.Errorf("cassette replay cannot be combined with backend: %s", backendFake)
+	}
+
 	// Expand ~ to home directory
 	if strings.HasPrefix(kubeconfigPath, "~") {
 		home, err := os.UserHomeDir()
@@ -60,32 +129,96 @@ func (e *Extension) handleInitialize(config map[string]any) error {
 		kubeconfigPath = filepath.Join(home, ".kube", "config")
 	}
 
+	// Replayed calls need neither a cluster nor a kubeconfig
+	if cassette != nil && cassette.mode == cassetteReplay {
+		player, err := loadCassette(cassette.path)
+		if err != nil {
+			return err
+		}
+		// Names derived from the run ID or generated suffixes match the recorded calls
+		if recorded, err := player.play(cassetteRequest{Method: cassetteRunID}); err == nil && runID == "" {
+			e.runID = recorded.Text
+		}
+		e.suffixes = func(int) (string, error) {
+			recorded, err := player.play(cassetteRequest{Method: cassetteNameSuffix})
+			return recorded.Text, err
+		}
+		e.client = newRetryingClient(newReplayingClient(player, kubeconfigPath), retryPolicy, e.logRetry)
+		return nil
+	}
+
+	var resourceClient ResourceClient
+	if backend == backendFake {
+		// The fake cluster only reads the kubeconfig for the kubeconfig operations
+		cluster, err := newFakeCluster(fixtures)
+		if err != nil {
+			return err
+		}
+		resourceClient = newFakeClient(cluster, kubeconfigPath)
+	} else if resourceClient, err = newClusterClient(kubeconfigPath); err != nil {
+		return err
+	}
+
+	if cassette != nil {
+		recorder, err := newCassetteRecorder(cassette.path)
+		if err != nil {
+			return err
+		}
+		if err := recorder.recordValue(cassetteRunID, e.runID); err != nil {
+			recorder.Close()
+			return err
+		}
+		e.recorder = recorder
+		e.suffixes = func(length int) (string, error) {
+			suffix := rand.String(length)
+			return suffix, recorder.recordValue(cassetteNameSuffix, suffix)
+		}
+		resourceClient = newRecordingClient(resourceClient, recorder)
+	}
+	e.client = newRetryingClient(resourceClient, retryPolicy, e.logRetry)
+	return nil
+}
+
+// newClusterClient creates a client for the cluster of the kubeconfig at kubeconfigPath.
+func newClusterClient(kubeconfigPath string) (ResourceClient, error) {
 	// Validate kubeconfig file exists
 	if _, err := os.Stat(kubeconfigPath); os.IsNotExist(err) {
-		return fmt.Errorf("kubeconfig not found: %s", kubeconfigPath)
+		return nil, fmt.Errorf("kubeconfig not found: %s", kubeconfigPath)
 	}
 
 	kubeconfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
 	if err != nil {
-		return fmt.Errorf("failed to build kubeconfig from %s: %w", kubeconfigPath, err)
+		return nil, fmt.Errorf("failed to build kubeconfig from %s: %w", kubeconfigPath, err)
 	}
 
 	client, err := dynamic.NewForConfig(kubeconfig)
 	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
 	authzClient, err := authorizationv1client.NewForConfig(kubeconfig)
 	if err != nil {
-		return fmt.Errorf("failed to create authorization client: %w", err)
+		return nil, fmt.Errorf("failed to create authorization client: %w", err)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
 	}
 
-	e.client = &dynamicClientAdapter{
-		client:         client,
-		authzClient:    authzClient,
-		kubeconfigPath: kubeconfigPath,
+	httpClient, err := rest.HTTPClientFor(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
-	return nil
+
+	return &dynamicClientAdapter{
+		client:          client,
+		authzClient:     authzClient,
+		discoveryClient: discoveryClient,
+		httpClient:      httpClient,
+		host:            kubeconfig.Host + kubeconfig.APIPath,
+		kubeconfigPath:  kubeconfigPath,
+	}, nil
 }
 
 // Run starts the extension, listening for JSON-RPC messages on stdin/stdout
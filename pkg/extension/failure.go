@@ -0,0 +1,95 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Failure categories reported in the category output of failed operations.
+const (
+	// categoryInfra marks failures of the cluster or the environment, such as timeouts.
+	categoryInfra = "infra"
+	// categoryTask marks failures caused by the task definition, such as invalid manifests.
+	categoryTask = "task"
+	// categoryAgent marks failures because the agent did not bring the cluster into the expected state.
+	categoryAgent = "agent"
+)
+
+// errClientNotInitialized is returned by operations called before the extension was initialized.
+var errClientNotInitialized = errors.New("kubernetes client not initialized")
+
+// failure returns a failed result for err, classified from the error itself.
+func failure(err error) *sdk.OperationResult {
+	return failureWithCategory(classifyError(err), "", err)
+}
+
+// failureWithCategory returns a failed result in the given category. Every failure
+// carries the statusCode, reason, causes and category outputs, so results can be
+// aggregated without parsing error messages.
+func failureWithCategory(category, message string, err error) *sdk.OperationResult {
+	result := sdk.FailureWithMessage(message, err)
+
+	var statusCode, reason string
+	causes := []metav1.StatusCause{}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		s := status.Status()
+		if s.Code != 0 {
+			statusCode = strconv.Itoa(int(s.Code))
+		}
+		reason = string(s.Reason)
+		if s.Details != nil && len(s.Details.Causes) > 0 {
+			causes = s.Details.Causes
+		}
+	}
+	causesJSON, _ := json.Marshal(causes)
+
+	result.Outputs = map[string]string{
+		"statusCode": statusCode,
+		"reason":     reason,
+		"causes":     string(causesJSON),
+		"category":   category,
+	}
+	return result
+}
+
+// classifyError returns the failure category for err. Server-side and transport
+// errors are infrastructure failures; the API server rejecting a request, and
+// errors in the operation arguments, are failures of the task.
+func classifyError(err error) string {
+	if err == nil {
+		return categoryTask
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		code := status.Status().Code
+		switch {
+		case apierrors.IsTooManyRequests(err),
+			apierrors.IsServerTimeout(err),
+			apierrors.IsTimeout(err),
+			apierrors.IsConflict(err),
+			apierrors.IsUnauthorized(err),
+			code >= 500:
+			return categoryInfra
+		}
+		return categoryTask
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errClientNotInitialized),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr),
+		isConnectionError(err):
+		return categoryInfra
+	}
+	return categoryTask
+}
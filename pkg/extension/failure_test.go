@@ -0,0 +1,103 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestClassifyError(t *testing.T) {
+	gr := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "invalid manifest", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "p", nil), want: categoryTask},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "p", fmt.Errorf("denied")), want: categoryTask},
+		{name: "not found", err: apierrors.NewNotFound(gr, "p"), want: categoryTask},
+		{name: "wrapped already exists", err: fmt.Errorf("failed to create resource: %w", apierrors.NewAlreadyExists(gr, "p")), want: categoryTask},
+		{name: "argument error", err: fmt.Errorf("kind is required"), want: categoryTask},
+		{name: "throttled", err: apierrors.NewTooManyRequests("slow down", 1), want: categoryInfra},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "create", 1), want: categoryInfra},
+		{name: "internal error", err: apierrors.NewInternalError(fmt.Errorf("etcd")), want: categoryInfra},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("expired token"), want: categoryInfra},
+		{name: "connection dropped", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: categoryInfra},
+		{name: "deadline exceeded", err: fmt.Errorf("poll: %w", context.DeadlineExceeded), want: categoryInfra},
+		{name: "client not initialized", err: errClientNotInitialized, want: categoryInfra},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureOutputs(t *testing.T) {
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "p", field.ErrorList{
+		field.Required(field.NewPath("spec", "containers"), "containers are required"),
+	})
+
+	tests := []struct {
+		name           string
+		err            error
+		wantStatusCode string
+		wantReason     string
+		wantCauses     string
+		wantCategory   string
+	}{
+		{
+			name:           "api error with causes",
+			err:            fmt.Errorf("failed to create resource: %w", invalid),
+			wantStatusCode: "422",
+			wantReason:     "Invalid",
+			wantCauses:     `[{"reason":"FieldValueRequired","message":"Required value: containers are required","field":"spec.containers"}]`,
+			wantCategory:   categoryTask,
+		},
+		{
+			name:           "api error without causes",
+			err:            apierrors.NewServiceUnavailable("down"),
+			wantStatusCode: "503",
+			wantReason:     "ServiceUnavailable",
+			wantCauses:     `[]`,
+			wantCategory:   categoryInfra,
+		},
+		{
+			name:         "plain error",
+			err:          fmt.Errorf("kind is required"),
+			wantCauses:   `[]`,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := failure(tt.err)
+			if result.Success {
+				t.Fatalf("failure() returned a successful result")
+			}
+			if result.Error != tt.err.Error() {
+				t.Errorf("Error = %q, want %q", result.Error, tt.err.Error())
+			}
+			want := map[string]string{
+				"statusCode": tt.wantStatusCode,
+				"reason":     tt.wantReason,
+				"causes":     tt.wantCauses,
+				"category":   tt.wantCategory,
+			}
+			for key, value := range want {
+				if got := result.Outputs[key]; got != value {
+					t.Errorf("%s output = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
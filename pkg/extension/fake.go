@@ -0,0 +1,1106 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/testing"
+)
+
+// Backends selected by the backend option of the extension config.
+const (
+	// backendCluster talks to the cluster of the kubeconfig.
+	backendCluster = "cluster"
+	// backendFake keeps resources in memory, seeded from fixture files.
+	backendFake = "fake"
+)
+
+// fakeResource describes a resource served by the fake backend.
+type fakeResource struct {
+	gvk        schema.GroupVersionKind
+	resource   string
+	namespaced bool
+}
+
+// replicaSetGVR is the resource of the ReplicaSets the fake Deployment controller manages.
+var replicaSetGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+
+// builtinResources are the resources the fake backend serves without fixtures.
+var builtinResources = []fakeResource{
+	{schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, "namespaces", false},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Node"}, "nodes", false},
+	{schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}, "persistentvolumes", false},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, "pods", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "services", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"}, "endpoints", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "configmaps", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, "secrets", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, "serviceaccounts", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}, "persistentvolumeclaims", true},
+	{schema.GroupVersionKind{Version: "v1", Kind: "Event"}, "events", true},
+	{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "deployments", true},
+	{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, "replicasets", true},
+	{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, "statefulsets", true},
+	{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}, "daemonsets", true},
+	{schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, "jobs", true},
+	{schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}, "cronjobs", true},
+	{schema.GroupVersionKind{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"}, "endpointslices", true},
+	{schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, "ingresses", true},
+	{schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, "networkpolicies", true},
+	{schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}, "poddisruptionbudgets", true},
+	{schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}, "horizontalpodautoscalers", true},
+	{schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}, "roles", true},
+	{schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}, "rolebindings", true},
+	{schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, "clusterroles", false},
+	{schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}, "clusterrolebindings", false},
+	{schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}, "storageclasses", false},
+	crdResource,
+}
+
+var crdResource = fakeResource{schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, "customresourcedefinitions", false}
+
+// defaultNamespaces exist in every fake cluster, like in a new real one.
+var defaultNamespaces = []string{"default", "kube-system", "kube-public"}
+
+// fakeCluster is an in-memory cluster for running tasks without one. Resources are
+// kept by the client-go fake dynamic client. On top of it, the cluster assigns uids,
+// resource versions and generated names, honors finalizers and preconditions,
+// collects the dependents of deleted owners and the contents of deleted namespaces,
+// and runs simple controllers: pods become ready, Deployments create a ReplicaSet per
+// pod template, ReplicaSets and StatefulSets create their pods and replace deleted ones,
+// workloads become available, Jobs complete, and namespaces, claims and CRDs become
+// active, bound and established.
+type fakeCluster struct {
+	// mu serializes all calls, which also protects the scheme the fake client shares.
+	mu      sync.Mutex
+	client  *fake.FakeDynamicClient
+	scheme  *runtime.Scheme
+	tracker testing.ObjectTracker
+	// resources holds the resources the cluster has served, by resource.
+	resources map[schema.GroupVersionResource]fakeResource
+	// resourceVersion is the last resource version handed out.
+	resourceVersion int64
+	// reconciling holds the uids of the objects whose controller is running, so the
+	// deletions it makes do not run it again.
+	reconciling map[types.UID]bool
+}
+
+// newFakeCluster creates a fake cluster seeded with the objects of the YAML and JSON
+// files in fixtures, searched recursively. An empty fixtures starts an empty cluster.
+func newFakeCluster(fixtures string) (*fakeCluster, error) {
+	scheme := runtime.NewScheme()
+	// Unlike NewSimpleDynamicClient, this keeps the scheme, so kinds can be added later
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(scheme, nil)
+	c := &fakeCluster{
+		client:      client,
+		scheme:      scheme,
+		tracker:     client.Tracker(),
+		resources:   map[schema.GroupVersionResource]fakeResource{},
+		reconciling: map[types.UID]bool{},
+	}
+
+	var objects []*unstructured.Unstructured
+	if fixtures != "" {
+		var err error
+		if objects, err = readFixtures(fixtures); err != nil {
+			return nil, err
+		}
+	}
+
+	// CRDs first, so the custom resources of the fixtures can be mapped
+	slices.SortStableFunc(objects, func(a, b *unstructured.Unstructured) int {
+		return boolToInt(b.GetKind() == "CustomResourceDefinition") - boolToInt(a.GetKind() == "CustomResourceDefinition")
+	})
+
+	namespaces := slices.Clone(defaultNamespaces)
+	for _, obj := range objects {
+		if ns := obj.GetNamespace(); ns != "" && !slices.Contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	for _, name := range namespaces {
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		ns.SetName(name)
+		if err := c.seed(ns); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+	}
+
+	for _, obj := range objects {
+		if obj.GetKind() == "Namespace" {
+			// Replace the namespace created above with the fixture
+			_ = c.tracker.Delete(namespaceGVR, "", obj.GetName())
+		}
+		if err := c.seed(obj); err != nil {
+			return nil, fmt.Errorf("failed to load fixture %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return c, nil
+}
+
+// readFixtures reads the objects of the YAML and JSON files under dir. Files may hold
+// several documents and List objects.
+func readFixtures(dir string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+		for {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("failed to parse fixture %s: %w", path, err)
+			}
+			// Decode numbers as integers where possible, like the API server
+			content := map[string]any{}
+			if err := utiljson.Unmarshal(raw, &content); err != nil {
+				return fmt.Errorf("failed to parse fixture %s: %w", path, err)
+			}
+			if len(content) == 0 {
+				continue
+			}
+			obj := &unstructured.Unstructured{Object: content}
+			if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+				return fmt.Errorf("fixture %s: apiVersion and kind are required", path)
+			}
+			if !obj.IsList() {
+				objects = append(objects, obj)
+				continue
+			}
+			if err := obj.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, item.(*unstructured.Unstructured))
+				return nil
+			}); err != nil {
+				return fmt.Errorf("fixture %s: %w", path, err)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	return objects, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// seed adds a fixture object. Objects without a status are reconciled like new ones;
+// objects with a status are kept as written, to capture states such as failing pods.
+func (c *fakeCluster) seed(obj *unstructured.Unstructured) error {
+	r := c.resourceForKind(obj.GroupVersionKind())
+	if r.namespaced && obj.GetNamespace() == "" {
+		obj.SetNamespace(metav1.NamespaceDefault)
+	}
+	if !r.namespaced {
+		obj.SetNamespace("")
+	}
+	c.initMetadata(obj)
+
+	gvr := r.gvk.GroupVersion().WithResource(r.resource)
+	if err := c.tracker.Create(gvr, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	if status, ok := obj.Object["status"].(map[string]any); ok && len(status) > 0 {
+		return nil
+	}
+	return c.reconcile(gvr, obj.GetNamespace(), obj.GetName())
+}
+
+// resourceForKind returns the resource serving gvk, guessing the plural for unknown
+// kinds, and remembers it.
+func (c *fakeCluster) resourceForKind(gvk schema.GroupVersionKind) fakeResource {
+	for _, r := range c.servedResources() {
+		if r.gvk == gvk {
+			c.remember(r)
+			return r
+		}
+	}
+	r := fakeResource{gvk: gvk, resource: gvkToGVR(gvk).Resource, namespaced: true}
+	c.remember(r)
+	return r
+}
+
+// resourceFor returns what the cluster knows about gvr.
+func (c *fakeCluster) resourceFor(gvr schema.GroupVersionResource) (fakeResource, bool) {
+	if r, ok := c.resources[gvr]; ok {
+		return r, true
+	}
+	for _, r := range c.servedResources() {
+		if r.gvk.GroupVersion().WithResource(r.resource) == gvr {
+			c.remember(r)
+			return r, true
+		}
+	}
+	return fakeResource{}, false
+}
+
+// remember records a served resource and registers its kinds with the scheme, which
+// the fake client needs to list them.
+func (c *fakeCluster) remember(r fakeResource) {
+	c.resources[r.gvk.GroupVersion().WithResource(r.resource)] = r
+	listGVK := r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List")
+	if !c.scheme.Recognizes(r.gvk) {
+		c.scheme.AddKnownTypeWithName(r.gvk, &unstructured.Unstructured{})
+	}
+	if !c.scheme.Recognizes(listGVK) {
+		c.scheme.AddKnownTypeWithName(listGVK, &unstructured.UnstructuredList{})
+	}
+}
+
+// servedResources returns the built-in resources, those defined by CRDs and those
+// of objects the cluster holds.
+func (c *fakeCluster) servedResources() []fakeResource {
+	served := slices.Clone(builtinResources)
+
+	c.remember(crdResource)
+	if crds, err := c.listObjects(crdResource.gvk.GroupVersion().WithResource(crdResource.resource), crdResource.gvk, ""); err == nil {
+		for _, crd := range crds {
+			group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+			plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+			scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+			versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+			for _, v := range versions {
+				version, _ := v.(map[string]any)
+				name, _ := version["name"].(string)
+				if served, ok := version["served"].(bool); ok && !served {
+					continue
+				}
+				served = append(served, fakeResource{
+					gvk:        schema.GroupVersionKind{Group: group, Version: name, Kind: kind},
+					resource:   plural,
+					namespaced: scope != "Cluster",
+				})
+			}
+		}
+	}
+
+	for _, r := range c.resources {
+		if !slices.ContainsFunc(served, func(s fakeResource) bool { return s.gvk == r.gvk }) {
+			served = append(served, r)
+		}
+	}
+	return served
+}
+
+// listObjects returns the objects of a resource, across all namespaces if namespace
+// is empty.
+func (c *fakeCluster) listObjects(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, namespace string) ([]*unstructured.Unstructured, error) {
+	if _, ok := c.resourceFor(gvr); !ok {
+		c.remember(fakeResource{gvk: gvk, resource: gvr.Resource, namespaced: namespace != ""})
+	}
+	obj, err := c.tracker.List(gvr, gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := obj.(*unstructured.UnstructuredList)
+	if !ok {
+		return nil, fmt.Errorf("unexpected list type %T", obj)
+	}
+	items := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, &list.Items[i])
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// nextResourceVersion hands out a new resource version.
+func (c *fakeCluster) nextResourceVersion() string {
+	c.resourceVersion++
+	return strconv.FormatInt(c.resourceVersion, 10)
+}
+
+// initMetadata sets the fields the API server sets on creation.
+func (c *fakeCluster) initMetadata(obj *unstructured.Unstructured) {
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		obj.SetName(obj.GetGenerateName() + rand.String(5))
+	}
+	if obj.GetUID() == "" {
+		obj.SetUID(uuid.NewUUID())
+	}
+	if created := obj.GetCreationTimestamp(); created.IsZero() {
+		obj.SetCreationTimestamp(metav1.Now())
+	}
+	if obj.GetGeneration() == 0 {
+		obj.SetGeneration(1)
+	}
+	obj.SetResourceVersion(c.nextResourceVersion())
+}
+
+// store writes obj back with a new resource version, bumping the generation if its
+// spec changed, and removes it if it is being deleted and has no finalizers left.
+func (c *fakeCluster) store(gvr schema.GroupVersionResource, previous, obj *unstructured.Unstructured) error {
+	if !reflect.DeepEqual(previous.Object["spec"], obj.Object["spec"]) {
+		obj.SetGeneration(previous.GetGeneration() + 1)
+	}
+	obj.SetUID(previous.GetUID())
+	obj.SetCreationTimestamp(previous.GetCreationTimestamp())
+	if previous.GetDeletionTimestamp() != nil {
+		obj.SetDeletionTimestamp(previous.GetDeletionTimestamp())
+	}
+	obj.SetResourceVersion(c.nextResourceVersion())
+	if err := c.tracker.Update(gvr, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	if obj.GetDeletionTimestamp() != nil && len(obj.GetFinalizers()) == 0 {
+		return c.remove(gvr, obj, metav1.DeletePropagationBackground)
+	}
+	return nil
+}
+
+// get returns the object, or a NotFound error.
+func (c *fakeCluster) get(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	obj, err := c.tracker.Get(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", obj)
+	}
+	return u.DeepCopy(), nil
+}
+
+// delete deletes an object like the API server: objects with finalizers are only
+// marked for deletion until the finalizers are removed.
+func (c *fakeCluster) delete(gvr schema.GroupVersionResource, namespace, name string, opts metav1.DeleteOptions) error {
+	obj, err := c.get(gvr, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if p := opts.Preconditions; p != nil {
+		gr := gvr.GroupResource()
+		if p.UID != nil && *p.UID != obj.GetUID() {
+			return apierrors.NewConflict(gr, name, fmt.Errorf("the UID in the precondition (%s) does not match the UID in record (%s). The object might have been deleted and then recreated", *p.UID, obj.GetUID()))
+		}
+		if p.ResourceVersion != nil && *p.ResourceVersion != obj.GetResourceVersion() {
+			return apierrors.NewConflict(gr, name, fmt.Errorf("the ResourceVersion in the precondition (%s) does not match the ResourceVersion in record (%s). The object might have been modified", *p.ResourceVersion, obj.GetResourceVersion()))
+		}
+	}
+
+	if len(obj.GetFinalizers()) > 0 {
+		if obj.GetDeletionTimestamp() == nil {
+			now := metav1.Now()
+			obj.SetDeletionTimestamp(&now)
+			obj.SetResourceVersion(c.nextResourceVersion())
+			if err := c.tracker.Update(gvr, obj, namespace); err != nil {
+				return err
+			}
+			return c.reconcileOwner(obj)
+		}
+		return nil
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	if opts.PropagationPolicy != nil {
+		propagation = *opts.PropagationPolicy
+	}
+	return c.remove(gvr, obj, propagation)
+}
+
+// remove removes an object along with the contents of a namespace and, unless
+// orphaned, the objects it owns.
+func (c *fakeCluster) remove(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, propagation metav1.DeletionPropagation) error {
+	if err := c.tracker.Delete(gvr, obj.GetNamespace(), obj.GetName()); err != nil {
+		return err
+	}
+
+	for resource, r := range c.resources {
+		if !r.namespaced {
+			continue
+		}
+		items, err := c.listObjects(resource, r.gvk, "")
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			inNamespace := gvr == namespaceGVR && item.GetNamespace() == obj.GetName()
+			if !inNamespace && (propagation == metav1.DeletePropagationOrphan || !isOwnedBy(item, obj.GetUID())) {
+				continue
+			}
+			if err := c.delete(resource, item.GetNamespace(), item.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return c.reconcileOwner(obj)
+}
+
+// reconcileOwner runs the controller of the object's controller owner, so a workload
+// replaces a pod that is deleted or being deleted, like on a cluster.
+func (c *fakeCluster) reconcileOwner(obj *unstructured.Unstructured) error {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil || c.reconciling[owner.UID] {
+		return nil
+	}
+	// Nothing is recreated in a namespace being deleted
+	if namespace := obj.GetNamespace(); namespace != "" {
+		if _, err := c.get(namespaceGVR, "", namespace); apierrors.IsNotFound(err) {
+			return nil
+		}
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return nil
+	}
+	r := c.resourceForKind(gv.WithKind(owner.Kind))
+	err = c.reconcile(gv.WithResource(r.resource), obj.GetNamespace(), owner.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isOwnedBy(obj *unstructured.Unstructured, uid types.UID) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcile runs the simulated controller of the object's kind, if there is one.
+func (c *fakeCluster) reconcile(gvr schema.GroupVersionResource, namespace, name string) error {
+	obj, err := c.get(gvr, namespace, name)
+	if err != nil {
+		return err
+	}
+	if obj.GetDeletionTimestamp() != nil || c.reconciling[obj.GetUID()] {
+		return nil
+	}
+	c.reconciling[obj.GetUID()] = true
+	defer delete(c.reconciling, obj.GetUID())
+	previous := obj.DeepCopy()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Pod"}:
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" {
+			return nil
+		}
+		setReadyPodStatus(obj, now)
+	case schema.GroupKind{Kind: "Namespace"}:
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" {
+			return nil
+		}
+		_ = unstructured.SetNestedField(obj.Object, "Active", "status", "phase")
+	case schema.GroupKind{Kind: "PersistentVolumeClaim"}:
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" {
+			return nil
+		}
+		_ = unstructured.SetNestedField(obj.Object, "Bound", "status", "phase")
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		replicas, err := c.reconcileReplicaSets(obj)
+		if err != nil {
+			return err
+		}
+		setWorkloadStatus(obj, replicas, now)
+	case schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+		schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		replicas, err := c.reconcilePods(obj)
+		if err != nil {
+			return err
+		}
+		setWorkloadStatus(obj, replicas, now)
+	case schema.GroupKind{Group: "batch", Kind: "Job"}:
+		if _, ok, _ := unstructured.NestedMap(obj.Object, "status"); ok {
+			return nil
+		}
+		completions, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "completions")
+		if !ok {
+			completions = 1
+		}
+		_ = unstructured.SetNestedMap(obj.Object, map[string]any{
+			"startTime":      now,
+			"completionTime": now,
+			"succeeded":      completions,
+			"conditions": []any{
+				condition("SuccessCriteriaMet", "CompletionsReached", now),
+				condition("Complete", "CompletionsReached", now),
+			},
+		}, "status")
+	case schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:
+		names, _, _ := unstructured.NestedMap(obj.Object, "spec", "names")
+		_ = unstructured.SetNestedMap(obj.Object, map[string]any{
+			"acceptedNames": names,
+			"conditions": []any{
+				condition("NamesAccepted", "NoConflicts", now),
+				condition("Established", "InitialNamesAccepted", now),
+			},
+		}, "status")
+	default:
+		return nil
+	}
+
+	if reflect.DeepEqual(previous.Object, obj.Object) {
+		return nil
+	}
+	obj.SetResourceVersion(c.nextResourceVersion())
+	return c.tracker.Update(gvr, obj, namespace)
+}
+
+// reconcilePods creates and deletes the pods of a workload to match its replicas and
+// returns the number of replicas.
+func (c *fakeCluster) reconcilePods(workload *unstructured.Unstructured) (int64, error) {
+	replicas, ok, _ := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+	if !ok {
+		replicas = 1
+	}
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	pods, err := c.listObjects(podGVR, podGVK, workload.GetNamespace())
+	if err != nil {
+		return 0, err
+	}
+	var owned []*unstructured.Unstructured
+	for _, pod := range pods {
+		// Pods being deleted are replaced right away
+		if isOwnedBy(pod, workload.GetUID()) && pod.GetDeletionTimestamp() == nil {
+			owned = append(owned, pod)
+		}
+	}
+
+	// Remove the newest pods first, as controllers scale down
+	for i := len(owned) - 1; i >= int(replicas); i-- {
+		if err := c.delete(podGVR, owned[i].GetNamespace(), owned[i].GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+	}
+
+	template, _, _ := unstructured.NestedMap(workload.Object, "spec", "template")
+	podLabels, _, _ := unstructured.NestedStringMap(template, "metadata", "labels")
+	podSpec, _, _ := unstructured.NestedMap(template, "spec")
+	for i := len(owned); i < int(replicas); i++ {
+		pod := &unstructured.Unstructured{Object: map[string]any{"spec": podSpec}}
+		pod.SetGroupVersionKind(podGVK)
+		pod.SetNamespace(workload.GetNamespace())
+		if workload.GetKind() == "StatefulSet" {
+			pod.SetName(fmt.Sprintf("%s-%d", workload.GetName(), i))
+		} else {
+			pod.SetGenerateName(workload.GetName() + "-")
+		}
+		pod.SetLabels(podLabels)
+		controller := true
+		pod.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion:         workload.GetAPIVersion(),
+			Kind:               workload.GetKind(),
+			Name:               workload.GetName(),
+			UID:                workload.GetUID(),
+			Controller:         &controller,
+			BlockOwnerDeletion: &controller,
+		}})
+		c.initMetadata(pod)
+		if err := c.tracker.Create(podGVR, pod, pod.GetNamespace()); err != nil {
+			return 0, err
+		}
+		if err := c.reconcile(podGVR, pod.GetNamespace(), pod.GetName()); err != nil {
+			return 0, err
+		}
+	}
+	return replicas, nil
+}
+
+// reconcileReplicaSets creates the ReplicaSet of the current pod template of a
+// Deployment, named after a hash of the template, and scales it to the replicas of the
+// Deployment and the ReplicaSets of earlier templates to zero, as a rollout ends. It
+// returns the number of replicas.
+func (c *fakeCluster) reconcileReplicaSets(deployment *unstructured.Unstructured) (int64, error) {
+	replicas, ok, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	if !ok {
+		replicas = 1
+	}
+	template, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template")
+	hash := templateHash(template)
+	name := deployment.GetName() + "-" + hash
+
+	replicaSetGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	sets, err := c.listObjects(replicaSetGVR, replicaSetGVK, deployment.GetNamespace())
+	if err != nil {
+		return 0, err
+	}
+	found := false
+	for _, set := range sets {
+		if !isOwnedBy(set, deployment.GetUID()) || set.GetDeletionTimestamp() != nil {
+			continue
+		}
+		want := int64(0)
+		if set.GetName() == name {
+			found, want = true, replicas
+		}
+		if current, _, _ := unstructured.NestedInt64(set.Object, "spec", "replicas"); current == want {
+			continue
+		}
+		previous := set.DeepCopy()
+		_ = unstructured.SetNestedField(set.Object, want, "spec", "replicas")
+		if err := c.store(replicaSetGVR, previous, set); err != nil {
+			return 0, err
+		}
+		if err := c.reconcile(replicaSetGVR, set.GetNamespace(), set.GetName()); err != nil {
+			return 0, err
+		}
+	}
+	if found {
+		return replicas, nil
+	}
+
+	// The pod-template-hash label keeps the pods of each ReplicaSet apart
+	podTemplate := runtime.DeepCopyJSONValue(template).(map[string]any)
+	podLabels, _, _ := unstructured.NestedStringMap(podTemplate, "metadata", "labels")
+	if podLabels == nil {
+		podLabels = map[string]string{}
+	}
+	podLabels["pod-template-hash"] = hash
+	_ = unstructured.SetNestedStringMap(podTemplate, podLabels, "metadata", "labels")
+	selector, _, _ := unstructured.NestedMap(deployment.Object, "spec", "selector")
+	selector = runtime.DeepCopyJSON(selector)
+	_ = unstructured.SetNestedField(selector, hash, "matchLabels", "pod-template-hash")
+
+	set := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"replicas": replicas, "selector": selector, "template": podTemplate},
+	}}
+	set.SetGroupVersionKind(replicaSetGVK)
+	set.SetName(name)
+	set.SetNamespace(deployment.GetNamespace())
+	set.SetLabels(podLabels)
+	controller := true
+	set.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         deployment.GetAPIVersion(),
+		Kind:               deployment.GetKind(),
+		Name:               deployment.GetName(),
+		UID:                deployment.GetUID(),
+		Controller:         &controller,
+		BlockOwnerDeletion: &controller,
+	}})
+	c.initMetadata(set)
+	if err := c.tracker.Create(replicaSetGVR, set, set.GetNamespace()); err != nil {
+		return 0, err
+	}
+	return replicas, c.reconcile(replicaSetGVR, set.GetNamespace(), set.GetName())
+}
+
+// templateHash returns a short hash of a pod template for the names of ReplicaSets,
+// like the pod-template-hash of the Deployment controller.
+func templateHash(template map[string]any) string {
+	data, _ := json.Marshal(template)
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	return rand.SafeEncodeString(strconv.FormatUint(uint64(hasher.Sum32()), 10))
+}
+
+// setReadyPodStatus marks a pod as running with all containers ready.
+func setReadyPodStatus(pod *unstructured.Unstructured, now string) {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	statuses := make([]any, 0, len(containers))
+	for _, c := range containers {
+		container, _ := c.(map[string]any)
+		statuses = append(statuses, map[string]any{
+			"name":         container["name"],
+			"image":        container["image"],
+			"ready":        true,
+			"started":      true,
+			"restartCount": int64(0),
+			"state":        map[string]any{"running": map[string]any{"startedAt": now}},
+		})
+	}
+	_ = unstructured.SetNestedMap(pod.Object, map[string]any{
+		"phase":     "Running",
+		"startTime": now,
+		"conditions": []any{
+			condition("PodScheduled", "", now),
+			condition("Initialized", "", now),
+			condition("ContainersReady", "", now),
+			condition("Ready", "", now),
+		},
+		"containerStatuses": statuses,
+	}, "status")
+}
+
+// setWorkloadStatus reports all replicas of a workload as ready and available.
+func setWorkloadStatus(workload *unstructured.Unstructured, replicas int64, now string) {
+	status := map[string]any{
+		"observedGeneration": workload.GetGeneration(),
+		"replicas":           replicas,
+		"readyReplicas":      replicas,
+		"availableReplicas":  replicas,
+	}
+	switch workload.GetKind() {
+	case "Deployment":
+		status["updatedReplicas"] = replicas
+		status["conditions"] = []any{
+			condition("Available", "MinimumReplicasAvailable", now),
+			condition("Progressing", "NewReplicaSetAvailable", now),
+		}
+	case "ReplicaSet":
+		status["fullyLabeledReplicas"] = replicas
+	case "StatefulSet":
+		status["currentReplicas"] = replicas
+		status["updatedReplicas"] = replicas
+	}
+	_ = unstructured.SetNestedMap(workload.Object, status, "status")
+}
+
+func condition(conditionType, reason, now string) map[string]any {
+	c := map[string]any{"type": conditionType, "status": "True", "lastTransitionTime": now}
+	if reason != "" {
+		c["reason"] = reason
+	}
+	return c
+}
+
+// normalize returns a copy of obj with whole numbers as int64, as the API server
+// decodes them, so controllers can read fields such as spec.replicas.
+func normalize(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	normalized := &unstructured.Unstructured{}
+	if err := normalized.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// matchesFieldSelector reports whether obj matches a field selector, reading each
+// field from the object, e.g. status.phase.
+func matchesFieldSelector(obj *unstructured.Unstructured, selector fields.Selector) bool {
+	set := fields.Set{}
+	for _, requirement := range selector.Requirements() {
+		value, _, _ := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(requirement.Field, ".")...)
+		if value != nil {
+			set[requirement.Field] = fmt.Sprint(value)
+		}
+	}
+	return selector.Matches(set)
+}
+
+// fakeClient serves the ResourceClient calls from a fakeCluster. Kubeconfig calls
+// read the kubeconfig file as usual; proxying and access checks are not supported.
+type fakeClient struct {
+	// dynamicClientAdapter sends resource calls to the fake dynamic client.
+	*dynamicClientAdapter
+	cluster *fakeCluster
+}
+
+func newFakeClient(cluster *fakeCluster, kubeconfigPath string) *fakeClient {
+	return &fakeClient{
+		dynamicClientAdapter: &dynamicClientAdapter{client: cluster.client, kubeconfigPath: kubeconfigPath},
+		cluster:              cluster,
+	}
+}
+
+// namespaceExists returns a NotFound error if namespace is set and does not exist, as
+// the API server does for namespaced calls.
+func (f *fakeClient) namespaceExists(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	_, err := f.cluster.get(namespaceGVR, "", namespace)
+	return err
+}
+
+func (f *fakeClient) Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	if err := f.namespaceExists(namespace); err != nil {
+		return nil, err
+	}
+	obj, err := normalize(obj)
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() == "" {
+		return nil, apierrors.NewInvalid(obj.GroupVersionKind().GroupKind(), "", field.ErrorList{
+			field.Required(field.NewPath("metadata", "name"), "name or generateName is required"),
+		})
+	}
+	obj.SetNamespace(namespace)
+	f.cluster.remember(fakeResource{gvk: obj.GroupVersionKind(), resource: gvr.Resource, namespaced: namespace != ""})
+	f.cluster.initMetadata(obj)
+
+	if _, err := f.dynamicClientAdapter.Create(ctx, gvr, obj, namespace); err != nil {
+		return nil, err
+	}
+	if err := f.cluster.reconcile(gvr, namespace, obj.GetName()); err != nil {
+		return nil, err
+	}
+	return f.cluster.get(gvr, namespace, obj.GetName())
+}
+
+func (f *fakeClient) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	current, err := f.cluster.get(gvr, namespace, obj.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if rv := obj.GetResourceVersion(); rv != "" && rv != current.GetResourceVersion() {
+		return nil, apierrors.NewConflict(gvr.GroupResource(), obj.GetName(), fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+
+	updated, err := normalize(obj)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.Join(subresources, "/") {
+	case "":
+	case scaleSubresource:
+		replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		updated = current.DeepCopy()
+		if err := unstructured.SetNestedField(updated.Object, replicas, "spec", "replicas"); err != nil {
+			return nil, err
+		}
+	case "status":
+		updated = current.DeepCopy()
+		updated.Object["status"] = obj.Object["status"]
+	case "finalize":
+		updated = current.DeepCopy()
+		finalizers, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "finalizers")
+		if err := unstructured.SetNestedStringSlice(updated.Object, finalizers, "spec", "finalizers"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), obj.GetName()+"/"+strings.Join(subresources, "/"))
+	}
+
+	if err := f.cluster.store(gvr, current, updated); err != nil {
+		return nil, err
+	}
+	return f.reconciled(gvr, namespace, obj.GetName(), subresources)
+}
+
+func (f *fakeClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	if len(subresources) > 0 {
+		return nil, apierrors.NewMethodNotSupported(gvr.GroupResource(), "patch "+strings.Join(subresources, "/"))
+	}
+	current, err := f.cluster.get(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := f.dynamicClientAdapter.Patch(ctx, gvr, name, namespace, patchType, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.cluster.store(gvr, current, patched); err != nil {
+		return nil, err
+	}
+	return f.reconciled(gvr, namespace, name, nil)
+}
+
+// reconciled runs the controller of a changed object and returns it, or its scale.
+func (f *fakeClient) reconciled(gvr schema.GroupVersionResource, namespace, name string, subresources []string) (*unstructured.Unstructured, error) {
+	if err := f.cluster.reconcile(gvr, namespace, name); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	obj, err := f.cluster.get(gvr, namespace, name)
+	if apierrors.IsNotFound(err) {
+		// Removing the last finalizer of an object being deleted removes it
+		return &unstructured.Unstructured{}, nil
+	}
+	if err != nil || strings.Join(subresources, "/") != scaleSubresource {
+		return obj, err
+	}
+	return scaleOf(obj), nil
+}
+
+func (f *fakeClient) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	obj, err := f.cluster.get(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.Join(subresources, "/") {
+	case "", "status":
+		return obj, nil
+	case scaleSubresource:
+		if _, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas"); !ok && obj.GetKind() != "Deployment" && obj.GetKind() != "ReplicaSet" && obj.GetKind() != "StatefulSet" {
+			return nil, apierrors.NewNotFound(gvr.GroupResource(), name+"/scale")
+		}
+		return scaleOf(obj), nil
+	default:
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name+"/"+strings.Join(subresources, "/"))
+	}
+}
+
+// scaleOf returns the Scale object of a workload, as its scale subresource serves it.
+func scaleOf(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	replicas, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !ok {
+		replicas = 1
+	}
+	statusReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+	matchLabels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+
+	scale := &unstructured.Unstructured{Object: map[string]any{
+		"spec":   map[string]any{"replicas": replicas},
+		"status": map[string]any{"replicas": statusReplicas, "selector": labels.SelectorFromSet(matchLabels).String()},
+	}}
+	scale.SetAPIVersion("autoscaling/v1")
+	scale.SetKind("Scale")
+	scale.SetName(obj.GetName())
+	scale.SetNamespace(obj.GetNamespace())
+	scale.SetUID(obj.GetUID())
+	scale.SetResourceVersion(obj.GetResourceVersion())
+	return scale
+}
+
+func (f *fakeClient) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err))
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid field selector: %v", err))
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(gvr.GroupVersion().String())
+	list.SetKind("List")
+	list.SetResourceVersion(strconv.FormatInt(f.cluster.resourceVersion, 10))
+
+	r, ok := f.cluster.resourceFor(gvr)
+	if !ok {
+		// Nothing of an unknown resource was ever created
+		return list, nil
+	}
+	list.SetKind(r.gvk.Kind + "List")
+	items, err := f.cluster.listObjects(gvr, r.gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if labelSelector.Matches(labels.Set(item.GetLabels())) && matchesFieldSelector(item, fieldSelector) {
+			list.Items = append(list.Items, *item)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	return f.cluster.delete(gvr, namespace, name, opts)
+}
+
+func (f *fakeClient) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	list, err := f.List(ctx, gvr, namespace, listOpts)
+	if err != nil {
+		return err
+	}
+
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	for _, item := range list.Items {
+		if err := f.cluster.delete(gvr, item.GetNamespace(), item.GetName(), opts); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	f.cluster.mu.Lock()
+	defer f.cluster.mu.Unlock()
+
+	var resources []metav1.APIResource
+	for _, r := range f.cluster.servedResources() {
+		if r.gvk.GroupVersion().String() != groupVersion || slices.ContainsFunc(resources, func(a metav1.APIResource) bool { return a.Name == r.resource }) {
+			continue
+		}
+		resources = append(resources, metav1.APIResource{
+			Name:       r.resource,
+			Kind:       r.gvk.Kind,
+			Namespaced: r.namespaced,
+			Verbs:      metav1.Verbs{"create", "delete", "deletecollection", "get", "list", "patch", "update"},
+		})
+	}
+	if len(resources) == 0 {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, groupVersion)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+	return resources, nil
+}
+
+func (f *fakeClient) Proxy(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.GroupResource{Resource: req.Resource}, "proxy with the fake backend")
+}
+
+func (f *fakeClient) CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
+	return false, "", apierrors.NewMethodNotSupported(schema.GroupResource{Group: "authorization.k8s.io", Resource: "subjectaccessreviews"}, "create with the fake backend")
+}
+
+func (f *fakeClient) WithKubeconfig(path string) ResourceClient {
+	return newFakeClient(f.cluster, path)
+}
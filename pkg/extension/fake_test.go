@@ -0,0 +1,376 @@
+package extension
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const fakeFixtures = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.27
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: settings
+      namespace: shop
+    data:
+      mode: fast
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: broken
+      namespace: shop
+    spec:
+      containers:
+        - name: app
+          image: app:latest
+    status:
+      phase: Running
+      containerStatuses:
+        - name: app
+          ready: false
+          restartCount: 5
+          state:
+            waiting:
+              reason: CrashLoopBackOff
+`
+
+// newFakeExtension returns an extension on the fake backend, seeded with fixtures.
+func newFakeExtension(t *testing.T, fixtures string) *Extension {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fixtures.yaml"), []byte(fixtures), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ext := New()
+	ext.SetLogOutput(&strings.Builder{})
+	config := map[string]any{
+		"kubeconfig": filepath.Join(dir, "missing-kubeconfig"),
+		"backend":    "fake",
+		"fixtures":   dir,
+	}
+	if err := ext.Initialize(config); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	return ext
+}
+
+// execute runs an operation and returns its result.
+func execute(t *testing.T, ext *Extension, operation string, args map[string]any) *sdk.OperationResult {
+	t.Helper()
+	req := &sdk.OperationRequest{Args: args}
+	req.Context.Workdir = t.TempDir()
+	result, err := ext.Execute(context.Background(), operation, req)
+	if err != nil {
+		t.Fatalf("Execute(%s) error = %v", operation, err)
+	}
+	return result
+}
+
+func TestFakeBackendConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr string
+	}{
+		{name: "invalid backend", config: map[string]any{"backend": "kind"}, wantErr: "invalid backend: must be cluster or fake"},
+		{name: "fixtures without fake backend", config: map[string]any{"fixtures": "testdata"}, wantErr: "fixtures requires backend: fake"},
+		{
+			name:    "replay on fake backend",
+			config:  map[string]any{"backend": "fake", "cassette": map[string]any{"mode": "replay", "path": "verify.yaml"}},
+			wantErr: "cassette replay cannot be combined with backend: fake",
+		},
+		{
+			name:    "missing fixtures",
+			config:  map[string]any{"backend": "fake", "fixtures": filepath.Join(t.TempDir(), "missing")},
+			wantErr: "failed to read fixtures",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Initialize(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Initialize() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFakeBackendFixtures(t *testing.T) {
+	ext := newFakeExtension(t, fakeFixtures)
+
+	result := execute(t, ext, "wait", map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web", "namespace": "shop"},
+		"condition":  "Available",
+		"timeout":    "5s",
+	})
+	if !result.Success {
+		t.Errorf("wait for the fixture Deployment failed: %s", result.Error)
+	}
+
+	result = execute(t, ext, "podHealth", map[string]any{
+		"metadata": map[string]any{"namespace": "shop"},
+		"owner":    map[string]any{"kind": "Deployment", "name": "web"},
+		"minPods":  float64(2),
+	})
+	if !result.Success {
+		t.Errorf("podHealth of the Deployment pods failed: %s", result.Error)
+	}
+
+	// Fixtures with a status are kept as written
+	result = execute(t, ext, "podHealth", map[string]any{
+		"metadata": map[string]any{"name": "broken", "namespace": "shop"},
+	})
+	if result.Success || !strings.Contains(result.Error, "CrashLoopBackOff") {
+		t.Errorf("podHealth of the broken pod = %v, %q, want a CrashLoopBackOff failure", result.Success, result.Error)
+	}
+
+	configMap, err := ext.client.Get(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "settings", "shop")
+	if err != nil {
+		t.Fatalf("Get() of the fixture ConfigMap error = %v", err)
+	}
+	if configMap.GetUID() == "" || configMap.GetResourceVersion() == "" {
+		t.Errorf("fixture ConfigMap has uid %q and resourceVersion %q, want both set", configMap.GetUID(), configMap.GetResourceVersion())
+	}
+}
+
+func TestFakeBackendLifecycle(t *testing.T) {
+	ext := newFakeExtension(t, "")
+	ctx := context.Background()
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	// Creating in a namespace that does not exist fails like on a cluster
+	deployment := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "api", "namespace": "demo"},
+		"spec": map[string]any{
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "api"}},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "api"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "api", "image": "api:1"}}},
+			},
+		},
+	}
+	if result := execute(t, ext, "create", deployment); result.Success {
+		t.Fatal("create in a missing namespace succeeded")
+	}
+
+	steps := []struct {
+		operation string
+		args      map[string]any
+	}{
+		{"create", map[string]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]any{"name": "demo"}}},
+		{"create", deployment},
+		{"wait", map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "api", "namespace": "demo"},
+			"condition":  "Available",
+			"timeout":    "5s",
+		}},
+		{"scale", map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "api", "namespace": "demo"},
+			"replicas":   float64(3),
+			"wait":       true,
+			"timeout":    "5s",
+			"expect":     map[string]any{"replicas": float64(3), "statusReplicas": float64(3)},
+		}},
+	}
+	for _, step := range steps {
+		if result := execute(t, ext, step.operation, step.args); !result.Success {
+			t.Fatalf("%s failed: %s", step.operation, result.Error)
+		}
+	}
+
+	pods, err := ext.client.List(ctx, podGVR, "demo", metav1.ListOptions{LabelSelector: "app=api", FieldSelector: "status.phase=Running"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(pods.Items) != 3 {
+		t.Errorf("Deployment has %d running pods after scaling, want 3", len(pods.Items))
+	}
+
+	// Deleting the namespace deletes its contents
+	if err := ext.client.Delete(ctx, namespaceGVR, "demo", "", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := ext.client.Get(ctx, deployments, "api", "demo"); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() of the Deployment after deleting its namespace error = %v, want NotFound", err)
+	}
+	if pods, err := ext.client.List(ctx, podGVR, "", metav1.ListOptions{}); err != nil || len(pods.Items) != 0 {
+		t.Errorf("List() of pods after deleting their namespace = %v, %v, want none", pods, err)
+	}
+}
+
+func TestFakeBackendFinalizers(t *testing.T) {
+	ext := newFakeExtension(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: demo\n")
+	ctx := context.Background()
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetGenerateName("held-")
+	configMap.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "mcpchecker"})
+	configMap.SetFinalizers([]string{"example.com/hold"})
+	created, err := ext.client.Create(ctx, configMaps, configMap, "demo")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	name := created.GetName()
+	if !strings.HasPrefix(name, "held-") || len(name) != len("held-")+5 {
+		t.Errorf("generated name = %q, want held- and a random suffix", name)
+	}
+
+	// Deleting with a stale precondition conflicts
+	stale := "0"
+	err = ext.client.Delete(ctx, configMaps, name, "demo", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &stale}})
+	if !apierrors.IsConflict(err) {
+		t.Errorf("Delete() with a stale resourceVersion error = %v, want Conflict", err)
+	}
+
+	if err := ext.client.Delete(ctx, configMaps, name, "demo", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	held, err := ext.client.Get(ctx, configMaps, name, "demo")
+	if err != nil {
+		t.Fatalf("Get() of the ConfigMap held by its finalizer error = %v", err)
+	}
+	if held.GetDeletionTimestamp() == nil {
+		t.Error("ConfigMap held by its finalizer has no deletionTimestamp")
+	}
+
+	result := execute(t, ext, "removeFinalizers", map[string]any{
+		"apiVersion":   "v1",
+		"kind":         "ConfigMap",
+		"metadata":     map[string]any{"name": name, "namespace": "demo"},
+		"allowUnowned": true,
+	})
+	if !result.Success {
+		t.Fatalf("removeFinalizers failed: %s", result.Error)
+	}
+	if _, err := ext.client.Get(ctx, configMaps, name, "demo"); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() after removing the finalizers error = %v, want NotFound", err)
+	}
+}
+
+func TestFakeBackendControllers(t *testing.T) {
+	ext := newFakeExtension(t, fakeFixtures)
+	ctx := context.Background()
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	// webPods returns the names of the running pods of the Deployment and checks that a
+	// ReplicaSet of the Deployment controls them
+	webPods := func() []string {
+		t.Helper()
+		pods, err := ext.client.List(ctx, podGVR, "shop", metav1.ListOptions{LabelSelector: "app=web"})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var names []string
+		for _, pod := range pods.Items {
+			owner := metav1.GetControllerOf(&pod)
+			if owner == nil || owner.Kind != "ReplicaSet" {
+				t.Fatalf("pod %s is controlled by %v, want a ReplicaSet", pod.GetName(), owner)
+			}
+			set, err := ext.client.Get(ctx, replicaSetGVR, owner.Name, "shop")
+			if err != nil {
+				t.Fatalf("Get() of ReplicaSet %s error = %v", owner.Name, err)
+			}
+			if setOwner := metav1.GetControllerOf(set); setOwner == nil || setOwner.Kind != "Deployment" || setOwner.Name != "web" {
+				t.Fatalf("ReplicaSet %s is controlled by %v, want Deployment web", set.GetName(), setOwner)
+			}
+			if phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase"); phase == "Running" {
+				names = append(names, pod.GetName())
+			}
+		}
+		return names
+	}
+
+	before := webPods()
+	if len(before) != 2 {
+		t.Fatalf("Deployment has running pods %v, want 2", before)
+	}
+
+	// Deleted pods are replaced
+	for _, name := range before {
+		if err := ext.client.Delete(ctx, podGVR, name, "shop", metav1.DeleteOptions{}); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+	}
+	after := webPods()
+	if len(after) != 2 || slices.Contains(after, before[0]) || slices.Contains(after, before[1]) {
+		t.Errorf("running pods after deleting %v = %v, want 2 new ones", before, after)
+	}
+
+	// A new pod template rolls out a new ReplicaSet and scales down the old one
+	patch := []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.28"}]}}}}`)
+	if _, err := ext.client.Patch(ctx, deployments, "web", "shop", types.MergePatchType, patch); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if pods := webPods(); len(pods) != 2 {
+		t.Errorf("running pods after the rollout = %v, want 2", pods)
+	}
+	sets, err := ext.client.List(ctx, replicaSetGVR, "shop", metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var replicas []int64
+	for _, set := range sets.Items {
+		count, _, _ := unstructured.NestedInt64(set.Object, "spec", "replicas")
+		replicas = append(replicas, count)
+	}
+	slices.Sort(replicas)
+	if !slices.Equal(replicas, []int64{0, 2}) {
+		t.Errorf("ReplicaSets have replicas %v, want the old one at 0 and the new one at 2", replicas)
+	}
+
+	// Deleting the Deployment deletes its ReplicaSets and their pods
+	if err := ext.client.Delete(ctx, deployments, "web", "shop", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if sets, err := ext.client.List(ctx, replicaSetGVR, "shop", metav1.ListOptions{}); err != nil || len(sets.Items) != 0 {
+		t.Errorf("List() of ReplicaSets after deleting the Deployment = %v, %v, want none", sets, err)
+	}
+	if pods, err := ext.client.List(ctx, podGVR, "shop", metav1.ListOptions{LabelSelector: "app=web"}); err != nil || len(pods.Items) != 0 {
+		t.Errorf("List() of pods after deleting the Deployment = %v, %v, want none", pods, err)
+	}
+}
@@ -0,0 +1,214 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// protectedNamespaces are namespaces whose objects finalizers are never removed from.
+var protectedNamespaces = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// protectedNamespacePrefixes are prefixes of namespaces managed by the platform.
+var protectedNamespacePrefixes = []string{"kube-", "openshift-"}
+
+// isProtectedNamespace reports whether name is a system namespace.
+func isProtectedNamespace(name string) bool {
+	if protectedNamespaces[name] {
+		return true
+	}
+	for _, prefix := range protectedNamespacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNamespaceRef reports whether ref refers to a Namespace.
+func isNamespaceRef(ref *resourceRef) bool {
+	return ref.apiVersion == "v1" && ref.kind == "Namespace"
+}
+
+// checkForceAllowed returns an error unless finalizers may be removed from the referenced
+// object. Objects in system namespaces are always refused. Other objects must be owned by
+// the extension, by label, by having been created by a task or by living in a namespace
+// it owns, unless allowUnowned is set.
+func (e *Extension) checkForceAllowed(ctx context.Context, ref *resourceRef, allowUnowned bool) error {
+	namespace := ref.namespace
+	if isNamespaceRef(ref) {
+		namespace = ref.name
+	}
+	if namespace != "" && isProtectedNamespace(namespace) {
+		return fmt.Errorf("refusing to remove finalizers in system namespace %s", namespace)
+	}
+	if allowUnowned {
+		return nil
+	}
+
+	owned, err := e.isOwned(ctx, ref, namespace)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return fmt.Errorf("refusing to remove finalizers from %s, which is not owned by %s; set allowUnowned to override", refName(ref), managedByValue)
+	}
+	return nil
+}
+
+// isOwned reports whether the referenced object, or the namespace it lives in, was
+// created by the extension.
+func (e *Extension) isOwned(ctx context.Context, ref *resourceRef, namespace string) (bool, error) {
+	if namespace != "" && e.activeNamespaces()[namespace] {
+		return true, nil
+	}
+
+	gvr, err := e.resourceFor(ref)
+	if err != nil {
+		return false, err
+	}
+	owned, err := e.isOwnedObject(ctx, gvr, ref.name, ref.namespace)
+	if err != nil || owned || namespace == "" || isNamespaceRef(ref) {
+		return owned, err
+	}
+	return e.isOwnedObject(ctx, namespaceGVR, namespace, "")
+}
+
+// isOwnedObject reports whether an object carries the managed-by label of the extension
+// or was created by a task that has not been cleaned up yet. A missing object is not owned.
+func (e *Extension) isOwnedObject(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (bool, error) {
+	obj, err := e.client.Get(ctx, gvr, name, namespace)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check ownership of %s: %w", name, err)
+	}
+	return obj.GetLabels()[labelManagedBy] == managedByValue || e.isCreated(obj.GetUID()), nil
+}
+
+// removeFinalizers strips the finalizers of the referenced object and returns what was
+// removed. For namespaces, the spec finalizers are cleared through the finalize subresource.
+func (e *Extension) removeFinalizers(ctx context.Context, gvr schema.GroupVersionResource, ref *resourceRef) ([]string, error) {
+	obj, err := e.client.Get(ctx, gvr, ref.name, ref.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", refName(ref), err)
+	}
+
+	var removed []string
+	if finalizers := obj.GetFinalizers(); len(finalizers) > 0 {
+		// A null value removes the whole list in a JSON merge patch
+		patch, _ := json.Marshal(map[string]any{"metadata": map[string]any{"finalizers": nil}})
+		obj, err = e.client.Patch(ctx, gvr, ref.name, ref.namespace, types.MergePatchType, patch)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return finalizers, nil
+			}
+			return nil, fmt.Errorf("failed to remove finalizers of %s: %w", refName(ref), err)
+		}
+		removed = append(removed, finalizers...)
+	}
+
+	if isNamespaceRef(ref) {
+		specFinalizers, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "finalizers")
+		if len(specFinalizers) > 0 {
+			if err := unstructured.SetNestedStringSlice(obj.Object, []string{}, "spec", "finalizers"); err != nil {
+				return nil, fmt.Errorf("failed to clear spec finalizers: %w", err)
+			}
+			if _, err := e.client.Update(ctx, namespaceGVR, obj, "", "finalize"); err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to finalize namespace %s: %w", ref.name, err)
+			}
+			for _, finalizer := range specFinalizers {
+				removed = append(removed, "spec:"+finalizer)
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// waitForDeletion waits until the referenced object is gone. It reports whether the
+// object was deleted within the timeout.
+func (e *Extension) waitForDeletion(ctx context.Context, gvr schema.GroupVersionResource, ref *resourceRef, timeout time.Duration) (bool, error) {
+	err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, timeout, true, func(ctx context.Context) (bool, error) {
+		_, err := e.client.Get(ctx, gvr, ref.name, ref.namespace)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil && isPermanentError(err) {
+			return false, err
+		}
+		return false, nil
+	})
+	if err == nil {
+		return true, nil
+	}
+	if ctx.Err() == nil && wait.Interrupted(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// handleRemoveFinalizers strips the finalizers of an object, such as a custom resource
+// left behind by a failed operator, so that its deletion can complete.
+func (e *Extension) handleRemoveFinalizers(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	ref, err := parseResourceRef(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	allowUnowned, _ := args["allowUnowned"].(bool)
+	if err := e.checkForceAllowed(ctx, ref, allowUnowned); err != nil {
+		return failure(err), nil
+	}
+
+	gvr, err := e.resourceFor(ref)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	removed, err := e.removeFinalizers(ctx, gvr, ref)
+	if err != nil {
+		e.LogError(ctx, "Failed to remove finalizers", map[string]any{
+			"resource": refName(ref),
+			"error":    err.Error(),
+		})
+		return failure(err), nil
+	}
+
+	e.LogWarn(ctx, "Removed finalizers", map[string]any{
+		"resource":   refName(ref),
+		"finalizers": removed,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Removed %d finalizer(s) from %s", len(removed), refName(ref)),
+		map[string]string{
+			"removed": strings.Join(removed, ","),
+			"count":   fmt.Sprintf("%d", len(removed)),
+		},
+	), nil
+}
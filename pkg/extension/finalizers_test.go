@@ -0,0 +1,363 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeStuck serves objects that stay around after deletion until their finalizers,
+// and for namespaces their spec finalizers, are gone. It records finalizer removals.
+type fakeStuck struct {
+	objects map[string]*unstructured.Unstructured
+	deleted map[string]bool
+	changes []string
+}
+
+func newFakeStuck(objects ...*unstructured.Unstructured) *fakeStuck {
+	f := &fakeStuck{objects: map[string]*unstructured.Unstructured{}, deleted: map[string]bool{}}
+	for _, obj := range objects {
+		f.objects[obj.GetName()] = obj
+	}
+	return f
+}
+
+func (f *fakeStuck) get(gvr schema.GroupVersionResource, name string) (*unstructured.Unstructured, error) {
+	obj, ok := f.objects[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+	specFinalizers, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "finalizers")
+	if f.deleted[name] && len(obj.GetFinalizers()) == 0 && len(specFinalizers) == 0 {
+		delete(f.objects, name)
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeStuck) client() *mockClient {
+	return &mockClient{
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			return f.get(gvr, name)
+		},
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			if _, err := f.get(gvr, name); err != nil {
+				return err
+			}
+			f.deleted[name] = true
+			return nil
+		},
+		patchFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+			if _, err := f.get(gvr, name); err != nil {
+				return nil, err
+			}
+			if string(data) != `{"metadata":{"finalizers":null}}` {
+				return nil, fmt.Errorf("unexpected patch %s", data)
+			}
+			f.changes = append(f.changes, "patch "+name)
+			f.objects[name].SetFinalizers(nil)
+			return f.get(gvr, name)
+		},
+		updateFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+			if gvr != namespaceGVR || len(subresources) != 1 || subresources[0] != "finalize" {
+				return nil, fmt.Errorf("unexpected update of %s %v", gvr.Resource, subresources)
+			}
+			finalizers, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "finalizers")
+			f.changes = append(f.changes, "finalize "+obj.GetName())
+			if err := unstructured.SetNestedStringSlice(f.objects[obj.GetName()].Object, finalizers, "spec", "finalizers"); err != nil {
+				return nil, err
+			}
+			return f.get(gvr, obj.GetName())
+		},
+	}
+}
+
+func stuckObject(apiVersion, kind, name, namespace string, labels map[string]string, finalizers ...string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+	obj.SetFinalizers(finalizers)
+	return obj
+}
+
+func stuckNamespace(name string, labels map[string]string, specFinalizers ...string) *unstructured.Unstructured {
+	obj := stuckObject("v1", "Namespace", name, "", labels)
+	_ = unstructured.SetNestedStringSlice(obj.Object, specFinalizers, "spec", "finalizers")
+	return obj
+}
+
+var ownerLabels = map[string]string{labelManagedBy: managedByValue}
+
+// withUID sets the UID of obj and returns it.
+func withUID(obj *unstructured.Unstructured, uid types.UID) *unstructured.Unstructured {
+	obj.SetUID(uid)
+	return obj
+}
+
+func TestHandleRemoveFinalizers(t *testing.T) {
+	widgetArgs := func(namespace string, extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]any{"name": "stuck", "namespace": namespace},
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		objects      []*unstructured.Unstructured
+		wantSuccess  bool
+		wantChanges  []string
+		wantRemoved  string
+		wantError    string
+		wantCategory string
+	}{
+		{
+			name:        "object in task namespace",
+			args:        widgetArgs("task-ns", nil),
+			objects:     []*unstructured.Unstructured{stuckObject("example.com/v1", "Widget", "stuck", "task-ns", nil, "example.com/cleanup")},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "example.com/cleanup",
+		},
+		{
+			name:        "labelled object",
+			args:        widgetArgs("other", nil),
+			objects:     []*unstructured.Unstructured{stuckObject("example.com/v1", "Widget", "stuck", "other", ownerLabels, "a", "b")},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "a,b",
+		},
+		{
+			name: "object in labelled namespace",
+			args: widgetArgs("other", nil),
+			objects: []*unstructured.Unstructured{
+				stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a"),
+				stuckNamespace("other", ownerLabels),
+			},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "a",
+		},
+		{
+			name:        "object created by a task",
+			args:        widgetArgs("other", nil),
+			objects:     []*unstructured.Unstructured{withUID(stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a"), "created-widget")},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "a",
+		},
+		{
+			name: "object in namespace created by a task",
+			args: widgetArgs("other", nil),
+			objects: []*unstructured.Unstructured{
+				stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a"),
+				withUID(stuckNamespace("other", nil), "created-namespace"),
+			},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "a",
+		},
+		{
+			name:         "same name as an object created by a task",
+			args:         widgetArgs("other", nil),
+			objects:      []*unstructured.Unstructured{withUID(stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a"), "recreated-widget")},
+			wantSuccess:  false,
+			wantError:    "not owned by mcpchecker",
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "unowned object",
+			args:         widgetArgs("other", nil),
+			objects:      []*unstructured.Unstructured{stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a")},
+			wantSuccess:  false,
+			wantError:    "not owned by mcpchecker",
+			wantCategory: categoryTask,
+		},
+		{
+			name:        "unowned object with allowUnowned",
+			args:        widgetArgs("other", map[string]any{"allowUnowned": true}),
+			objects:     []*unstructured.Unstructured{stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a")},
+			wantSuccess: true,
+			wantChanges: []string{"patch stuck"},
+			wantRemoved: "a",
+		},
+		{
+			name:         "system namespace",
+			args:         widgetArgs("kube-system", map[string]any{"allowUnowned": true}),
+			objects:      []*unstructured.Unstructured{stuckObject("example.com/v1", "Widget", "stuck", "kube-system", ownerLabels, "a")},
+			wantSuccess:  false,
+			wantError:    "system namespace kube-system",
+			wantCategory: categoryTask,
+		},
+		{
+			name: "namespace finalized through subresource",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata":   map[string]any{"name": "stuck"},
+			},
+			objects:     []*unstructured.Unstructured{stuckNamespace("stuck", ownerLabels, "kubernetes")},
+			wantSuccess: true,
+			wantChanges: []string{"finalize stuck"},
+			wantRemoved: "spec:kubernetes",
+		},
+		{
+			name: "openshift namespace",
+			args: map[string]any{
+				"apiVersion":   "v1",
+				"kind":         "Namespace",
+				"metadata":     map[string]any{"name": "openshift-monitoring"},
+				"allowUnowned": true,
+			},
+			objects:      []*unstructured.Unstructured{stuckNamespace("openshift-monitoring", nil, "kubernetes")},
+			wantSuccess:  false,
+			wantError:    "system namespace openshift-monitoring",
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stuck := newFakeStuck(tt.objects...)
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    stuck.client(),
+				tasks: map[string]*taskState{"task": {
+					namespaces: []string{"task-ns"},
+					created:    []types.UID{"created-widget", "created-namespace"},
+				}},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleRemoveFinalizers(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleRemoveFinalizers() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleRemoveFinalizers() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if fmt.Sprint(stuck.changes) != fmt.Sprint(tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", stuck.changes, tt.wantChanges)
+			}
+			if tt.wantSuccess {
+				if got := result.Outputs["removed"]; got != tt.wantRemoved {
+					t.Errorf("removed output = %q, want %q", got, tt.wantRemoved)
+				}
+			}
+			if tt.wantError != "" && !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("error %q does not contain %q", result.Error, tt.wantError)
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleDeleteForce(t *testing.T) {
+	deleteArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]any{"name": "stuck", "namespace": "task-ns"},
+			"force":      true,
+			"forceAfter": "600ms",
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		object      *unstructured.Unstructured
+		wantSuccess bool
+		wantDeleted bool
+		wantChanges []string
+		wantOutputs map[string]string
+	}{
+		{
+			name:        "finalizers removed after timeout",
+			args:        deleteArgs(nil),
+			object:      stuckObject("example.com/v1", "Widget", "stuck", "task-ns", nil, "example.com/cleanup"),
+			wantSuccess: true,
+			wantDeleted: true,
+			wantChanges: []string{"patch stuck"},
+			wantOutputs: map[string]string{"forced": "true", "finalizersRemoved": "example.com/cleanup"},
+		},
+		{
+			name:        "deleted without finalizers",
+			args:        deleteArgs(nil),
+			object:      stuckObject("example.com/v1", "Widget", "stuck", "task-ns", nil),
+			wantSuccess: true,
+			wantDeleted: true,
+			wantOutputs: map[string]string{"forced": "false", "finalizersRemoved": ""},
+		},
+		{
+			name:        "unowned object is not deleted",
+			args:        deleteArgs(map[string]any{"metadata": map[string]any{"name": "stuck", "namespace": "other"}}),
+			object:      stuckObject("example.com/v1", "Widget", "stuck", "other", nil, "a"),
+			wantSuccess: false,
+		},
+		{
+			name:        "invalid forceAfter",
+			args:        deleteArgs(map[string]any{"forceAfter": "soon"}),
+			object:      stuckObject("example.com/v1", "Widget", "stuck", "task-ns", nil, "a"),
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stuck := newFakeStuck(tt.object)
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    stuck.client(),
+				tasks:     map[string]*taskState{"task": {namespaces: []string{"task-ns"}}},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleDelete(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleDelete() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleDelete() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if stuck.deleted["stuck"] != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", stuck.deleted["stuck"], tt.wantDeleted)
+			}
+			if fmt.Sprint(stuck.changes) != fmt.Sprint(tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", stuck.changes, tt.wantChanges)
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
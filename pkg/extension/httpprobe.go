@@ -0,0 +1,329 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// maxBodyOutput limits how much of the response body is returned in outputs and messages.
+const maxBodyOutput = 4096
+
+// probeExpectation is what an httpProbe response must satisfy.
+type probeExpectation struct {
+	// status is the expected status code, or 0 to accept any 2xx status.
+	status  int64
+	headers map[string]*regexp.Regexp
+	body    *regexp.Regexp
+}
+
+// handleHTTPProbe requests a path on a service or pod through the proxy subresource of
+// the API server, retrying until the response meets the expectations or the timeout expires.
+func (e *Extension) handleHTTPProbe(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	probe, err := parseProxyRequest(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	want, err := parseProbeExpectation(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	timeoutStr, _ := args["timeout"].(string)
+	if timeoutStr == "" {
+		timeoutStr = "20s"
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+	}
+
+	intervalStr, _ := args["interval"].(string)
+	if intervalStr == "" {
+		intervalStr = "1s"
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid interval format: %w", err)), nil
+	}
+	if interval <= 0 {
+		return failure(fmt.Errorf("interval must be positive")), nil
+	}
+
+	target := describeProxyTarget(probe)
+	e.LogInfo(ctx, "Probing HTTP endpoint", map[string]any{
+		"target":  target,
+		"method":  probe.Method,
+		"timeout": timeoutStr,
+	})
+
+	var lastResp *ProxyResponse
+	var lastErr error
+	var mismatches []string
+	attempts := 0
+	err = wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
+		attempts++
+		resp, err := e.client.Proxy(ctx, probe)
+		if err != nil {
+			// Connection errors are expected while the backend starts, so keep trying
+			lastErr = err
+			return false, nil
+		}
+		lastResp, lastErr = resp, nil
+		mismatches = want.check(resp)
+		return len(mismatches) == 0, nil
+	})
+
+	if err != nil {
+		if lastResp == nil {
+			e.LogError(ctx, "HTTP probe failed", map[string]any{
+				"target":   target,
+				"attempts": attempts,
+				"error":    fmt.Sprint(lastErr),
+			})
+			if lastErr == nil {
+				lastErr = err
+			}
+			result := failureWithCategory(categoryInfra,
+				fmt.Sprintf("No response from %s", target),
+				fmt.Errorf("no response from %s after %d attempt(s) within %s: %w", target, attempts, timeout, lastErr),
+			)
+			result.Outputs["attempts"] = fmt.Sprintf("%d", attempts)
+			return result, nil
+		}
+
+		details := append([]string{fmt.Sprintf("last response from %s did not meet expectations after %d attempt(s):", target, attempts)}, mismatches...)
+		if apiErr := apiServerError(lastResp); apiErr != "" {
+			details = append(details, "the API server answered instead of the backend: "+apiErr)
+		}
+		e.LogError(ctx, "HTTP probe did not meet expectations", map[string]any{
+			"target":     target,
+			"attempts":   attempts,
+			"httpStatus": lastResp.StatusCode,
+			"mismatches": strings.Join(mismatches, "; "),
+		})
+
+		result := failureWithCategory(categoryAgent,
+			fmt.Sprintf("Response from %s did not meet expectations", target),
+			errors.New(strings.Join(details, "\n")),
+		)
+		for key, value := range probeOutputs(lastResp, attempts) {
+			result.Outputs[key] = value
+		}
+		return result, nil
+	}
+
+	e.LogInfo(ctx, "HTTP probe succeeded", map[string]any{
+		"target":     target,
+		"attempts":   attempts,
+		"httpStatus": lastResp.StatusCode,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%s responded with status %d", target, lastResp.StatusCode),
+		probeOutputs(lastResp, attempts),
+	), nil
+}
+
+// parseProxyRequest reads the target and request arguments of httpProbe.
+func parseProxyRequest(args map[string]any) (ProxyRequest, error) {
+	var probe ProxyRequest
+
+	kind, _ := args["kind"].(string)
+	switch kind {
+	case "", "Service":
+		probe.Resource = "services"
+	case "Pod":
+		probe.Resource = "pods"
+	default:
+		return probe, fmt.Errorf("kind must be Service or Pod, got %q", kind)
+	}
+
+	if metadata, ok := args["metadata"].(map[string]any); ok {
+		probe.Name, _ = metadata["name"].(string)
+		probe.Namespace, _ = metadata["namespace"].(string)
+	}
+	if probe.Name == "" {
+		return probe, fmt.Errorf("metadata.name is required")
+	}
+	if probe.Namespace == "" {
+		probe.Namespace = "default"
+	}
+
+	if port, ok := args["port"]; ok {
+		if n, ok := toInt64(port); ok {
+			probe.Port = fmt.Sprintf("%d", n)
+		} else if name, ok := port.(string); ok {
+			probe.Port = name
+		} else {
+			return probe, fmt.Errorf("port must be a port number or name")
+		}
+	}
+
+	probe.Scheme, _ = args["scheme"].(string)
+	if probe.Scheme != "" && probe.Scheme != "http" && probe.Scheme != "https" {
+		return probe, fmt.Errorf("scheme must be http or https, got %q", probe.Scheme)
+	}
+
+	probe.Method, _ = args["method"].(string)
+	probe.Method = strings.ToUpper(probe.Method)
+	if probe.Method == "" {
+		probe.Method = http.MethodGet
+	}
+
+	probe.Path, _ = args["path"].(string)
+	if probe.Path == "" {
+		probe.Path = "/"
+	}
+
+	if headers, ok := args["headers"].(map[string]any); ok {
+		probe.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			str, ok := value.(string)
+			if !ok {
+				return probe, fmt.Errorf("headers.%s must be a string", name)
+			}
+			probe.Headers[name] = str
+		}
+	}
+
+	return probe, nil
+}
+
+// parseProbeExpectation reads the status, headers and body expectations of httpProbe.
+func parseProbeExpectation(args map[string]any) (*probeExpectation, error) {
+	expect, err := parseExpect(args)
+	if err != nil {
+		return nil, err
+	}
+
+	want := &probeExpectation{}
+	if status, ok := expect["status"]; ok {
+		n, ok := toInt64(status)
+		if !ok || n < 100 || n > 599 {
+			return nil, fmt.Errorf("expect.status must be an HTTP status code")
+		}
+		want.status = n
+	}
+
+	if headers, ok := expect["headers"].(map[string]any); ok {
+		want.headers = make(map[string]*regexp.Regexp, len(headers))
+		for name, value := range headers {
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("expect.headers.%s must be a string", name)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expect.headers.%s: %w", name, err)
+			}
+			want.headers[name] = re
+		}
+	}
+
+	if body, ok := expect["body"].(string); ok {
+		re, err := regexp.Compile(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect.body: %w", err)
+		}
+		want.body = re
+	}
+
+	return want, nil
+}
+
+// check returns the expectations resp does not meet.
+func (w *probeExpectation) check(resp *ProxyResponse) []string {
+	var result expectationResult
+	switch {
+	case w.status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		result.addf("expected a 2xx status but got %d", resp.StatusCode)
+	case w.status != 0 && int64(resp.StatusCode) != w.status:
+		result.addf("expected status %d but got %d", w.status, resp.StatusCode)
+	}
+
+	for _, name := range sortedKeys(w.headers) {
+		values := resp.Header.Values(name)
+		if len(values) == 0 {
+			result.addf("expected header %s matching %q but it is missing", name, w.headers[name])
+			continue
+		}
+		if value := strings.Join(values, ", "); !w.headers[name].MatchString(value) {
+			result.addf("expected header %s matching %q but got %q", name, w.headers[name], value)
+		}
+	}
+
+	if w.body != nil && !w.body.Match(resp.Body) {
+		result.addf("expected body matching %q but got %q", w.body, truncateBody(resp.Body))
+	}
+	return result.mismatches
+}
+
+// apiServerError returns the message of a Status the API server returned in place of
+// the backend response, such as when the service does not exist or has no endpoints.
+func apiServerError(resp *ProxyResponse) string {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return ""
+	}
+	var status metav1.Status
+	if err := json.Unmarshal(resp.Body, &status); err != nil || status.Kind != "Status" {
+		return ""
+	}
+	return status.Message
+}
+
+// describeProxyTarget renders the target of a proxy request for messages.
+func describeProxyTarget(probe ProxyRequest) string {
+	kind := "Service"
+	if probe.Resource == "pods" {
+		kind = "Pod"
+	}
+	target := fmt.Sprintf("%s %s/%s", kind, probe.Namespace, probe.Name)
+	if probe.Port != "" {
+		target += ":" + probe.Port
+	}
+	return target + probe.Path
+}
+
+// probeOutputs returns the outputs describing resp.
+func probeOutputs(resp *ProxyResponse, attempts int) map[string]string {
+	headers := make(map[string]string, len(resp.Header))
+	for name, values := range resp.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	headersJSON, _ := json.Marshal(headers)
+
+	return map[string]string{
+		"httpStatus": fmt.Sprintf("%d", resp.StatusCode),
+		"headers":    string(headersJSON),
+		"body":       truncateBody(resp.Body),
+		"attempts":   fmt.Sprintf("%d", attempts),
+	}
+}
+
+// truncateBody returns body as a string, cut to maxBodyOutput bytes.
+func truncateBody(body []byte) string {
+	if len(body) > maxBodyOutput {
+		return string(body[:maxBodyOutput]) + "...(truncated)"
+	}
+	return string(body)
+}
@@ -0,0 +1,213 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+func TestHandleHTTPProbe(t *testing.T) {
+	probeArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"metadata": map[string]any{"name": "web", "namespace": "default"},
+			"port":     float64(8080),
+			"path":     "/healthz",
+			"timeout":  "1s",
+			"interval": "100ms",
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+	respond := func(status int, contentType, body string) func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+		return func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+			return &ProxyResponse{
+				StatusCode: status,
+				Header:     http.Header{"Content-Type": []string{contentType}},
+				Body:       []byte(body),
+			}, nil
+		}
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		proxyFn      func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error)
+		wantSuccess  bool
+		wantErrors   []string
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name:        "healthy service",
+			args:        probeArgs(nil),
+			proxyFn:     respond(http.StatusOK, "text/plain", "ok"),
+			wantSuccess: true,
+			wantOutputs: map[string]string{"httpStatus": "200", "body": "ok", "attempts": "1", "headers": `{"Content-Type":"text/plain"}`},
+		},
+		{
+			name: "all expectations met",
+			args: probeArgs(map[string]any{"expect": map[string]any{
+				"status":  float64(200),
+				"headers": map[string]any{"Content-Type": "^text/"},
+				"body":    "^ok$",
+			}}),
+			proxyFn:     respond(http.StatusOK, "text/plain", "ok"),
+			wantSuccess: true,
+		},
+		{
+			name: "succeeds once the backend starts",
+			args: probeArgs(nil),
+			proxyFn: func() func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+				calls := 0
+				return func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+					calls++
+					if calls < 3 {
+						return nil, fmt.Errorf("connection refused")
+					}
+					return &ProxyResponse{StatusCode: http.StatusOK}, nil
+				}
+			}(),
+			wantSuccess: true,
+			wantOutputs: map[string]string{"attempts": "3"},
+		},
+		{
+			name:         "unexpected status",
+			args:         probeArgs(nil),
+			proxyFn:      respond(http.StatusInternalServerError, "text/plain", "boom"),
+			wantSuccess:  false,
+			wantErrors:   []string{"expected a 2xx status but got 500"},
+			wantOutputs:  map[string]string{"httpStatus": "500", "body": "boom"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "mismatched header and body",
+			args: probeArgs(map[string]any{"expect": map[string]any{
+				"headers": map[string]any{"Content-Type": "json", "X-Version": "v2"},
+				"body":    "ready",
+			}}),
+			proxyFn:     respond(http.StatusOK, "text/plain", "starting"),
+			wantSuccess: false,
+			wantErrors: []string{
+				`expected header Content-Type matching "json" but got "text/plain"`,
+				`expected header X-Version matching "v2" but it is missing`,
+				`expected body matching "ready" but got "starting"`,
+			},
+		},
+		{
+			name:        "service without endpoints",
+			args:        probeArgs(nil),
+			proxyFn:     respond(http.StatusServiceUnavailable, "application/json", `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"no endpoints available for service \"web\"","code":503}`),
+			wantSuccess: false,
+			wantErrors:  []string{`the API server answered instead of the backend: no endpoints available for service "web"`},
+		},
+		{
+			name: "no response",
+			args: probeArgs(nil),
+			proxyFn: func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+				return nil, fmt.Errorf("connection refused")
+			},
+			wantSuccess:  false,
+			wantErrors:   []string{"connection refused"},
+			wantCategory: categoryInfra,
+		},
+		{
+			name:         "invalid body regex",
+			args:         probeArgs(map[string]any{"expect": map[string]any{"body": "("}}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "unsupported kind",
+			args:         probeArgs(map[string]any{"kind": "Deployment"}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    &mockClient{proxyFn: tt.proxyFn},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleHTTPProbe(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleHTTPProbe() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleHTTPProbe() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestDynamicClientAdapterProxy(t *testing.T) {
+	var gotPath, gotQuery, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotQuery = r.URL.RawQuery
+		gotHeader = r.Header.Get("X-Probe")
+		w.Header().Set("X-Backend", "web")
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, "short and stout")
+	}))
+	defer server.Close()
+
+	adapter := &dynamicClientAdapter{httpClient: server.Client(), host: server.URL}
+	resp, err := adapter.Proxy(context.Background(), ProxyRequest{
+		Resource:  "services",
+		Namespace: "shop",
+		Name:      "web",
+		Scheme:    "https",
+		Port:      "metrics",
+		Path:      "/status?verbose=1",
+		Headers:   map[string]string{"X-Probe": "yes"},
+	})
+	if err != nil {
+		t.Fatalf("Proxy() returned error: %v", err)
+	}
+
+	if want := "/api/v1/namespaces/shop/services/https:web:metrics/proxy/status"; gotPath != want {
+		t.Errorf("request path = %q, want %q", gotPath, want)
+	}
+	if gotQuery != "verbose=1" {
+		t.Errorf("request query = %q, want %q", gotQuery, "verbose=1")
+	}
+	if gotHeader != "yes" {
+		t.Errorf("X-Probe header = %q, want %q", gotHeader, "yes")
+	}
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusTeapot)
+	}
+	if got := resp.Header.Get("X-Backend"); got != "web" {
+		t.Errorf("X-Backend header = %q, want %q", got, "web")
+	}
+	if string(resp.Body) != "short and stout" {
+		t.Errorf("body = %q, want %q", resp.Body, "short and stout")
+	}
+}
@@ -2,60 +2,111 @@ package extension
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 // ContextInfo represents information about a Kubernetes context
 type ContextInfo struct {
-	Name      string `json:"name"`
-	Cluster   string `json:"cluster"`
-	User      string `json:"user"`
-	Namespace string `json:"namespace,omitempty"`
-	IsCurrent bool   `json:"isCurrent"`
+	Name                    string `json:"name"`
+	Cluster                 string `json:"cluster"`
+	User                    string `json:"user"`
+	Namespace               string `json:"namespace,omitempty"`
+	IsCurrent               bool   `json:"isCurrent"`
+	Server                  string `json:"server,omitempty"`
+	HasCertificateAuthority bool   `json:"hasCertificateAuthority"`
 }
 
 // handleListContexts lists all contexts from the kubeconfig file.
-// Returns the current context name and total count.
+// Returns the current context name, total count, and all contexts as JSON.
 func (e *Extension) handleListContexts(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	expect, err := parseExpect(args)
+	if err != nil {
+		return failure(err), nil
 	}
 
 	e.LogInfo(ctx, "Listing kubeconfig contexts", nil)
 
-	contexts, err := e.client.ListContexts(ctx)
+	contexts, err := e.clientFor(req).ListContexts(ctx)
 	if err != nil {
 		e.LogError(ctx, "Failed to list contexts", map[string]any{
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to list contexts: %w", err)), nil
+		return failure(fmt.Errorf("failed to list contexts: %w", err)), nil
 	}
 
 	if len(contexts) == 0 {
-		return sdk.Failure(fmt.Errorf("no contexts found in kubeconfig")), nil
+		// The agent is often asked to create or select contexts, so their absence is its outcome
+		return failureWithCategory(categoryAgent, "", fmt.Errorf("no contexts found in kubeconfig")), nil
 	}
 
 	// Find current context
 	var currentContext string
+	names := make(map[string]bool, len(contexts))
 	for _, c := range contexts {
+		names[c.Name] = true
 		if c.IsCurrent {
 			currentContext = c.Name
-			break
 		}
 	}
 
+	contextsJSON, err := json.Marshal(contexts)
+	if err != nil {
+		return failure(fmt.Errorf("failed to marshal contexts: %w", err)), nil
+	}
+
 	e.LogInfo(ctx, "Contexts listed successfully", map[string]any{
 		"count":   len(contexts),
 		"current": currentContext,
 	})
 
+	// Handle expect verification
+	if expect != nil {
+		var result expectationResult
+		if err := result.checkString(expect, "current", currentContext); err != nil {
+			return failure(err), nil
+		}
+		if err := result.checkInt(expect, "count", int64(len(contexts))); err != nil {
+			return failure(err), nil
+		}
+		if contains, ok := expect["contains"]; ok {
+			wanted, ok := toStringSlice(contains)
+			if !ok {
+				return failure(fmt.Errorf("expect.contains must be a list of context names")), nil
+			}
+			for _, name := range wanted {
+				if !names[name] {
+					result.addf("expected context %q to exist", name)
+				}
+			}
+		}
+		if result.failed() {
+			return failureWithCategory(categoryAgent,
+				fmt.Sprintf("context list check failed: %s", result.String()),
+				fmt.Errorf("context expectation not met"),
+			), nil
+		}
+	}
+
 	return sdk.SuccessWithOutputs(
 		fmt.Sprintf("Found %d context(s), current: %s", len(contexts), currentContext),
 		map[string]string{
-			"current": currentContext,
-			"count":   fmt.Sprintf("%d", len(contexts)),
+			"current":  currentContext,
+			"count":    fmt.Sprintf("%d", len(contexts)),
+			"contexts": string(contextsJSON),
 		},
 	), nil
 }
@@ -64,27 +115,51 @@ func (e *Extension) handleListContexts(ctx context.Context, req *sdk.OperationRe
 // Fails if no current context is set.
 func (e *Extension) handleGetCurrentContext(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	expect, err := parseExpect(args)
+	if err != nil {
+		return failure(err), nil
 	}
 
 	e.LogInfo(ctx, "Getting current kubeconfig context", nil)
 
-	currentContext, err := e.client.GetCurrentContext(ctx)
+	currentContext, err := e.clientFor(req).GetCurrentContext(ctx)
 	if err != nil {
 		e.LogError(ctx, "Failed to get current context", map[string]any{
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to get current context: %w", err)), nil
+		return failure(fmt.Errorf("failed to get current context: %w", err)), nil
 	}
 
 	if currentContext == "" {
-		return sdk.Failure(fmt.Errorf("no current context set in kubeconfig")), nil
+		return failureWithCategory(categoryAgent, "", fmt.Errorf("no current context set in kubeconfig")), nil
 	}
 
 	e.LogInfo(ctx, "Current context retrieved", map[string]any{
 		"context": currentContext,
 	})
 
+	// Handle expect.context verification
+	if expect != nil {
+		var result expectationResult
+		if err := result.checkString(expect, "context", currentContext); err != nil {
+			return failure(err), nil
+		}
+		if result.failed() {
+			return failureWithCategory(categoryAgent,
+				fmt.Sprintf("current context check failed: %s", result.String()),
+				fmt.Errorf("context expectation not met"),
+			), nil
+		}
+	}
+
 	return sdk.SuccessWithOutputs(
 		fmt.Sprintf("Current context: %s", currentContext),
 		map[string]string{
@@ -93,11 +168,113 @@ func (e *Extension) handleGetCurrentContext(ctx context.Context, req *sdk.Operat
 	), nil
 }
 
-// handleViewConfig returns the kubeconfig as YAML.
+// handleGetContext returns a single context from the kubeconfig, defaulting to the current one.
+// Supports expectations on the context's cluster, user, namespace, server and CA presence.
+func (e *Extension) handleGetContext(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	name, _ := args["name"].(string)
+
+	expect, err := parseExpect(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	e.LogInfo(ctx, "Getting kubeconfig context", map[string]any{
+		"name": name,
+	})
+
+	contexts, err := e.clientFor(req).ListContexts(ctx)
+	if err != nil {
+		e.LogError(ctx, "Failed to list contexts", map[string]any{
+			"error": err.Error(),
+		})
+		return failure(fmt.Errorf("failed to list contexts: %w", err)), nil
+	}
+
+	var found *ContextInfo
+	for i := range contexts {
+		if (name == "" && contexts[i].IsCurrent) || (name != "" && contexts[i].Name == name) {
+			found = &contexts[i]
+			break
+		}
+	}
+	if found == nil {
+		if name == "" {
+			return failureWithCategory(categoryAgent, "", fmt.Errorf("no current context set in kubeconfig")), nil
+		}
+		return failureWithCategory(categoryAgent,
+			fmt.Sprintf("context %q not found in kubeconfig", name),
+			fmt.Errorf("context not found"),
+		), nil
+	}
+
+	contextJSON, err := json.Marshal(found)
+	if err != nil {
+		return failure(fmt.Errorf("failed to marshal context: %w", err)), nil
+	}
+
+	e.LogInfo(ctx, "Context retrieved", map[string]any{
+		"name":    found.Name,
+		"cluster": found.Cluster,
+		"user":    found.User,
+	})
+
+	// Handle expect verification
+	if expect != nil {
+		var result expectationResult
+		for _, field := range []struct{ key, actual string }{
+			{"cluster", found.Cluster},
+			{"user", found.User},
+			{"namespace", found.Namespace},
+			{"server", found.Server},
+		} {
+			if err := result.checkString(expect, field.key, field.actual); err != nil {
+				return failure(err), nil
+			}
+		}
+		if err := result.checkBool(expect, "hasCertificateAuthority", found.HasCertificateAuthority); err != nil {
+			return failure(err), nil
+		}
+		if err := result.checkBool(expect, "isCurrent", found.IsCurrent); err != nil {
+			return failure(err), nil
+		}
+		if result.failed() {
+			return failureWithCategory(categoryAgent,
+				fmt.Sprintf("context %s check failed: %s", found.Name, result.String()),
+				fmt.Errorf("context expectation not met"),
+			), nil
+		}
+	}
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Context %s: cluster %s, user %s", found.Name, found.Cluster, found.User),
+		map[string]string{
+			"name":                    found.Name,
+			"cluster":                 found.Cluster,
+			"user":                    found.User,
+			"namespace":               found.Namespace,
+			"server":                  found.Server,
+			"hasCertificateAuthority": fmt.Sprintf("%t", found.HasCertificateAuthority),
+			"isCurrent":               fmt.Sprintf("%t", found.IsCurrent),
+			"context":                 string(contextJSON),
+		},
+	), nil
+}
+
+// handleViewConfig returns the kubeconfig as YAML with credentials redacted.
 // When minify is true, returns only the current context and its dependencies.
+// Raw disables redaction and flatten inlines referenced certificate and key files.
 func (e *Extension) handleViewConfig(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
 	}
 
 	// Parse args
@@ -106,21 +283,29 @@ func (e *Extension) handleViewConfig(ctx context.Context, req *sdk.OperationRequ
 		args = make(map[string]any)
 	}
 
-	minify := false
+	opts := ViewConfigOptions{}
 	if m, ok := args["minify"].(bool); ok {
-		minify = m
+		opts.Minify = m
+	}
+	if f, ok := args["flatten"].(bool); ok {
+		opts.Flatten = f
+	}
+	if r, ok := args["raw"].(bool); ok {
+		opts.Raw = r
 	}
 
 	e.LogInfo(ctx, "Viewing kubeconfig", map[string]any{
-		"minify": minify,
+		"minify":  opts.Minify,
+		"flatten": opts.Flatten,
+		"raw":     opts.Raw,
 	})
 
-	configYAML, err := e.client.ViewConfig(ctx, minify)
+	configYAML, err := e.clientFor(req).ViewConfig(ctx, opts)
 	if err != nil {
 		e.LogError(ctx, "Failed to view config", map[string]any{
 			"error": err.Error(),
 		})
-		return sdk.Failure(fmt.Errorf("failed to view config: %w", err)), nil
+		return failure(fmt.Errorf("failed to view config: %w", err)), nil
 	}
 
 	e.LogInfo(ctx, "Kubeconfig retrieved successfully", nil)
@@ -132,3 +317,78 @@ func (e *Extension) handleViewConfig(ctx context.Context, req *sdk.OperationRequ
 		},
 	), nil
 }
+
+// handleIsolateKubeconfig copies the kubeconfig to a temporary file owned by the task.
+// Subsequent kubeconfig operations of the task read from the copy, so tasks that
+// switch or edit contexts can run in parallel. The copy is removed by the cleanup operation.
+func (e *Extension) handleIsolateKubeconfig(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	contextName, _ := args["context"].(string)
+	minify, _ := args["minify"].(bool)
+
+	sourcePath := e.client.KubeconfigPath()
+
+	e.LogInfo(ctx, "Isolating kubeconfig", map[string]any{
+		"source":  sourcePath,
+		"context": contextName,
+		"minify":  minify,
+	})
+
+	config, err := clientcmd.LoadFromFile(sourcePath)
+	if err != nil {
+		return failure(fmt.Errorf("failed to load kubeconfig: %w", err)), nil
+	}
+
+	// Relative certificate and key paths would break once the file is moved
+	if err := clientcmd.ResolveLocalPaths(config); err != nil {
+		return failure(fmt.Errorf("failed to resolve kubeconfig paths: %w", err)), nil
+	}
+
+	if contextName != "" || minify {
+		if contextName == "" {
+			contextName = config.CurrentContext
+		}
+		config, err = minifyConfig(config, contextName)
+		if err != nil {
+			return failure(err), nil
+		}
+	}
+
+	file, err := os.CreateTemp("", "kubeconfig-*.yaml")
+	if err != nil {
+		return failure(fmt.Errorf("failed to create kubeconfig copy: %w", err)), nil
+	}
+	path := file.Name()
+	file.Close()
+
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		os.Remove(path)
+		return failure(fmt.Errorf("failed to write kubeconfig copy: %w", err)), nil
+	}
+
+	// Replace a copy left over from an earlier isolateKubeconfig call in the same task
+	if previous := e.swapKubeconfigPath(req, path); previous != "" {
+		os.Remove(previous)
+	}
+
+	e.LogInfo(ctx, "Kubeconfig isolated", map[string]any{
+		"path":    path,
+		"context": config.CurrentContext,
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Isolated kubeconfig at %s", path),
+		map[string]string{
+			"kubeconfig": path,
+			"context":    config.CurrentContext,
+		},
+	), nil
+}
@@ -2,15 +2,29 @@ package extension
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/mcpchecker/mcpchecker/pkg/extension/protocol"
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
 )
 
 func TestHandleListContexts(t *testing.T) {
+	twoContexts := &mockClient{
+		listContextsFn: func(ctx context.Context) ([]ContextInfo, error) {
+			return []ContextInfo{
+				{Name: "dev", Cluster: "dev-cluster", User: "dev-user", IsCurrent: false},
+				{Name: "prod", Cluster: "prod-cluster", User: "prod-user", IsCurrent: true},
+			}, nil
+		},
+	}
+
 	tests := []struct {
 		name        string
+		args        map[string]any
 		client      *mockClient
 		wantSuccess bool
 		wantOutputs bool
@@ -58,6 +72,43 @@ func TestHandleListContexts(t *testing.T) {
 			},
 			wantSuccess: false,
 		},
+		{
+			name: "expectations met",
+			args: map[string]any{
+				"expect": map[string]any{
+					"current":  "prod",
+					"count":    float64(2),
+					"contains": []any{"dev"},
+				},
+			},
+			client:      twoContexts,
+			wantSuccess: true,
+			wantOutputs: true,
+		},
+		{
+			name: "expected context missing",
+			args: map[string]any{
+				"expect": map[string]any{"contains": []any{"staging"}},
+			},
+			client:      twoContexts,
+			wantSuccess: false,
+		},
+		{
+			name: "expected current context differs",
+			args: map[string]any{
+				"expect": map[string]any{"current": "dev"},
+			},
+			client:      twoContexts,
+			wantSuccess: false,
+		},
+		{
+			name: "invalid expect.count type",
+			args: map[string]any{
+				"expect": map[string]any{"count": "two"},
+			},
+			client:      twoContexts,
+			wantSuccess: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -67,7 +118,7 @@ func TestHandleListContexts(t *testing.T) {
 				client:    tt.client,
 			}
 
-			req := &sdk.OperationRequest{Args: map[string]any{}}
+			req := &sdk.OperationRequest{Args: tt.args}
 			result, err := ext.handleListContexts(context.Background(), req)
 
 			if err != nil {
@@ -79,6 +130,12 @@ func TestHandleListContexts(t *testing.T) {
 			if tt.wantOutputs && result.Outputs == nil {
 				t.Errorf("handleListContexts() outputs = nil, want outputs")
 			}
+			if tt.wantOutputs {
+				var contexts []ContextInfo
+				if err := json.Unmarshal([]byte(result.Outputs["contexts"]), &contexts); err != nil {
+					t.Errorf("handleListContexts() contexts output is not valid JSON: %v", err)
+				}
+			}
 		})
 	}
 }
@@ -86,6 +143,7 @@ func TestHandleListContexts(t *testing.T) {
 func TestHandleGetCurrentContext(t *testing.T) {
 	tests := []struct {
 		name        string
+		args        map[string]any
 		client      *mockClient
 		wantSuccess bool
 		wantContext string
@@ -118,6 +176,19 @@ func TestHandleGetCurrentContext(t *testing.T) {
 			},
 			wantSuccess: false,
 		},
+		{
+			name:        "expected context matches",
+			args:        map[string]any{"expect": map[string]any{"context": "default"}},
+			client:      &mockClient{},
+			wantSuccess: true,
+			wantContext: "default",
+		},
+		{
+			name:        "expected context differs",
+			args:        map[string]any{"expect": map[string]any{"context": "staging"}},
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,7 +198,7 @@ func TestHandleGetCurrentContext(t *testing.T) {
 				client:    tt.client,
 			}
 
-			req := &sdk.OperationRequest{Args: map[string]any{}}
+			req := &sdk.OperationRequest{Args: tt.args}
 			result, err := ext.handleGetCurrentContext(context.Background(), req)
 
 			if err != nil {
@@ -145,6 +216,87 @@ func TestHandleGetCurrentContext(t *testing.T) {
 	}
 }
 
+func TestHandleGetContext(t *testing.T) {
+	source := writeTestKubeconfig(t)
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		wantSuccess  bool
+		wantCluster  string
+		wantCategory string
+	}{
+		{
+			name:        "current context by default",
+			args:        map[string]any{},
+			wantSuccess: true,
+			wantCluster: "prod-cluster",
+		},
+		{
+			name: "named context with expectations met",
+			args: map[string]any{
+				"name": "dev",
+				"expect": map[string]any{
+					"cluster":                 "dev-cluster",
+					"user":                    "dev-user",
+					"namespace":               "dev-ns",
+					"server":                  "https://dev.example.com",
+					"hasCertificateAuthority": false,
+					"isCurrent":               false,
+				},
+			},
+			wantSuccess: true,
+			wantCluster: "dev-cluster",
+		},
+		{
+			name: "namespace expectation not met",
+			args: map[string]any{
+				"name":   "dev",
+				"expect": map[string]any{"namespace": "staging"},
+			},
+			wantSuccess: false,
+		},
+		{
+			name:         "unknown context",
+			args:         map[string]any{"name": "staging"},
+			wantSuccess:  false,
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "invalid expectation type",
+			args: map[string]any{
+				"expect": map[string]any{"isCurrent": "yes"},
+			},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    &dynamicClientAdapter{kubeconfigPath: source},
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleGetContext(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleGetContext() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleGetContext() success = %v, want %v (message: %s)", result.Success, tt.wantSuccess, result.Message)
+			}
+			if tt.wantCategory != "" && result.Outputs["category"] != tt.wantCategory {
+				t.Errorf("handleGetContext() category = %q, want %q", result.Outputs["category"], tt.wantCategory)
+			}
+			if tt.wantSuccess && result.Outputs["cluster"] != tt.wantCluster {
+				t.Errorf("handleGetContext() cluster = %q, want %q", result.Outputs["cluster"], tt.wantCluster)
+			}
+		})
+	}
+}
+
 func TestHandleViewConfig(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -158,8 +310,8 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": false,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
-					if minify {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
+					if opts.Minify {
 						t.Error("expected minify=false")
 					}
 					return "apiVersion: v1\nkind: Config\nclusters:\n- cluster:\n    server: https://example.com\n", nil
@@ -173,8 +325,8 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": true,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
-					if !minify {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
+					if !opts.Minify {
 						t.Error("expected minify=true")
 					}
 					return "apiVersion: v1\nkind: Config\ncurrent-context: prod\n", nil
@@ -186,9 +338,25 @@ func TestHandleViewConfig(t *testing.T) {
 			name: "default minify to false",
 			args: map[string]any{},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
-					if minify {
-						t.Error("expected minify=false by default")
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
+					if opts.Minify || opts.Raw {
+						t.Errorf("expected minify=false and raw=false by default, got %+v", opts)
+					}
+					return "apiVersion: v1\nkind: Config\n", nil
+				},
+			},
+			wantSuccess: true,
+		},
+		{
+			name: "raw and flatten passed through",
+			args: map[string]any{
+				"raw":     true,
+				"flatten": true,
+			},
+			client: &mockClient{
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
+					if !opts.Raw || !opts.Flatten {
+						t.Errorf("expected raw and flatten, got %+v", opts)
 					}
 					return "apiVersion: v1\nkind: Config\n", nil
 				},
@@ -199,7 +367,7 @@ func TestHandleViewConfig(t *testing.T) {
 			name: "client error",
 			args: map[string]any{},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
 					return "", errors.New("failed to read kubeconfig")
 				},
 			},
@@ -211,7 +379,7 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": true,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
 					return "", errors.New("cluster \"missing-cluster\" not found in kubeconfig")
 				},
 			},
@@ -223,7 +391,7 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": true,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
 					return "", errors.New("user \"missing-user\" not found in kubeconfig")
 				},
 			},
@@ -235,7 +403,7 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": true,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
 					return "", errors.New("current context \"prod\" has no cluster")
 				},
 			},
@@ -247,7 +415,7 @@ func TestHandleViewConfig(t *testing.T) {
 				"minify": true,
 			},
 			client: &mockClient{
-				viewConfigFn: func(ctx context.Context, minify bool) (string, error) {
+				viewConfigFn: func(ctx context.Context, opts ViewConfigOptions) (string, error) {
 					// AuthInfo is optional, so empty authInfo should succeed
 					return "apiVersion: v1\nkind: Config\ncurrent-context: prod\n", nil
 				},
@@ -275,3 +443,139 @@ func TestHandleViewConfig(t *testing.T) {
 		})
 	}
 }
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+    namespace: dev-ns
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+users:
+- name: dev-user
+  user:
+    token: dev-token
+- name: prod-user
+  user:
+    token: prod-token
+`
+
+func writeTestKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestHandleIsolateKubeconfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         any
+		wantSuccess  bool
+		wantContext  string
+		wantContexts int
+	}{
+		{
+			name:         "full copy",
+			args:         map[string]any{},
+			wantSuccess:  true,
+			wantContext:  "prod",
+			wantContexts: 2,
+		},
+		{
+			name:         "minified to named context",
+			args:         map[string]any{"context": "dev"},
+			wantSuccess:  true,
+			wantContext:  "dev",
+			wantContexts: 1,
+		},
+		{
+			name:         "minified to current context",
+			args:         map[string]any{"minify": true},
+			wantSuccess:  true,
+			wantContext:  "prod",
+			wantContexts: 1,
+		},
+		{
+			name:        "unknown context",
+			args:        map[string]any{"context": "missing"},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := writeTestKubeconfig(t)
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    &dynamicClientAdapter{kubeconfigPath: source},
+			}
+			defer ext.Close()
+
+			req := &sdk.OperationRequest{Args: tt.args, Context: protocol.ExecuteContext{Workdir: "/tasks/a"}}
+			result, err := ext.handleIsolateKubeconfig(context.Background(), req)
+			if err != nil {
+				t.Fatalf("handleIsolateKubeconfig() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleIsolateKubeconfig() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+
+			copyPath := result.Outputs["kubeconfig"]
+			if copyPath == "" || copyPath == source {
+				t.Fatalf("handleIsolateKubeconfig() kubeconfig = %q, want a new file", copyPath)
+			}
+
+			// Kubeconfig operations of the same task read the copy
+			contexts, err := ext.clientFor(req).ListContexts(context.Background())
+			if err != nil {
+				t.Fatalf("ListContexts() returned error: %v", err)
+			}
+			if len(contexts) != tt.wantContexts {
+				t.Errorf("ListContexts() returned %d contexts, want %d", len(contexts), tt.wantContexts)
+			}
+			current, err := ext.clientFor(req).GetCurrentContext(context.Background())
+			if err != nil {
+				t.Fatalf("GetCurrentContext() returned error: %v", err)
+			}
+			if current != tt.wantContext {
+				t.Errorf("GetCurrentContext() = %q, want %q", current, tt.wantContext)
+			}
+
+			// Other tasks keep using the shared kubeconfig
+			other := &sdk.OperationRequest{Context: protocol.ExecuteContext{Workdir: "/tasks/b"}}
+			if got := ext.clientFor(other).KubeconfigPath(); got != source {
+				t.Errorf("clientFor(other task).KubeconfigPath() = %q, want %q", got, source)
+			}
+
+			cleanup, err := ext.handleCleanup(context.Background(), req)
+			if err != nil || !cleanup.Success {
+				t.Fatalf("handleCleanup() = %+v, %v", cleanup, err)
+			}
+			if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+				t.Errorf("isolated kubeconfig %s still exists after cleanup", copyPath)
+			}
+			if got := ext.clientFor(req).KubeconfigPath(); got != source {
+				t.Errorf("clientFor(req).KubeconfigPath() after cleanup = %q, want %q", got, source)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// metadataChange is a change of the labels or annotations of objects.
+type metadataChange struct {
+	// field is labels or annotations.
+	field string
+	// set holds the keys to add or overwrite, remove the keys to remove.
+	set    map[string]string
+	remove []string
+	// overwrite allows changing the value of existing keys.
+	overwrite bool
+}
+
+// metadataExpectation is what the labels or annotations of every object must satisfy.
+type metadataExpectation struct {
+	values  map[string]string
+	present []string
+	absent  []string
+}
+
+func (e *Extension) handleLabel(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	return e.handleMetadata(ctx, req, "labels")
+}
+
+func (e *Extension) handleAnnotate(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	return e.handleMetadata(ctx, req, "annotations")
+}
+
+// handleMetadata adds, overwrites and removes labels or annotations on a resource, a list
+// of resources or the resources matching a selector, and checks them against expectations.
+// Changes are applied as JSON merge patches, so they do not conflict with other edits.
+func (e *Extension) handleMetadata(ctx context.Context, req *sdk.OperationRequest, field string) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	change, err := parseMetadataChange(args, field)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	want, err := parseMetadataExpectation(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	targets, err := parseWaitTargets(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	mutate := len(change.set) > 0 || len(change.remove) > 0
+	if !mutate && want == nil {
+		return failure(fmt.Errorf("%s, remove or expect is required", field)), nil
+	}
+
+	refs, err := e.resolveTargets(ctx, targets)
+	if err != nil {
+		e.LogError(ctx, "Failed to resolve resources", map[string]any{
+			"targets": targets.String(),
+			"error":   err.Error(),
+		})
+		return failure(err), nil
+	}
+	if len(refs) == 0 {
+		err := fmt.Errorf("no resources found: %s", targets)
+		if mutate {
+			return failure(err), nil
+		}
+		return failureWithCategory(categoryAgent, err.Error(), err), nil
+	}
+
+	e.LogInfo(ctx, "Updating "+field, map[string]any{
+		"targets": targets.String(),
+		"count":   len(refs),
+		"set":     change.set,
+		"remove":  change.remove,
+	})
+
+	gvrs := make([]schema.GroupVersionResource, len(refs))
+	for i, ref := range refs {
+		if gvrs[i], err = e.resourceFor(ref); err != nil {
+			return failure(err), nil
+		}
+	}
+
+	// Check every object before changing any, so a conflict leaves them all untouched
+	if mutate && !change.overwrite {
+		var conflicts []string
+		for i, ref := range refs {
+			found, err := e.metadataConflicts(ctx, gvrs[i], ref, change)
+			if err != nil {
+				return failure(err), nil
+			}
+			conflicts = append(conflicts, found...)
+		}
+		if len(conflicts) > 0 {
+			e.LogError(ctx, "Refusing to overwrite "+field, map[string]any{
+				"targets":   targets.String(),
+				"conflicts": strings.Join(conflicts, "; "),
+			})
+			return failure(errors.New(strings.Join(conflicts, "\n"))), nil
+		}
+	}
+
+	var mismatches []string
+	objects := make([]map[string]any, 0, len(refs))
+	for i, ref := range refs {
+		gvr := gvrs[i]
+		var obj *unstructured.Unstructured
+		var err error
+		if mutate {
+			obj, err = e.applyMetadataChange(ctx, gvr, ref, change)
+		} else {
+			obj, err = e.client.Get(ctx, gvr, ref.name, ref.namespace)
+			if err != nil {
+				err = fmt.Errorf("failed to get %s: %w", refName(ref), err)
+			}
+		}
+		if err != nil {
+			e.LogError(ctx, "Failed to update "+field, map[string]any{
+				"resource": refName(ref),
+				"error":    err.Error(),
+			})
+			return failure(err), nil
+		}
+
+		values := metadataValues(obj, field)
+		objects = append(objects, map[string]any{"name": refName(ref), field: values})
+		if want != nil {
+			for _, mismatch := range want.check(values, strings.TrimSuffix(field, "s")) {
+				mismatches = append(mismatches, refName(ref)+": "+mismatch)
+			}
+		}
+	}
+
+	objectsJSON, _ := json.Marshal(objects)
+	outputs := map[string]string{
+		"count":   fmt.Sprintf("%d", len(refs)),
+		"objects": string(objectsJSON),
+	}
+
+	if len(mismatches) > 0 {
+		e.LogError(ctx, "Metadata check failed", map[string]any{
+			"field":      field,
+			"targets":    targets.String(),
+			"mismatches": strings.Join(mismatches, "; "),
+		})
+		result := failureWithCategory(categoryAgent,
+			fmt.Sprintf("%s check failed on %s", field, targets),
+			errors.New(strings.Join(mismatches, "\n")),
+		)
+		for key, value := range outputs {
+			result.Outputs[key] = value
+		}
+		return result, nil
+	}
+
+	message := fmt.Sprintf("Checked %s of %s", field, targets)
+	if mutate {
+		message = fmt.Sprintf("Updated %s of %s", field, targets)
+	}
+	return sdk.SuccessWithOutputs(message, outputs), nil
+}
+
+// metadataConflicts returns the keys of the change that the referenced object already
+// has with another value, which only overwrite may change.
+func (e *Extension) metadataConflicts(ctx context.Context, gvr schema.GroupVersionResource, ref *resourceRef, change *metadataChange) ([]string, error) {
+	obj, err := e.client.Get(ctx, gvr, ref.name, ref.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", refName(ref), err)
+	}
+	var conflicts []string
+	current := metadataValues(obj, change.field)
+	for _, key := range sortedKeys(change.set) {
+		if old, exists := current[key]; exists && old != change.set[key] {
+			conflicts = append(conflicts, fmt.Sprintf("%s already has %s %s=%s; set overwrite to change it", refName(ref), strings.TrimSuffix(change.field, "s"), key, old))
+		}
+	}
+	return conflicts, nil
+}
+
+// applyMetadataChange patches the labels or annotations of the referenced object and
+// returns the patched object.
+func (e *Extension) applyMetadataChange(ctx context.Context, gvr schema.GroupVersionResource, ref *resourceRef, change *metadataChange) (*unstructured.Unstructured, error) {
+	values := make(map[string]any, len(change.set)+len(change.remove))
+	for key, value := range change.set {
+		values[key] = value
+	}
+	// A null value removes the key in a JSON merge patch
+	for _, key := range change.remove {
+		values[key] = nil
+	}
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{change.field: values}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build patch: %w", err)
+	}
+
+	obj, err := e.client.Patch(ctx, gvr, ref.name, ref.namespace, types.MergePatchType, patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch %s: %w", refName(ref), err)
+	}
+	return obj, nil
+}
+
+// resolveTargets returns the referenced objects, listing the objects matching the
+// selectors for selector targets.
+func (e *Extension) resolveTargets(ctx context.Context, t *waitTargets) ([]*resourceRef, error) {
+	if t.refs != nil {
+		return t.refs, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(t.apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion: %w", err)
+	}
+	list, err := e.client.List(ctx, e.gvrFor(gv.WithKind(t.kind)), t.namespace, metav1.ListOptions{
+		LabelSelector: t.labelSelector,
+		FieldSelector: t.fieldSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", t, err)
+	}
+
+	refs := make([]*resourceRef, 0, len(list.Items))
+	for _, item := range list.Items {
+		refs = append(refs, &resourceRef{
+			apiVersion: t.apiVersion,
+			kind:       t.kind,
+			name:       item.GetName(),
+			namespace:  item.GetNamespace(),
+			uid:        item.GetUID(),
+		})
+	}
+	return refs, nil
+}
+
+// parseMetadataChange reads the keys to set from args[field], where null values
+// remove keys, the keys to remove and the overwrite flag.
+func parseMetadataChange(args map[string]any, field string) (*metadataChange, error) {
+	change := &metadataChange{field: field, set: map[string]string{}}
+
+	if raw, ok := args[field]; ok {
+		values, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object", field)
+		}
+		for key, value := range values {
+			switch v := value.(type) {
+			case nil:
+				change.remove = append(change.remove, key)
+			case string:
+				change.set[key] = v
+			default:
+				return nil, fmt.Errorf("%s.%s must be a string or null", field, key)
+			}
+		}
+	}
+
+	if raw, ok := args["remove"]; ok {
+		keys, ok := toStringSlice(raw)
+		if !ok {
+			return nil, fmt.Errorf("remove must be a list of keys")
+		}
+		change.remove = append(change.remove, keys...)
+	}
+
+	for _, key := range change.remove {
+		if _, ok := change.set[key]; ok {
+			return nil, fmt.Errorf("%s cannot be both set and removed", key)
+		}
+	}
+
+	change.overwrite, _ = args["overwrite"].(bool)
+	return change, nil
+}
+
+// parseMetadataExpectation reads the values, present and absent expectations.
+// It returns nil when the arguments contain no expectations.
+func parseMetadataExpectation(args map[string]any) (*metadataExpectation, error) {
+	expect, err := parseExpect(args)
+	if err != nil || expect == nil {
+		return nil, err
+	}
+
+	want := &metadataExpectation{}
+	if raw, ok := expect["values"]; ok {
+		values, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expect.values must be an object")
+		}
+		want.values = make(map[string]string, len(values))
+		for key, value := range values {
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("expect.values.%s must be a string", key)
+			}
+			want.values[key] = str
+		}
+	}
+	for key, target := range map[string]*[]string{"present": &want.present, "absent": &want.absent} {
+		if raw, ok := expect[key]; ok {
+			keys, ok := toStringSlice(raw)
+			if !ok {
+				return nil, fmt.Errorf("expect.%s must be a list of keys", key)
+			}
+			*target = keys
+		}
+	}
+	return want, nil
+}
+
+// check returns the expectations values does not meet. noun names a single key,
+// label or annotation, in messages.
+func (w *metadataExpectation) check(values map[string]string, noun string) []string {
+	var result expectationResult
+	for _, key := range sortedKeys(w.values) {
+		got, ok := values[key]
+		switch {
+		case !ok:
+			result.addf("expected %s %s=%q but it is missing", noun, key, w.values[key])
+		case got != w.values[key]:
+			result.addf("expected %s %s=%q but got %q", noun, key, w.values[key], got)
+		}
+	}
+	for _, key := range w.present {
+		if _, ok := values[key]; !ok {
+			result.addf("expected %s %s to be present", noun, key)
+		}
+	}
+	for _, key := range w.absent {
+		if value, ok := values[key]; ok {
+			result.addf("expected %s %s to be absent but it is %q", noun, key, value)
+		}
+	}
+	return result.mismatches
+}
+
+// metadataValues returns the labels or annotations of obj.
+func metadataValues(obj *unstructured.Unstructured, field string) map[string]string {
+	values, _, _ := unstructured.NestedStringMap(obj.Object, "metadata", field)
+	if values == nil {
+		values = map[string]string{}
+	}
+	return values
+}
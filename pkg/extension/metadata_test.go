@@ -0,0 +1,269 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeNodes serves nodes with labels and applies merge patches to their labels
+// and annotations, recording each patch.
+type fakeNodes struct {
+	nodes   map[string]map[string]any
+	patches []string
+}
+
+func (f *fakeNodes) object(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   f.nodes[name],
+	}}
+}
+
+func (f *fakeNodes) client() *mockClient {
+	return &mockClient{
+		getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+			return f.object(name), nil
+		},
+		listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			selector, err := labels.Parse(opts.LabelSelector)
+			if err != nil {
+				return nil, err
+			}
+			list := &unstructured.UnstructuredList{}
+			for _, name := range sortedKeys(f.nodes) {
+				if obj := f.object(name); selector.Matches(labels.Set(obj.GetLabels())) {
+					list.Items = append(list.Items, *obj)
+				}
+			}
+			return list, nil
+		},
+		patchFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+			if patchType != types.MergePatchType {
+				return nil, fmt.Errorf("unexpected patch type %s", patchType)
+			}
+			f.patches = append(f.patches, name+" "+string(data))
+			var patch struct {
+				Metadata map[string]map[string]any `json:"metadata"`
+			}
+			if err := json.Unmarshal(data, &patch); err != nil {
+				return nil, err
+			}
+			for field, values := range patch.Metadata {
+				current, _ := f.nodes[name][field].(map[string]any)
+				if current == nil {
+					current = map[string]any{}
+				}
+				for key, value := range values {
+					if value == nil {
+						delete(current, key)
+					} else {
+						current[key] = value
+					}
+				}
+				f.nodes[name][field] = current
+			}
+			return f.object(name), nil
+		},
+	}
+}
+
+func newFakeNodes() *fakeNodes {
+	return &fakeNodes{nodes: map[string]map[string]any{
+		"node-1": {"name": "node-1", "labels": map[string]any{"zone": "a", "tier": "web"}},
+		"node-2": {"name": "node-2", "labels": map[string]any{"zone": "a"}},
+		"node-3": {"name": "node-3", "labels": map[string]any{"zone": "b"}},
+	}}
+}
+
+func TestHandleLabel(t *testing.T) {
+	nodeArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Node",
+			"metadata":   map[string]any{"name": "node-1"},
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		wantSuccess  bool
+		wantPatches  []string
+		wantErrors   []string
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name:        "add and remove labels",
+			args:        nodeArgs(map[string]any{"labels": map[string]any{"role": "edge", "tier": nil}}),
+			wantSuccess: true,
+			wantPatches: []string{`node-1 {"metadata":{"labels":{"role":"edge","tier":null}}}`},
+			wantOutputs: map[string]string{"count": "1", "objects": `[{"labels":{"role":"edge","zone":"a"},"name":"Node node-1"}]`},
+		},
+		{
+			name:        "remove list",
+			args:        nodeArgs(map[string]any{"remove": []any{"tier"}}),
+			wantSuccess: true,
+			wantPatches: []string{`node-1 {"metadata":{"labels":{"tier":null}}}`},
+		},
+		{
+			name:        "label nodes matching selector",
+			args:        nodeArgs(map[string]any{"metadata": map[string]any{}, "selector": "zone=a", "labels": map[string]any{"gpu": "true"}}),
+			wantSuccess: true,
+			wantPatches: []string{
+				`node-1 {"metadata":{"labels":{"gpu":"true"}}}`,
+				`node-2 {"metadata":{"labels":{"gpu":"true"}}}`,
+			},
+			wantOutputs: map[string]string{"count": "2"},
+		},
+		{
+			name:         "existing value without overwrite",
+			args:         nodeArgs(map[string]any{"labels": map[string]any{"tier": "db"}}),
+			wantSuccess:  false,
+			wantErrors:   []string{"Node node-1 already has label tier=web; set overwrite to change it"},
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "conflict on a later selected node changes none",
+			args:         nodeArgs(map[string]any{"metadata": map[string]any{}, "selector": "zone", "labels": map[string]any{"zone": "a"}}),
+			wantSuccess:  false,
+			wantErrors:   []string{"Node node-3 already has label zone=b; set overwrite to change it"},
+			wantCategory: categoryTask,
+		},
+		{
+			name:        "existing value with overwrite",
+			args:        nodeArgs(map[string]any{"labels": map[string]any{"tier": "db"}, "overwrite": true}),
+			wantSuccess: true,
+			wantPatches: []string{`node-1 {"metadata":{"labels":{"tier":"db"}}}`},
+		},
+		{
+			name:        "same value without overwrite",
+			args:        nodeArgs(map[string]any{"labels": map[string]any{"tier": "web"}}),
+			wantSuccess: true,
+			wantPatches: []string{`node-1 {"metadata":{"labels":{"tier":"web"}}}`},
+		},
+		{
+			name: "expectations met",
+			args: nodeArgs(map[string]any{"expect": map[string]any{
+				"values":  map[string]any{"zone": "a"},
+				"present": []any{"tier"},
+				"absent":  []any{"gpu"},
+			}}),
+			wantSuccess: true,
+		},
+		{
+			name: "expectations not met on selected nodes",
+			args: nodeArgs(map[string]any{
+				"metadata": map[string]any{},
+				"selector": "zone=a",
+				"expect":   map[string]any{"values": map[string]any{"tier": "web"}, "absent": []any{"zone"}},
+			}),
+			wantSuccess: false,
+			wantErrors: []string{
+				`Node node-1: expected label zone to be absent but it is "a"`,
+				`Node node-2: expected label tier="web" but it is missing`,
+			},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "no matching nodes to check",
+			args:         nodeArgs(map[string]any{"metadata": map[string]any{}, "selector": "zone=c", "expect": map[string]any{"present": []any{"zone"}}}),
+			wantSuccess:  false,
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "set and remove the same key",
+			args:         nodeArgs(map[string]any{"labels": map[string]any{"tier": "db"}, "remove": []any{"tier"}}),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "nothing to do",
+			args:         nodeArgs(nil),
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := newFakeNodes()
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    nodes.client(),
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleLabel(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleLabel() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleLabel() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if fmt.Sprint(nodes.patches) != fmt.Sprint(tt.wantPatches) {
+				t.Errorf("patches = %v, want %v", nodes.patches, tt.wantPatches)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleAnnotate(t *testing.T) {
+	nodes := newFakeNodes()
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client:    nodes.client(),
+	}
+
+	req := &sdk.OperationRequest{Args: map[string]any{
+		"apiVersion":  "v1",
+		"kind":        "Node",
+		"metadata":    map[string]any{"name": "node-3"},
+		"annotations": map[string]any{"owner": "team-x"},
+		"expect":      map[string]any{"values": map[string]any{"owner": "team-x"}},
+	}}
+	result, err := ext.handleAnnotate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleAnnotate() returned error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("handleAnnotate() failed: %s", result.Error)
+	}
+	if want := []string{`node-3 {"metadata":{"annotations":{"owner":"team-x"}}}`}; fmt.Sprint(nodes.patches) != fmt.Sprint(want) {
+		t.Errorf("patches = %v, want %v", nodes.patches, want)
+	}
+	if labels := nodes.nodes["node-3"]["labels"].(map[string]any); len(labels) != 1 {
+		t.Errorf("labels changed to %v", labels)
+	}
+}
@@ -2,20 +2,30 @@ package extension
 
 import (
 	"context"
+	"net/http"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type mockClient struct {
 	createFn            func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error)
+	updateFn            func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error)
+	patchFn             func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error)
 	getFn               func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error)
+	getSubresourceFn    func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error)
+	listFn              func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
 	deleteFn            func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error
+	deleteCollectionFn  func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	serverResourcesFn   func(ctx context.Context, groupVersion string) ([]metav1.APIResource, error)
+	proxyFn             func(ctx context.Context, req ProxyRequest) (*ProxyResponse, error)
 	checkAccessFn       func(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error)
 	listContextsFn      func(ctx context.Context) ([]ContextInfo, error)
 	getCurrentContextFn func(ctx context.Context) (string, error)
-	viewConfigFn        func(ctx context.Context, minify bool) (string, error)
+	viewConfigFn        func(ctx context.Context, opts ViewConfigOptions) (string, error)
+	kubeconfigPath      string
 }
 
 func (m *mockClient) Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
@@ -25,13 +35,40 @@ func (m *mockClient) Create(ctx context.Context, gvr schema.GroupVersionResource
 	return obj, nil
 }
 
-func (m *mockClient) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+func (m *mockClient) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, gvr, obj, namespace, subresources...)
+	}
+	return obj, nil
+}
+
+func (m *mockClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	if m.patchFn != nil {
+		return m.patchFn(ctx, gvr, name, namespace, patchType, data, subresources...)
+	}
+	return &unstructured.Unstructured{}, nil
+}
+
+func (m *mockClient) Get(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		if m.getSubresourceFn != nil {
+			return m.getSubresourceFn(ctx, gvr, name, namespace, subresources...)
+		}
+		return nil, nil
+	}
 	if m.getFn != nil {
 		return m.getFn(ctx, gvr, name, namespace)
 	}
 	return nil, nil
 }
 
+func (m *mockClient) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, gvr, namespace, opts)
+	}
+	return &unstructured.UnstructuredList{}, nil
+}
+
 func (m *mockClient) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
 	if m.deleteFn != nil {
 		return m.deleteFn(ctx, gvr, name, namespace, opts)
@@ -39,6 +76,27 @@ func (m *mockClient) Delete(ctx context.Context, gvr schema.GroupVersionResource
 	return nil
 }
 
+func (m *mockClient) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	if m.deleteCollectionFn != nil {
+		return m.deleteCollectionFn(ctx, gvr, namespace, opts, listOpts)
+	}
+	return nil
+}
+
+func (m *mockClient) ServerResources(ctx context.Context, groupVersion string) ([]metav1.APIResource, error) {
+	if m.serverResourcesFn != nil {
+		return m.serverResourcesFn(ctx, groupVersion)
+	}
+	return nil, nil
+}
+
+func (m *mockClient) Proxy(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+	if m.proxyFn != nil {
+		return m.proxyFn(ctx, req)
+	}
+	return &ProxyResponse{StatusCode: http.StatusOK}, nil
+}
+
 func (m *mockClient) CheckAccess(ctx context.Context, user, verb, resource, apiGroup, namespace, resourceName string) (bool, string, error) {
 	if m.checkAccessFn != nil {
 		return m.checkAccessFn(ctx, user, verb, resource, apiGroup, namespace, resourceName)
@@ -62,9 +120,19 @@ func (m *mockClient) GetCurrentContext(ctx context.Context) (string, error) {
 	return "default", nil
 }
 
-func (m *mockClient) ViewConfig(ctx context.Context, minify bool) (string, error) {
+func (m *mockClient) ViewConfig(ctx context.Context, opts ViewConfigOptions) (string, error) {
 	if m.viewConfigFn != nil {
-		return m.viewConfigFn(ctx, minify)
+		return m.viewConfigFn(ctx, opts)
 	}
 	return "apiVersion: v1\nkind: Config\n", nil
 }
+
+func (m *mockClient) KubeconfigPath() string {
+	return m.kubeconfigPath
+}
+
+func (m *mockClient) WithKubeconfig(path string) ResourceClient {
+	scoped := *m
+	scoped.kubeconfigPath = path
+	return &scoped
+}
@@ -0,0 +1,263 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// labelManagedBy marks objects created and owned by the extension.
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	// managedByValue is the value of labelManagedBy on owned objects.
+	managedByValue = "mcpchecker"
+	// labelTask records the task that created an owned object.
+	labelTask = "mcpchecker.io/task"
+	// labelRunID records the extension run that created an owned object.
+	labelRunID = "mcpchecker.io/run-id"
+
+	// defaultNamespacePrefix is used when createNamespace is called without a prefix.
+	defaultNamespacePrefix = "mcpchecker-"
+	// namespaceSuffixLength is the length of the random suffix appended to the prefix.
+	namespaceSuffixLength = 5
+)
+
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// invalidLabelChars matches characters not allowed in label values.
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// labelValue turns s into a valid label value by replacing invalid characters
+// and truncating it to the maximum label length.
+func labelValue(s string) string {
+	v := invalidLabelChars.ReplaceAllString(s, "-")
+	if len(v) > validation.LabelValueMaxLength {
+		v = v[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(v, "-_.")
+}
+
+// taskName returns the task name recorded on owned objects. Tasks are identified by
+// their directory, so the directory name is used unless args override it.
+func taskName(req *sdk.OperationRequest, args map[string]any) string {
+	if name, ok := args["task"].(string); ok && name != "" {
+		return name
+	}
+	if req.Context.Workdir == "" {
+		return ""
+	}
+	return filepath.Base(req.Context.Workdir)
+}
+
+// nameSuffix returns a random suffix of length for a generated name, or the recorded
+// suffix when replaying a cassette.
+func (e *Extension) nameSuffix(length int) (string, error) {
+	if e.suffixes == nil {
+		return rand.String(length), nil
+	}
+	return e.suffixes(length)
+}
+
+// ownerLabels returns the labels that mark an object as owned by this extension run.
+func (e *Extension) ownerLabels(req *sdk.OperationRequest, args map[string]any) map[string]string {
+	return map[string]string{
+		labelManagedBy: managedByValue,
+		labelTask:      labelValue(taskName(req, args)),
+		labelRunID:     labelValue(e.runID),
+	}
+}
+
+// handleCreateNamespace creates a uniquely named namespace owned by the task.
+// The namespace is labeled with the task and run that created it and is deleted
+// by the cleanup operation.
+func (e *Extension) handleCreateNamespace(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	prefix, _ := args["prefix"].(string)
+	if prefix == "" {
+		prefix = defaultNamespacePrefix
+	}
+	useGenerateName, _ := args["generateName"].(bool)
+
+	// The prefix must still form a valid namespace name once the suffix is appended
+	if errs := validation.IsDNS1123Label(prefix + strings.Repeat("a", namespaceSuffixLength)); len(errs) > 0 {
+		return failure(fmt.Errorf("invalid namespace prefix %q: %s", prefix, strings.Join(errs, ", "))), nil
+	}
+
+	labels := e.ownerLabels(req, args)
+	if extra, ok := args["labels"].(map[string]any); ok {
+		for key, value := range extra {
+			// Ownership labels decide what gcNamespaces and force deletes may touch
+			if _, owner := labels[key]; owner {
+				return failureWithCategory(categoryTask, "", fmt.Errorf("labels.%s is set by the extension and cannot be overridden", key)), nil
+			}
+			str, ok := value.(string)
+			if !ok {
+				return failure(fmt.Errorf("labels.%s must be a string", key)), nil
+			}
+			labels[key] = str
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+	obj.SetLabels(labels)
+	if useGenerateName {
+		obj.SetGenerateName(prefix)
+	} else {
+		suffix, err := e.nameSuffix(namespaceSuffixLength)
+		if err != nil {
+			return failure(fmt.Errorf("failed to generate namespace name: %w", err)), nil
+		}
+		obj.SetName(prefix + suffix)
+	}
+
+	e.LogInfo(ctx, "Creating namespace", map[string]any{
+		"name":         obj.GetName(),
+		"generateName": obj.GetGenerateName(),
+		"task":         labels[labelTask],
+		"runId":        labels[labelRunID],
+	})
+
+	result, err := e.client.Create(ctx, namespaceGVR, obj, "")
+	if err != nil {
+		e.LogError(ctx, "Failed to create namespace", map[string]any{
+			"name":  obj.GetName(),
+			"error": err.Error(),
+		})
+		return failure(fmt.Errorf("failed to create namespace: %w", err)), nil
+	}
+
+	e.recordNamespace(req, result.GetName())
+
+	e.LogInfo(ctx, "Namespace created successfully", map[string]any{
+		"name": result.GetName(),
+		"uid":  string(result.GetUID()),
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("Created Namespace/%s", result.GetName()),
+		map[string]string{
+			"name":      result.GetName(),
+			"namespace": result.GetName(),
+			"uid":       string(result.GetUID()),
+		},
+	), nil
+}
+
+// handleGCNamespaces deletes namespaces owned by the extension that are older than a TTL.
+// It removes namespaces left behind by crashed or interrupted runs.
+func (e *Extension) handleGCNamespaces(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		args = make(map[string]any)
+	}
+
+	ttlStr, _ := args["ttl"].(string)
+	if ttlStr == "" {
+		ttlStr = "24h"
+	}
+
+	ttl, err := time.ParseDuration(ttlStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid ttl format: %w", err)), nil
+	}
+
+	dryRun, _ := args["dryRun"].(bool)
+
+	selector := labelManagedBy + "=" + managedByValue
+
+	e.LogInfo(ctx, "Collecting stale namespaces", map[string]any{
+		"ttl":      ttlStr,
+		"selector": selector,
+		"dryRun":   dryRun,
+	})
+
+	list, err := e.client.List(ctx, namespaceGVR, "", metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		e.LogError(ctx, "Failed to list namespaces", map[string]any{
+			"error": err.Error(),
+		})
+		return failure(fmt.Errorf("failed to list namespaces: %w", err)), nil
+	}
+
+	active := e.activeNamespaces()
+	cutoff := time.Now().Add(-ttl)
+
+	var stale []string
+	for _, ns := range list.Items {
+		if ns.GetDeletionTimestamp() != nil || active[ns.GetName()] {
+			continue
+		}
+		if ns.GetCreationTimestamp().Time.After(cutoff) {
+			continue
+		}
+		stale = append(stale, ns.GetName())
+	}
+	sort.Strings(stale)
+
+	if !dryRun {
+		for _, name := range stale {
+			if err := e.deleteNamespace(ctx, name); err != nil {
+				e.LogError(ctx, "Failed to delete stale namespace", map[string]any{
+					"name":  name,
+					"error": err.Error(),
+				})
+				return failure(fmt.Errorf("failed to delete namespace %s: %w", name, err)), nil
+			}
+		}
+	}
+
+	e.LogInfo(ctx, "Stale namespaces collected", map[string]any{
+		"count":  len(stale),
+		"dryRun": dryRun,
+	})
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%s %d stale namespace(s)", verb, len(stale)),
+		map[string]string{
+			"count":      fmt.Sprintf("%d", len(stale)),
+			"namespaces": strings.Join(stale, ","),
+		},
+	), nil
+}
+
+// deleteNamespace deletes a namespace in the background, treating a missing namespace as deleted.
+func (e *Extension) deleteNamespace(ctx context.Context, name string) error {
+	propagation := metav1.DeletePropagationBackground
+	err := e.client.Delete(ctx, namespaceGVR, name, "", metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
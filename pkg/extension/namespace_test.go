@@ -0,0 +1,252 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/protocol"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestHandleCreateNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        any
+		client      *mockClient
+		wantSuccess bool
+		wantPrefix  string
+	}{
+		{
+			name:        "default prefix",
+			args:        map[string]any{},
+			client:      &mockClient{},
+			wantSuccess: true,
+			wantPrefix:  "mcpchecker-",
+		},
+		{
+			name:        "custom prefix with labels",
+			args:        map[string]any{"prefix": "web-", "labels": map[string]any{"team": "a"}},
+			client:      &mockClient{},
+			wantSuccess: true,
+			wantPrefix:  "web-",
+		},
+		{
+			name: "server generated name",
+			args: map[string]any{"prefix": "web-", "generateName": true},
+			client: &mockClient{
+				createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+					if obj.GetName() != "" || obj.GetGenerateName() != "web-" {
+						t.Errorf("expected generateName web-, got name=%q generateName=%q", obj.GetName(), obj.GetGenerateName())
+					}
+					result := obj.DeepCopy()
+					result.SetName("web-x7k2p")
+					return result, nil
+				},
+			},
+			wantSuccess: true,
+			wantPrefix:  "web-",
+		},
+		{
+			name:        "owner label override",
+			args:        map[string]any{"labels": map[string]any{"mcpchecker.io/run-id": "other"}},
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
+		{
+			name:        "invalid prefix",
+			args:        map[string]any{"prefix": "Web_"},
+			client:      &mockClient{},
+			wantSuccess: false,
+		},
+		{
+			name: "client error",
+			args: map[string]any{},
+			client: &mockClient{
+				createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+					return nil, errors.New("connection refused")
+				},
+			},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *unstructured.Unstructured
+			createFn := tt.client.createFn
+			tt.client.createFn = func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+				created = obj
+				if createFn != nil {
+					return createFn(ctx, gvr, obj, namespace)
+				}
+				return obj, nil
+			}
+
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+				runID:     "run-1",
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args, Context: protocol.ExecuteContext{Workdir: "/evals/tasks/create-pod"}}
+			result, err := ext.handleCreateNamespace(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleCreateNamespace() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleCreateNamespace() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+
+			name := result.Outputs["name"]
+			if !strings.HasPrefix(name, tt.wantPrefix) || len(name) <= len(tt.wantPrefix) {
+				t.Errorf("handleCreateNamespace() name = %q, want prefix %q and a suffix", name, tt.wantPrefix)
+			}
+			labels := created.GetLabels()
+			if labels[labelManagedBy] != managedByValue || labels[labelTask] != "create-pod" || labels[labelRunID] != "run-1" {
+				t.Errorf("handleCreateNamespace() labels = %v, want owner labels", labels)
+			}
+			if !ext.activeNamespaces()[name] {
+				t.Errorf("handleCreateNamespace() did not record namespace %q for cleanup", name)
+			}
+		})
+	}
+}
+
+func TestCleanupDeletesTaskNamespaces(t *testing.T) {
+	var deleted []string
+	client := &mockClient{
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			deleted = append(deleted, name)
+			if name == "gone" {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, name)
+			}
+			return nil
+		},
+	}
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client:    client,
+	}
+
+	req := &sdk.OperationRequest{Context: protocol.ExecuteContext{Workdir: "/tasks/a"}}
+	other := &sdk.OperationRequest{Context: protocol.ExecuteContext{Workdir: "/tasks/b"}}
+	ext.recordNamespace(req, "ns-a")
+	ext.recordNamespace(req, "gone")
+	ext.recordNamespace(other, "ns-b")
+
+	result, err := ext.handleCleanup(context.Background(), req)
+	if err != nil || !result.Success {
+		t.Fatalf("handleCleanup() = %+v, %v", result, err)
+	}
+	if strings.Join(deleted, ",") != "ns-a,gone" {
+		t.Errorf("handleCleanup() deleted %v, want [ns-a gone]", deleted)
+	}
+	if !ext.activeNamespaces()["ns-b"] {
+		t.Errorf("handleCleanup() released namespaces of another task")
+	}
+}
+
+func TestHandleGCNamespaces(t *testing.T) {
+	newNamespace := func(name string, age time.Duration, terminating bool) unstructured.Unstructured {
+		ns := unstructured.Unstructured{}
+		ns.SetName(name)
+		ns.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		if terminating {
+			now := metav1.Now()
+			ns.SetDeletionTimestamp(&now)
+		}
+		return ns
+	}
+
+	tests := []struct {
+		name        string
+		args        any
+		wantSuccess bool
+		wantDeleted string
+		wantOutput  string
+	}{
+		{
+			name:        "deletes namespaces older than ttl",
+			args:        map[string]any{"ttl": "1h"},
+			wantSuccess: true,
+			wantDeleted: "old-a,old-b",
+			wantOutput:  "old-a,old-b",
+		},
+		{
+			name:        "dry run deletes nothing",
+			args:        map[string]any{"ttl": "1h", "dryRun": true},
+			wantSuccess: true,
+			wantDeleted: "",
+			wantOutput:  "old-a,old-b",
+		},
+		{
+			name:        "default ttl keeps recent namespaces",
+			args:        map[string]any{},
+			wantSuccess: true,
+			wantDeleted: "old-b",
+			wantOutput:  "old-b",
+		},
+		{
+			name:        "invalid ttl",
+			args:        map[string]any{"ttl": "soon"},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			client := &mockClient{
+				listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+					if opts.LabelSelector != labelManagedBy+"="+managedByValue {
+						t.Errorf("unexpected selector %q", opts.LabelSelector)
+					}
+					return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+						newNamespace("old-b", 48*time.Hour, false),
+						newNamespace("old-a", 2*time.Hour, false),
+						newNamespace("recent", time.Minute, false),
+						newNamespace("terminating", 48*time.Hour, true),
+						newNamespace("in-use", 48*time.Hour, false),
+					}}, nil
+				},
+				deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+					deleted = append(deleted, name)
+					return nil
+				},
+			}
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    client,
+			}
+			ext.recordNamespace(&sdk.OperationRequest{}, "in-use")
+
+			result, err := ext.handleGCNamespaces(context.Background(), &sdk.OperationRequest{Args: tt.args})
+			if err != nil {
+				t.Fatalf("handleGCNamespaces() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleGCNamespaces() success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if !tt.wantSuccess {
+				return
+			}
+			if got := strings.Join(deleted, ","); got != tt.wantDeleted {
+				t.Errorf("handleGCNamespaces() deleted %q, want %q", got, tt.wantDeleted)
+			}
+			if got := result.Outputs["namespaces"]; got != tt.wantOutput {
+				t.Errorf("handleGCNamespaces() namespaces output = %q, want %q", got, tt.wantOutput)
+			}
+		})
+	}
+}
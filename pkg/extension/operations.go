@@ -1,195 +1,860 @@
 package extension
 
 import (
-	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
 	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
 )
 
+// operation describes an operation offered by the extension.
+type operation struct {
+	name        string
+	description string
+	params      jsonschema.Schema
+	handler     sdk.OperationHandler
+}
+
+// addOperation registers op with the SDK. The handler is wrapped so that references
+// to earlier outputs in its arguments are resolved before it runs, its API retries
+// are counted, and its own outputs are recorded for later steps of the same task.
+func (e *Extension) addOperation(op operation) {
+	handler := e.withTemplating(op.name, &op.params, withValidation(op, withRetryCount(op.handler)))
+	e.operations[op.name] = handler
+	e.schemas[op.name] = &op.params
+	e.AddOperation(
+		sdk.NewOperation(op.name,
+			sdk.WithDescription(op.description),
+			sdk.WithParams(op.params),
+		),
+		handler,
+	)
+}
+
 // registerOperations adds all available Kubernetes operations to the extension.
 // Each operation is defined with a JSON schema for input validation and a handler function.
 func (e *Extension) registerOperations() {
-	e.AddOperation(
-		sdk.NewOperation("create",
-			sdk.WithDescription("Create a Kubernetes resource"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "Kubernetes resource spec (apiVersion, kind, metadata, spec, etc.)",
-				Properties: map[string]*jsonschema.Schema{
-					"apiVersion": {
-						Type:        "string",
-						Description: "API version (e.g., v1, apps/v1)",
-					},
-					"kind": {
-						Type:        "string",
-						Description: "Resource kind (e.g., Pod, Namespace, Deployment)",
-					},
-					"metadata": {
-						Type:        "object",
-						Description: "Resource metadata (name, namespace, labels, annotations)",
-					},
-					"spec": {
-						Type:        "object",
-						Description: "Resource spec (optional, depends on resource type)",
-					},
+	e.addOperation(operation{
+		name:        "create",
+		description: "Create a Kubernetes resource",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Kubernetes resource spec (apiVersion, kind, metadata, spec, etc.)",
+			Properties: map[string]*jsonschema.Schema{
+				"apiVersion": {
+					Type:        "string",
+					Description: "API version (e.g., v1, apps/v1)",
 				},
-				Required: []string{"apiVersion", "kind", "metadata"},
-			}),
-		),
-		e.handleCreate,
-	)
+				"kind": {
+					Type:        "string",
+					Description: "Resource kind (e.g., Pod, Namespace, Deployment)",
+				},
+				"metadata": {
+					Type:        "object",
+					Description: "Resource metadata (name or generateName, namespace, labels, annotations)",
+				},
+				"spec": {
+					Type:        "object",
+					Description: "Resource spec (optional, depends on resource type)",
+				},
+				"ifExists": {
+					Type:        "string",
+					Description: "What to do when the resource already exists: fail, ignore, replace (delete and recreate) or update (default: fail)",
+					Enum:        []any{"fail", "ignore", "replace", "update"},
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "Timeout for the existing resource to be deleted when ifExists is replace (e.g., 30s, default: 60s)",
+				},
+			},
+			Required: []string{"apiVersion", "kind", "metadata"},
+			// Any other top-level field of the manifest, such as data or rules
+			AdditionalProperties: &jsonschema.Schema{},
+		},
+		handler: e.handleCreate,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("wait",
-			sdk.WithDescription("Wait for a condition on a Kubernetes resource"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "Resource reference with condition to wait for",
-				Properties: map[string]*jsonschema.Schema{
-					"apiVersion": {
-						Type:        "string",
-						Description: "API version (e.g., v1, apps/v1)",
-					},
-					"kind": {
-						Type:        "string",
-						Description: "Resource kind (e.g., Pod, Deployment)",
-					},
-					"metadata": {
-						Type:        "object",
-						Description: "Resource metadata (name, namespace)",
-					},
-					"condition": {
-						Type:        "string",
-						Description: "Condition type to wait for (e.g., Ready, Available)",
-					},
-					"status": {
-						Type:        "string",
-						Description: "Expected condition status (default: True)",
-					},
-					"timeout": {
-						Type:        "string",
-						Description: "Timeout duration (e.g., 60s, 5m, default: 60s)",
-					},
+	configMapParams := dataSourceProperties()
+	e.addOperation(operation{
+		name:        "createConfigMap",
+		description: "Create a ConfigMap from files, directories, literals and env files",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "ConfigMap metadata and data sources",
+			Properties:  configMapParams,
+			Required:    []string{"metadata"},
+		},
+		handler: e.handleCreateConfigMap,
+	})
+
+	secretParams := dataSourceProperties()
+	secretParams["type"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Secret type: generic, tls or docker-registry (default: generic)",
+		Enum:        []any{"generic", "tls", "docker-registry"},
+	}
+	secretParams["cert"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Certificate file for tls secrets, relative to the task directory",
+	}
+	secretParams["key"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Private key file for tls secrets, relative to the task directory",
+	}
+	secretParams["dockerServer"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Registry server for docker-registry secrets (default: https://index.docker.io/v1/)",
+	}
+	secretParams["dockerUsername"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Registry username for docker-registry secrets",
+	}
+	secretParams["dockerPassword"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Registry password for docker-registry secrets",
+	}
+	secretParams["dockerEmail"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Registry email for docker-registry secrets (optional)",
+	}
+	e.addOperation(operation{
+		name:        "createSecret",
+		description: "Create a generic, tls or docker-registry Secret from files, literals or credentials",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Secret metadata and data sources",
+			Properties:  secretParams,
+			Required:    []string{"metadata"},
+		},
+		handler: e.handleCreateSecret,
+	})
+
+	waitParams := checkProperties()
+	waitParams["timeout"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Timeout duration (e.g., 60s, 5m, default: 60s)",
+	}
+	e.addOperation(operation{
+		name:        "wait",
+		description: "Wait for a condition on a Kubernetes resource, on the resources matching a selector, or on a list of resources",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference, selector or list of resources with condition to wait for",
+			Properties:  waitParams,
+		},
+		handler: e.handleWait,
+	})
+
+	consistentlyParams := checkProperties()
+	consistentlyParams["duration"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "How long the condition must keep holding (e.g., 30s, default: 10s)",
+	}
+	consistentlyParams["interval"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "Time between samples (e.g., 500ms, default: 1s)",
+	}
+	e.addOperation(operation{
+		name:        "consistently",
+		description: "Check that a condition keeps holding on Kubernetes resources for a duration",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference, selector or list of resources with condition that must keep holding",
+			Properties:  consistentlyParams,
+		},
+		handler: e.handleConsistently,
+	})
+
+	e.addOperation(operation{
+		name:        "waitForAPI",
+		description: "Wait until the API server serves a resource kind, such as one defined by a newly installed CRD",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "API version and kind to wait for",
+			Properties: map[string]*jsonschema.Schema{
+				"apiVersion": {
+					Type:        "string",
+					Description: "API version (e.g., example.com/v1)",
 				},
-				Required: []string{"apiVersion", "kind", "metadata", "condition"},
-			}),
-		),
-		e.handleWait,
-	)
+				"kind": {
+					Type:        "string",
+					Description: "Resource kind (e.g., Widget)",
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "Timeout duration (e.g., 60s, 5m, default: 60s)",
+				},
+			},
+			Required: []string{"apiVersion", "kind"},
+		},
+		handler: e.handleWaitForAPI,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("delete",
-			sdk.WithDescription("Delete a Kubernetes resource"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "Resource reference to delete",
-				Properties: map[string]*jsonschema.Schema{
-					"apiVersion": {
-						Type:        "string",
-						Description: "API version (e.g., v1, apps/v1)",
-					},
-					"kind": {
-						Type:        "string",
-						Description: "Resource kind (e.g., Pod, Namespace)",
-					},
-					"metadata": {
-						Type:        "object",
-						Description: "Resource metadata (name, namespace)",
-					},
-					"ignoreNotFound": {
-						Type:        "boolean",
-						Description: "If true, do not fail when the resource does not exist",
-					},
+	e.addOperation(operation{
+		name:        "podHealth",
+		description: "Check that pods are healthy: containers ready, few restarts, no crash-looping, image pull or OOM problems",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Pods to check, by name, label selector or owning workload, and the health expectations",
+			Properties: map[string]*jsonschema.Schema{
+				"metadata": {
+					Type:        "object",
+					Description: "Namespace of the pods, and the pod name to check a single pod",
 				},
-				Required: []string{"apiVersion", "kind", "metadata"},
-			}),
-		),
-		e.handleDelete,
-	)
+				"selector": {
+					Type:        "string",
+					Description: "Label selector of the pods (e.g., app=web)",
+				},
+				"owner": {
+					Type:        "object",
+					Description: "Workload whose pods to check (kind, name, optional apiVersion), e.g. {kind: Deployment, name: web}",
+				},
+				"maxRestarts": {
+					Type:        "integer",
+					Description: "Highest restart count allowed for any container, -1 for no limit (default: 0)",
+				},
+				"images": {
+					Type:        "object",
+					Description: "Expected image per container name (e.g., {web: nginx:1.27})",
+				},
+				"minPods": {
+					Type:        "integer",
+					Description: "Minimum number of pods that must be selected (default: 1)",
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "Keep checking until the pods are healthy or the timeout expires (e.g., 60s, default: check once)",
+				},
+			},
+		},
+		handler: e.handlePodHealth,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("authCanI",
-			sdk.WithDescription("Check if a user or service account can perform an action on a resource"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "Permission check parameters",
-				Properties: map[string]*jsonschema.Schema{
-					"verb": {
-						Type:        "string",
-						Description: "Action verb (get, list, create, delete, watch, patch, update, etc.)",
-					},
-					"resource": {
-						Type:        "string",
-						Description: "Resource name (pods, deployments, configmaps, etc.)",
-					},
-					"as": {
-						Type:        "string",
-						Description: "User or service account to impersonate (e.g., alice, system:serviceaccount:ns:sa-name)",
+	e.addOperation(operation{
+		name:        "httpProbe",
+		description: "Send an HTTP request to a service or pod through the API server proxy and check the response",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Service or pod to probe, the request to send and the expected response",
+			Properties: map[string]*jsonschema.Schema{
+				"kind": {
+					Type:        "string",
+					Description: "Kind of the target (default: Service)",
+					Enum:        []any{"Service", "Pod"},
+				},
+				"metadata": {
+					Type:        "object",
+					Description: "Name and namespace of the service or pod",
+				},
+				"port": {
+					Types:       []string{"integer", "string"},
+					Description: "Port number or name (default: first port)",
+				},
+				"scheme": {
+					Type:        "string",
+					Description: "Scheme of the backend (default: http)",
+					Enum:        []any{"http", "https"},
+				},
+				"path": {
+					Type:        "string",
+					Description: "Request path including any query (default: /)",
+				},
+				"method": {
+					Type:        "string",
+					Description: "HTTP method (default: GET)",
+				},
+				"headers": {
+					Type:        "object",
+					Description: "Request headers",
+				},
+				"expect": {
+					Type:        "object",
+					Description: "Expected response: status code (default: any 2xx), headers as name to regex, body regex",
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "How long to retry until the response meets the expectations (e.g., 60s, default: 20s)",
+				},
+				"interval": {
+					Type:        "string",
+					Description: "Time between attempts (e.g., 500ms, default: 1s)",
+				},
+			},
+			Required: []string{"metadata"},
+		},
+		handler: e.handleHTTPProbe,
+	})
+
+	e.addOperation(operation{
+		name:        "serviceEndpoints",
+		description: "Check the ready endpoints of a service from its EndpointSlices, explaining why endpoints are missing",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Service to check and the expected endpoints",
+			Properties: map[string]*jsonschema.Schema{
+				"metadata": {
+					Type:        "object",
+					Description: "Name and namespace of the service",
+				},
+				"minReady": {
+					Type:        "integer",
+					Description: "Minimum number of ready endpoints (default: 1)",
+				},
+				"ports": {
+					Type:        "array",
+					Description: "Port names or numbers the endpoints must serve",
+					Items:       &jsonschema.Schema{Types: []string{"integer", "string"}},
+				},
+				"pods": {
+					Type:        "array",
+					Description: "Names of pods that must back ready endpoints",
+					Items:       &jsonschema.Schema{Type: "string"},
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "Keep checking until the expectations are met or the timeout expires (e.g., 60s, default: check once)",
+				},
+			},
+			Required: []string{"metadata"},
+		},
+		handler: e.handleServiceEndpoints,
+	})
+
+	e.addOperation(operation{
+		name:        "scale",
+		description: "Read or set the replicas of a scalable resource through its scale subresource",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference with the replicas to set and the expected replicas",
+			Properties: map[string]*jsonschema.Schema{
+				"apiVersion": {
+					Type:        "string",
+					Description: "API version (e.g., apps/v1)",
+				},
+				"kind": {
+					Type:        "string",
+					Description: "Resource kind (e.g., Deployment, StatefulSet)",
+				},
+				"metadata": {
+					Type:        "object",
+					Description: "Resource metadata (name, namespace)",
+				},
+				"replicas": {
+					Type:        "integer",
+					Description: "Replicas to set (optional, omit to only read or verify the replicas)",
+				},
+				"wait": {
+					Type:        "boolean",
+					Description: "Wait until the status reports the new replica count (default: false)",
+				},
+				"timeout": {
+					Type:        "string",
+					Description: "Timeout for wait (e.g., 60s, 5m, default: 60s)",
+				},
+				"expect": {
+					Type:        "object",
+					Description: "Expected replicas (spec) and statusReplicas",
+				},
+			},
+			Required: []string{"apiVersion", "kind", "metadata"},
+		},
+		handler: e.handleScale,
+	})
+
+	labelParams := metadataProperties()
+	labelParams["labels"] = &jsonschema.Schema{
+		Type:        "object",
+		Description: "Labels to add or overwrite; a null value removes the label (e.g., {tier: frontend, legacy: null})",
+	}
+	e.addOperation(operation{
+		name:        "label",
+		description: "Add, overwrite, remove or check labels on Kubernetes resources",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference, selector or list of resources with the labels to change and expect",
+			Properties:  labelParams,
+		},
+		handler: e.handleLabel,
+	})
+
+	annotateParams := metadataProperties()
+	annotateParams["annotations"] = &jsonschema.Schema{
+		Type:        "object",
+		Description: "Annotations to add or overwrite; a null value removes the annotation (e.g., {owner: team-x, legacy: null})",
+	}
+	e.addOperation(operation{
+		name:        "annotate",
+		description: "Add, overwrite, remove or check annotations on Kubernetes resources",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference, selector or list of resources with the annotations to change and expect",
+			Properties:  annotateParams,
+		},
+		handler: e.handleAnnotate,
+	})
+
+	e.addOperation(operation{
+		name:        "removeFinalizers",
+		description: "Remove the finalizers of a Kubernetes resource that is stuck in deletion",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference whose finalizers to remove",
+			Properties: map[string]*jsonschema.Schema{
+				"apiVersion": {
+					Type:        "string",
+					Description: "API version (e.g., v1, example.com/v1)",
+				},
+				"kind": {
+					Type:        "string",
+					Description: "Resource kind (e.g., Namespace, Widget)",
+				},
+				"metadata": {
+					Type:        "object",
+					Description: "Resource metadata (name, namespace)",
+				},
+				"allowUnowned": {
+					Type:        "boolean",
+					Description: "If true, allow removing finalizers from resources not created by mcpchecker",
+				},
+			},
+			Required: []string{"apiVersion", "kind", "metadata"},
+		},
+		handler: e.handleRemoveFinalizers,
+	})
+
+	e.addOperation(operation{
+		name:        "delete",
+		description: "Delete a Kubernetes resource or the resources matching a selector",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Resource reference or selector of the resources to delete",
+			Properties: map[string]*jsonschema.Schema{
+				"apiVersion": {
+					Type:        "string",
+					Description: "API version (e.g., v1, apps/v1)",
+				},
+				"kind": {
+					Type:        "string",
+					Description: "Resource kind (e.g., Pod, Namespace)",
+				},
+				"metadata": {
+					Type:        "object",
+					Description: "Resource metadata (name, namespace)",
+				},
+				"ignoreNotFound": {
+					Type:        "boolean",
+					Description: "If true, do not fail when the resource does not exist",
+				},
+				"selector": {
+					Types:       []string{"string", "object"},
+					Description: "Label selector (e.g., task=foo), or an object with labels and fields selectors, to delete all matching resources of kind instead of metadata.name",
+				},
+				"maxCount": {
+					Type:        "integer",
+					Description: "Maximum number of resources a selector may match; required with selector, nothing is deleted when more match",
+				},
+				"dryRun": {
+					Type:        "boolean",
+					Description: "If true, only report the resources matching the selector without deleting them",
+				},
+				"deleteCollection": {
+					Type:        "boolean",
+					Description: "If true, delete the resources matching the selector with a single delete collection request, which also removes resources that start matching after they were listed. Defaults to deleting exactly the listed resources",
+				},
+				"propagationPolicy": {
+					Type:        "string",
+					Enum:        []any{"Foreground", "Background", "Orphan"},
+					Description: "How dependents are deleted: Foreground waits for them, Background deletes them afterwards, Orphan keeps them. Defaults to Foreground",
+				},
+				"gracePeriodSeconds": {
+					Type:        "integer",
+					Description: "Seconds the resource has to terminate gracefully; 0 deletes immediately. Defaults to the resource's own grace period",
+				},
+				"preconditions": {
+					Type:        "object",
+					Description: "Only delete the resource if it still has this uid and/or resourceVersion",
+					Properties: map[string]*jsonschema.Schema{
+						"uid": {
+							Type:        "string",
+							Description: "UID the resource must have",
+						},
+						"resourceVersion": {
+							Type:        "string",
+							Description: "Resource version the resource must have",
+						},
 					},
-					"namespace": {
-						Type:        "string",
-						Description: "Namespace scope (optional, empty for cluster-wide check)",
+				},
+				"force": {
+					Type:        "boolean",
+					Description: "If true, remove the finalizers of the resource when it is still present after forceAfter",
+				},
+				"forceAfter": {
+					Type:        "string",
+					Description: "How long to wait for the deletion before removing finalizers (e.g., 30s). Defaults to 10s",
+				},
+				"allowUnowned": {
+					Type:        "boolean",
+					Description: "If true, allow removing finalizers from resources not created by mcpchecker",
+				},
+			},
+			Required: []string{"apiVersion", "kind"},
+		},
+		handler: e.handleDelete,
+	})
+
+	e.addOperation(operation{
+		name:        "authCanI",
+		description: "Check if a user or service account can perform an action on a resource",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Permission check parameters",
+			Properties: map[string]*jsonschema.Schema{
+				"verb": {
+					Type:        "string",
+					Description: "Action verb (get, list, create, delete, watch, patch, update, etc.)",
+				},
+				"resource": {
+					Type:        "string",
+					Description: "Resource name (pods, deployments, configmaps, etc.)",
+				},
+				"as": {
+					Type:        "string",
+					Description: "User or service account to impersonate (e.g., alice, system:serviceaccount:ns:sa-name)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace scope (optional, empty for cluster-wide check)",
+				},
+				"apiGroup": {
+					Type:        "string",
+					Description: "API group (optional, empty for core API, e.g., apps, batch, rbac.authorization.k8s.io)",
+				},
+				"resourceName": {
+					Type:        "string",
+					Description: "Specific resource name to check access for (optional)",
+				},
+				"expect": {
+					Type:        "object",
+					Description: "Expected result for inline verification",
+					Properties: map[string]*jsonschema.Schema{
+						"allowed": {
+							Type:        "boolean",
+							Description: "Expected permission result (true for allowed, false for denied)",
+						},
 					},
-					"apiGroup": {
-						Type:        "string",
-						Description: "API group (optional, empty for core API, e.g., apps, batch, rbac.authorization.k8s.io)",
+				},
+			},
+			Required: []string{"verb", "resource", "as"},
+		},
+		handler: e.handleAuthCanI,
+	})
+
+	e.addOperation(operation{
+		name:        "listContexts",
+		description: "List all contexts from kubeconfig",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Optional expectations on the context list",
+			Properties: map[string]*jsonschema.Schema{
+				"expect": {
+					Type:        "object",
+					Description: "Expected result for inline verification",
+					Properties: map[string]*jsonschema.Schema{
+						"current": {
+							Type:        "string",
+							Description: "Expected current context name",
+						},
+						"count": {
+							Type:        "integer",
+							Description: "Expected number of contexts",
+						},
+						"contains": {
+							Type:        "array",
+							Description: "Context names that must exist",
+							Items:       &jsonschema.Schema{Type: "string"},
+						},
 					},
-					"resourceName": {
-						Type:        "string",
-						Description: "Specific resource name to check access for (optional)",
+				},
+			},
+		},
+		handler: e.handleListContexts,
+	})
+
+	e.addOperation(operation{
+		name:        "getCurrentContext",
+		description: "Get the current context from kubeconfig",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Optional expectation on the current context",
+			Properties: map[string]*jsonschema.Schema{
+				"expect": {
+					Type:        "object",
+					Description: "Expected result for inline verification",
+					Properties: map[string]*jsonschema.Schema{
+						"context": {
+							Type:        "string",
+							Description: "Expected current context name",
+						},
 					},
-					"expect": {
-						Type:        "object",
-						Description: "Expected result for inline verification",
-						Properties: map[string]*jsonschema.Schema{
-							"allowed": {
-								Type:        "boolean",
-								Description: "Expected permission result (true for allowed, false for denied)",
-							},
+				},
+			},
+		},
+		handler: e.handleGetCurrentContext,
+	})
+
+	e.addOperation(operation{
+		name:        "getContext",
+		description: "Get a context from kubeconfig with its cluster, user, namespace and server",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Context lookup parameters",
+			Properties: map[string]*jsonschema.Schema{
+				"name": {
+					Type:        "string",
+					Description: "Context name (optional, defaults to the current context)",
+				},
+				"expect": {
+					Type:        "object",
+					Description: "Expected result for inline verification",
+					Properties: map[string]*jsonschema.Schema{
+						"cluster": {
+							Type:        "string",
+							Description: "Expected cluster name",
+						},
+						"user": {
+							Type:        "string",
+							Description: "Expected user name",
+						},
+						"namespace": {
+							Type:        "string",
+							Description: "Expected default namespace",
+						},
+						"server": {
+							Type:        "string",
+							Description: "Expected server URL of the cluster",
+						},
+						"hasCertificateAuthority": {
+							Type:        "boolean",
+							Description: "Whether the cluster is expected to have a CA file or CA data",
+						},
+						"isCurrent": {
+							Type:        "boolean",
+							Description: "Whether the context is expected to be the current context",
 						},
 					},
 				},
-				Required: []string{"verb", "resource", "as"},
-			}),
-		),
-		e.handleAuthCanI,
-	)
+			},
+		},
+		handler: e.handleGetContext,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("listContexts",
-			sdk.WithDescription("List all contexts from kubeconfig"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "No parameters required",
-			}),
-		),
-		e.handleListContexts,
-	)
+	e.addOperation(operation{
+		name:        "viewConfig",
+		description: "View kubeconfig as YAML",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Configuration view options",
+			Properties: map[string]*jsonschema.Schema{
+				"minify": {
+					Type:        "boolean",
+					Description: "If true, only show current context (default: false)",
+				},
+				"flatten": {
+					Type:        "boolean",
+					Description: "If true, inline certificate and key files referenced by the kubeconfig (default: false)",
+				},
+				"raw": {
+					Type:        "boolean",
+					Description: "If true, show tokens, keys, passwords and other credentials instead of redacting them (default: false)",
+				},
+			},
+		},
+		handler: e.handleViewConfig,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("getCurrentContext",
-			sdk.WithDescription("Get the current context from kubeconfig"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "No parameters required",
-			}),
-		),
-		e.handleGetCurrentContext,
-	)
+	e.addOperation(operation{
+		name:        "isolateKubeconfig",
+		description: "Copy the kubeconfig to a temporary file used by this task's kubeconfig operations",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Kubeconfig isolation options",
+			Properties: map[string]*jsonschema.Schema{
+				"context": {
+					Type:        "string",
+					Description: "Context to keep in the copy; implies minify and makes it the current context (optional)",
+				},
+				"minify": {
+					Type:        "boolean",
+					Description: "If true, only keep the current context and its cluster and user (default: false)",
+				},
+			},
+		},
+		handler: e.handleIsolateKubeconfig,
+	})
 
-	e.AddOperation(
-		sdk.NewOperation("viewConfig",
-			sdk.WithDescription("View kubeconfig as YAML"),
-			sdk.WithParams(jsonschema.Schema{
-				Type:        "object",
-				Description: "Configuration view options",
-				Properties: map[string]*jsonschema.Schema{
-					"minify": {
-						Type:        "boolean",
-						Description: "If true, only show current context (default: false)",
-					},
+	e.addOperation(operation{
+		name:        "createNamespace",
+		description: "Create a uniquely named namespace owned by this task and deleted on cleanup",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Namespace generation options",
+			Properties: map[string]*jsonschema.Schema{
+				"prefix": {
+					Type:        "string",
+					Description: "Name prefix followed by a random suffix (default: mcpchecker-)",
 				},
-			}),
-		),
-		e.handleViewConfig,
-	)
+				"generateName": {
+					Type:        "boolean",
+					Description: "If true, let the API server generate the suffix via metadata.generateName (default: false)",
+				},
+				"labels": {
+					Type:        "object",
+					Description: "Additional labels to set on the namespace",
+				},
+				"task": {
+					Type:        "string",
+					Description: "Task name recorded in the mcpchecker.io/task label (default: name of the task directory)",
+				},
+			},
+		},
+		handler: e.handleCreateNamespace,
+	})
+
+	e.addOperation(operation{
+		name:        "gcNamespaces",
+		description: "Delete namespaces owned by mcpchecker that are older than a TTL",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "Garbage collection options",
+			Properties: map[string]*jsonschema.Schema{
+				"ttl": {
+					Type:        "string",
+					Description: "Minimum age of namespaces to delete (e.g., 30m, 2h, default: 24h)",
+				},
+				"dryRun": {
+					Type:        "boolean",
+					Description: "If true, only report the namespaces that would be deleted (default: false)",
+				},
+			},
+		},
+		handler: e.handleGCNamespaces,
+	})
+
+	e.addOperation(operation{
+		name:        "cleanup",
+		description: "Release resources the extension created for this task, such as isolated kubeconfig copies",
+		params: jsonschema.Schema{
+			Type:        "object",
+			Description: "No parameters required",
+			Properties:  map[string]*jsonschema.Schema{},
+		},
+		handler: e.handleCleanup,
+	})
+}
+
+// dataSourceProperties returns the parameters shared by createConfigMap and createSecret.
+func dataSourceProperties() map[string]*jsonschema.Schema {
+	return map[string]*jsonschema.Schema{
+		"metadata": {
+			Type:        "object",
+			Description: "Resource metadata (name or generateName, namespace, labels, annotations)",
+		},
+		"fromFile": {
+			Type:        "array",
+			Description: "Files or directories to read, as path or key=path, relative to the task directory",
+			Items:       &jsonschema.Schema{Type: "string"},
+		},
+		"fromLiteral": {
+			Type:        "array",
+			Description: "Literal entries as key=value",
+			Items:       &jsonschema.Schema{Type: "string"},
+		},
+		"fromEnvFile": {
+			Type:        "array",
+			Description: "Env files with KEY=VALUE lines, relative to the task directory",
+			Items:       &jsonschema.Schema{Type: "string"},
+		},
+		"ifExists": {
+			Type:        "string",
+			Description: "What to do when the resource already exists: fail, ignore, replace or update (default: fail)",
+			Enum:        []any{"fail", "ignore", "replace", "update"},
+		},
+		"timeout": {
+			Type:        "string",
+			Description: "Timeout for the existing resource to be deleted when ifExists is replace (default: 60s)",
+		},
+	}
+}
+
+// metadataProperties returns the parameters shared by label and annotate: the resources
+// to change, the keys to remove and the expectations.
+func metadataProperties() map[string]*jsonschema.Schema {
+	properties := checkProperties()
+	for _, key := range []string{"mode", "minCount", "condition", "status", "jsonPath", "value"} {
+		delete(properties, key)
+	}
+	properties["remove"] = &jsonschema.Schema{
+		Type:        "array",
+		Description: "Keys to remove",
+		Items:       &jsonschema.Schema{Type: "string"},
+	}
+	properties["overwrite"] = &jsonschema.Schema{
+		Type:        "boolean",
+		Description: "Allow changing the value of existing keys (default: false)",
+	}
+	properties["expect"] = &jsonschema.Schema{
+		Type:        "object",
+		Description: "Expectations checked on every resource: values (key to expected value), present and absent (lists of keys)",
+	}
+	return properties
+}
+
+// checkProperties returns the parameters shared by wait and consistently: the resources
+// to observe and the condition or JSONPath check to evaluate on them.
+func checkProperties() map[string]*jsonschema.Schema {
+	return map[string]*jsonschema.Schema{
+		"apiVersion": {
+			Type:        "string",
+			Description: "API version (e.g., v1, apps/v1)",
+		},
+		"kind": {
+			Type:        "string",
+			Description: "Resource kind (e.g., Pod, Deployment)",
+		},
+		"metadata": {
+			Type:        "object",
+			Description: "Resource metadata (name, namespace); only the namespace is used with selector or resources",
+		},
+		"selector": {
+			Types:       []string{"string", "object"},
+			Description: "Label selector (e.g., app=web), or an object with labels and fields selectors, to select all matching resources of kind",
+		},
+		"resources": {
+			Type:        "array",
+			Description: "Resource references (apiVersion, kind, metadata); apiVersion, kind and namespace default to the top-level values",
+			Items:       &jsonschema.Schema{Type: "object"},
+		},
+		"mode": {
+			Type:        "string",
+			Description: "With selector or resources: all resources must meet the condition, or any one (default: all)",
+			Enum:        []any{"all", "any"},
+		},
+		"minCount": {
+			Type:        "integer",
+			Description: "With selector or resources: minimum number of resources that must meet the condition (default: 1)",
+		},
+		"condition": {
+			Type:        "string",
+			Description: "Condition type to check (e.g., Ready, Available)",
+		},
+		"status": {
+			Type:        "string",
+			Description: "Expected condition status (default: True)",
+		},
+		"jsonPath": {
+			Type:        "string",
+			Description: "JSONPath to check instead of a condition (e.g., {.status.phase})",
+		},
+		"value": {
+			Description: "Expected value at jsonPath; without it, any non-empty value passes",
+		},
+	}
 }
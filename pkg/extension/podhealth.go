@@ -0,0 +1,435 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var podGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// unhealthyWaitingReasons are reasons of waiting containers that will not resolve by themselves.
+var unhealthyWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// unhealthyTerminationReasons are termination reasons that mark a container unhealthy
+// even after it was restarted.
+var unhealthyTerminationReasons = map[string]bool{
+	"OOMKilled":          true,
+	"ContainerCannotRun": true,
+}
+
+// ownerAPIVersions are the default API versions of workloads podHealth can select pods by.
+var ownerAPIVersions = map[string]string{
+	"Deployment":  "apps/v1",
+	"StatefulSet": "apps/v1",
+	"DaemonSet":   "apps/v1",
+	"ReplicaSet":  "apps/v1",
+	"Job":         "batch/v1",
+}
+
+// podHealthOptions holds what podHealth checks on every container.
+type podHealthOptions struct {
+	// maxRestarts is the highest restart count allowed, or -1 for no limit.
+	maxRestarts int64
+	// images maps container names to their expected image.
+	images map[string]string
+}
+
+// containerIssue is a problem found on one container of a pod.
+type containerIssue struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Problem   string `json:"problem"`
+	LastState string `json:"lastState,omitempty"`
+}
+
+// String renders the issue for failure messages.
+func (i containerIssue) String() string {
+	s := i.Pod
+	if i.Container != "" {
+		s += " container " + i.Container
+	}
+	s += ": " + i.Problem
+	if i.LastState != "" {
+		s += "; last state: " + i.LastState
+	}
+	return s
+}
+
+// handlePodHealth checks that pods, selected by name, label selector or owning workload,
+// are healthy: all containers ready, few enough restarts, no crash-looping, image pull
+// or OOM problems, and optionally the expected images.
+func (e *Extension) handlePodHealth(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	// Containers of a healthy pod do not restart, so any restart fails by default
+	opts := podHealthOptions{maxRestarts: 0}
+	if value, ok := args["maxRestarts"]; ok {
+		n, ok := toInt64(value)
+		if !ok || n < -1 {
+			return failure(fmt.Errorf("maxRestarts must be a non-negative integer, or -1 for no limit")), nil
+		}
+		opts.maxRestarts = n
+	}
+	if images, ok := args["images"].(map[string]any); ok {
+		opts.images = make(map[string]string, len(images))
+		for name, image := range images {
+			str, ok := image.(string)
+			if !ok {
+				return failure(fmt.Errorf("images.%s must be a string", name)), nil
+			}
+			opts.images[name] = str
+		}
+	}
+
+	minPods := int64(1)
+	if value, ok := args["minPods"]; ok {
+		n, ok := toInt64(value)
+		if !ok || n < 0 {
+			return failure(fmt.Errorf("minPods must be a non-negative integer")), nil
+		}
+		minPods = n
+	}
+
+	timeoutStr, _ := args["timeout"].(string)
+	timeout := time.Duration(0)
+	if timeoutStr != "" {
+		var err error
+		timeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+		}
+	}
+
+	namespace, name := "", ""
+	if metadata, ok := args["metadata"].(map[string]any); ok {
+		namespace, _ = metadata["namespace"].(string)
+		name, _ = metadata["name"].(string)
+	}
+
+	selector, _ := args["selector"].(string)
+	owner, _ := args["owner"].(map[string]any)
+	sources := 0
+	for _, set := range []bool{name != "", selector != "", owner != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return failure(fmt.Errorf("exactly one of metadata.name, selector or owner is required")), nil
+	}
+
+	e.LogInfo(ctx, "Checking pod health", map[string]any{
+		"namespace":   namespace,
+		"name":        name,
+		"selector":    selector,
+		"maxRestarts": opts.maxRestarts,
+		"timeout":     timeoutStr,
+	})
+
+	var pods []corev1.Pod
+	var issues []containerIssue
+	var checkErr error
+	poll := func(ctx context.Context) (bool, error) {
+		pods, checkErr = e.selectPods(ctx, namespace, name, selector, owner)
+		if checkErr != nil {
+			// Keep polling through errors that may resolve, such as a pod not created yet
+			if isPermanentError(checkErr) {
+				return false, checkErr
+			}
+			return false, nil
+		}
+		issues = nil
+		if int64(len(pods)) < minPods {
+			issues = append(issues, containerIssue{Problem: fmt.Sprintf("found %d pod(s), want at least %d", len(pods), minPods)})
+		}
+		for i := range pods {
+			issues = append(issues, checkPod(&pods[i], opts)...)
+		}
+		return len(issues) == 0, nil
+	}
+
+	if timeout > 0 {
+		_ = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, poll)
+	} else {
+		_, _ = poll(ctx)
+	}
+
+	if checkErr != nil {
+		e.LogError(ctx, "Failed to select pods", map[string]any{
+			"error": checkErr.Error(),
+		})
+		return failure(checkErr), nil
+	}
+
+	if len(issues) > 0 {
+		lines := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			lines = append(lines, issue.String())
+		}
+		e.LogError(ctx, "Pods unhealthy", map[string]any{
+			"pods":   len(pods),
+			"issues": len(issues),
+		})
+
+		issuesJSON, _ := json.Marshal(issues)
+		result := failureWithCategory(categoryAgent,
+			fmt.Sprintf("%d problem(s) found on %d pod(s)", len(issues), len(pods)),
+			errors.New(strings.Join(lines, "\n")),
+		)
+		result.Outputs["pods"] = fmt.Sprintf("%d", len(pods))
+		result.Outputs["issues"] = string(issuesJSON)
+		return result, nil
+	}
+
+	e.LogInfo(ctx, "Pods healthy", map[string]any{
+		"pods": len(pods),
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%d pod(s) healthy", len(pods)),
+		map[string]string{
+			"pods":   fmt.Sprintf("%d", len(pods)),
+			"issues": "[]",
+		},
+	), nil
+}
+
+// selectPods returns the pods selected by name, by label selector, or by the selector
+// of an owning workload.
+func (e *Extension) selectPods(ctx context.Context, namespace, name, selector string, owner map[string]any) ([]corev1.Pod, error) {
+	if name != "" {
+		obj, err := e.client.Get(ctx, podGVR, name, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s: %w", name, err)
+		}
+		pod, err := toPod(obj)
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+
+	if owner != nil {
+		ownerSelector, err := e.workloadSelector(ctx, namespace, owner)
+		if err != nil {
+			return nil, err
+		}
+		selector = ownerSelector
+	}
+
+	list, err := e.client.List(ctx, podGVR, namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pods := make([]corev1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		// Pods being terminated, such as those of an old ReplicaSet during a rollout, are gone soon
+		if list.Items[i].GetDeletionTimestamp() != nil {
+			continue
+		}
+		pod, err := toPod(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, *pod)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// workloadSelector returns the pod label selector of the workload described by owner.
+func (e *Extension) workloadSelector(ctx context.Context, namespace string, owner map[string]any) (string, error) {
+	kind, _ := owner["kind"].(string)
+	name, _ := owner["name"].(string)
+	if kind == "" || name == "" {
+		return "", fmt.Errorf("owner.kind and owner.name are required")
+	}
+	apiVersion, _ := owner["apiVersion"].(string)
+	if apiVersion == "" {
+		apiVersion = ownerAPIVersions[kind]
+	}
+	if apiVersion == "" {
+		return "", fmt.Errorf("owner.apiVersion is required for kind %s", kind)
+	}
+
+	gvr, err := e.resourceFor(&resourceRef{apiVersion: apiVersion, kind: kind, name: name})
+	if err != nil {
+		return "", err
+	}
+	workload, err := e.client.Get(ctx, gvr, name, namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+
+	raw, found, err := unstructured.NestedMap(workload.Object, "spec", "selector")
+	if err != nil || !found {
+		return "", fmt.Errorf("%s %s has no spec.selector", kind, name)
+	}
+	var labelSelector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &labelSelector); err != nil {
+		return "", fmt.Errorf("invalid selector of %s %s: %w", kind, name, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector of %s %s: %w", kind, name, err)
+	}
+	return selector.String(), nil
+}
+
+// toPod converts an unstructured pod to its typed form.
+func toPod(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+	var pod corev1.Pod
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+		return nil, fmt.Errorf("failed to read pod %s: %w", obj.GetName(), err)
+	}
+	return &pod, nil
+}
+
+// checkPod returns the problems found on the containers of pod.
+func checkPod(pod *corev1.Pod, opts podHealthOptions) []containerIssue {
+	name := pod.Namespace + "/" + pod.Name
+	var issues []containerIssue
+
+	if pod.Status.Phase == corev1.PodFailed {
+		issues = append(issues, containerIssue{Pod: name, Problem: fmt.Sprintf("pod failed (%s)", pod.Status.Reason)})
+	}
+	// Completed pods, such as those of Jobs, are not expected to be ready
+	completed := pod.Status.Phase == corev1.PodSucceeded
+
+	for _, container := range pod.Spec.Containers {
+		if expected, ok := opts.images[container.Name]; ok && container.Image != expected {
+			issues = append(issues, containerIssue{
+				Pod:       name,
+				Container: container.Name,
+				Problem:   fmt.Sprintf("image is %s, want %s", container.Image, expected),
+			})
+		}
+	}
+	for container := range opts.images {
+		if !hasContainer(pod, container) {
+			issues = append(issues, containerIssue{Pod: name, Container: container, Problem: "container not found"})
+		}
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+	for _, container := range pod.Spec.Containers {
+		status, ok := statuses[container.Name]
+		if !ok {
+			issues = append(issues, containerIssue{Pod: name, Container: container.Name, Problem: "no status reported"})
+			continue
+		}
+		var problems []string
+		if !status.Ready && !completed {
+			problems = append(problems, "not ready")
+		}
+		problems = append(problems, containerProblems(status, opts)...)
+		if len(problems) > 0 {
+			issues = append(issues, containerIssue{
+				Pod:       name,
+				Container: container.Name,
+				Problem:   strings.Join(problems, ", "),
+				LastState: describeContainerState(status),
+			})
+		}
+	}
+
+	// Init containers have finished once the pod runs, so only their failures count
+	for _, status := range pod.Status.InitContainerStatuses {
+		if problems := containerProblems(status, opts); len(problems) > 0 {
+			issues = append(issues, containerIssue{
+				Pod:       name,
+				Container: status.Name + " (init)",
+				Problem:   strings.Join(problems, ", "),
+				LastState: describeContainerState(status),
+			})
+		}
+	}
+
+	return issues
+}
+
+// containerProblems returns the restart, waiting and termination problems of a container.
+func containerProblems(status corev1.ContainerStatus, opts podHealthOptions) []string {
+	var problems []string
+	if opts.maxRestarts >= 0 && int64(status.RestartCount) > opts.maxRestarts {
+		problems = append(problems, fmt.Sprintf("restarted %d times (max %d)", status.RestartCount, opts.maxRestarts))
+	}
+	if waiting := status.State.Waiting; waiting != nil && unhealthyWaitingReasons[waiting.Reason] {
+		problems = append(problems, waiting.Reason)
+	}
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		problems = append(problems, fmt.Sprintf("terminated with exit code %d (%s)", terminated.ExitCode, terminated.Reason))
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && unhealthyTerminationReasons[terminated.Reason] {
+		problems = append(problems, "previously "+terminated.Reason)
+	}
+	return problems
+}
+
+// describeContainerState renders the current state of a container, and the last
+// termination if the container was restarted.
+func describeContainerState(status corev1.ContainerStatus) string {
+	describe := func(state corev1.ContainerState) string {
+		switch {
+		case state.Running != nil:
+			return "running"
+		case state.Waiting != nil:
+			if state.Waiting.Message != "" {
+				return fmt.Sprintf("waiting (%s: %s)", state.Waiting.Reason, state.Waiting.Message)
+			}
+			return fmt.Sprintf("waiting (%s)", state.Waiting.Reason)
+		case state.Terminated != nil:
+			return fmt.Sprintf("terminated (%s, exit code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+		default:
+			return "unknown"
+		}
+	}
+
+	s := describe(status.State)
+	if status.LastTerminationState.Terminated != nil {
+		s += ", previously " + describe(status.LastTerminationState)
+	}
+	return s
+}
+
+// hasContainer reports whether pod has a container with the given name.
+func hasContainer(pod *corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
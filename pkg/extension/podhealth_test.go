@@ -0,0 +1,243 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// healthPod returns a running pod in default with one container web running nginx:1.27
+// and the given container status fields.
+func healthPod(name string, status map[string]any) unstructured.Unstructured {
+	containerStatus := map[string]any{
+		"name":         "web",
+		"ready":        true,
+		"restartCount": int64(0),
+		"state":        map[string]any{"running": map[string]any{}},
+	}
+	for key, value := range status {
+		containerStatus[key] = value
+	}
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": "default", "labels": map[string]any{"app": "web"}},
+		"spec": map[string]any{
+			"containers": []any{map[string]any{"name": "web", "image": "nginx:1.27"}},
+		},
+		"status": map[string]any{
+			"phase":             "Running",
+			"containerStatuses": []any{containerStatus},
+		},
+	}}
+}
+
+// terminatingPod marks pod as being deleted.
+func terminatingPod(pod unstructured.Unstructured) unstructured.Unstructured {
+	now := metav1.Now()
+	pod.SetDeletionTimestamp(&now)
+	return pod
+}
+
+func TestHandlePodHealth(t *testing.T) {
+	crashLooping := map[string]any{
+		"ready":        false,
+		"restartCount": int64(5),
+		"state": map[string]any{"waiting": map[string]any{
+			"reason":  "CrashLoopBackOff",
+			"message": "back-off 40s",
+		}},
+		"lastState": map[string]any{"terminated": map[string]any{"reason": "Error", "exitCode": int64(1)}},
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		client       *mockClient
+		wantSuccess  bool
+		wantErrors   []string
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name:        "healthy pods by selector",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default"}, "selector": "app=web"},
+			client:      &mockClient{listFn: listPods(healthPod("web-1", nil), healthPod("web-2", nil))},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"pods": "2", "issues": "[]"},
+		},
+		{
+			name:        "crash looping container",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default"}, "selector": "app=web"},
+			client:      &mockClient{listFn: listPods(healthPod("web-1", nil), healthPod("web-2", crashLooping))},
+			wantSuccess: false,
+			wantErrors: []string{
+				"default/web-2 container web: not ready, restarted 5 times (max 0), CrashLoopBackOff; last state: waiting (CrashLoopBackOff: back-off 40s), previously terminated (Error, exit code 1)",
+			},
+			wantOutputs:  map[string]string{"pods": "2"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:        "too many restarts",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}, "maxRestarts": float64(2)},
+			client:      &mockClient{getFn: getPod(healthPod("web-1", map[string]any{"restartCount": int64(3)}))},
+			wantSuccess: false,
+			wantErrors:  []string{"restarted 3 times (max 2)"},
+		},
+		{
+			name:        "any restart fails by default",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}},
+			client:      &mockClient{getFn: getPod(healthPod("web-1", map[string]any{"restartCount": int64(20)}))},
+			wantSuccess: false,
+			wantErrors:  []string{"restarted 20 times (max 0)"},
+		},
+		{
+			name:        "restart limit disabled",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}, "maxRestarts": float64(-1)},
+			client:      &mockClient{getFn: getPod(healthPod("web-1", map[string]any{"restartCount": int64(20)}))},
+			wantSuccess: true,
+		},
+		{
+			name:        "terminating pods skipped",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default"}, "selector": "app=web"},
+			client:      &mockClient{listFn: listPods(healthPod("web-1", nil), terminatingPod(healthPod("web-old", crashLooping)))},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"pods": "1", "issues": "[]"},
+		},
+		{
+			name:        "restarts within limit",
+			args:        map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}, "maxRestarts": float64(3)},
+			client:      &mockClient{getFn: getPod(healthPod("web-1", map[string]any{"restartCount": int64(3)}))},
+			wantSuccess: true,
+		},
+		{
+			name: "previously OOM killed",
+			args: map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}},
+			client: &mockClient{getFn: getPod(healthPod("web-1", map[string]any{
+				"restartCount": int64(1),
+				"lastState":    map[string]any{"terminated": map[string]any{"reason": "OOMKilled", "exitCode": int64(137)}},
+			}))},
+			wantSuccess: false,
+			wantErrors:  []string{"previously OOMKilled", "terminated (OOMKilled, exit code 137)"},
+		},
+		{
+			name: "image pull failure",
+			args: map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}},
+			client: &mockClient{getFn: getPod(healthPod("web-1", map[string]any{
+				"ready": false,
+				"state": map[string]any{"waiting": map[string]any{"reason": "ImagePullBackOff"}},
+			}))},
+			wantSuccess: false,
+			wantErrors:  []string{"not ready, ImagePullBackOff; last state: waiting (ImagePullBackOff)"},
+		},
+		{
+			name: "unexpected image",
+			args: map[string]any{
+				"metadata": map[string]any{"namespace": "default", "name": "web-1"},
+				"images":   map[string]any{"web": "nginx:1.28", "sidecar": "envoy:1.30"},
+			},
+			client:      &mockClient{getFn: getPod(healthPod("web-1", nil))},
+			wantSuccess: false,
+			wantErrors:  []string{"container web: image is nginx:1.27, want nginx:1.28", "container sidecar: container not found"},
+		},
+		{
+			name: "pods of owning deployment",
+			args: map[string]any{
+				"metadata": map[string]any{"namespace": "default"},
+				"owner":    map[string]any{"kind": "Deployment", "name": "web"},
+			},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					if gvr.Resource != "deployments" || name != "web" {
+						return nil, fmt.Errorf("unexpected get of %s %s", gvr.Resource, name)
+					}
+					return &unstructured.Unstructured{Object: map[string]any{
+						"spec": map[string]any{"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}}},
+					}}, nil
+				},
+				listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+					if opts.LabelSelector != "app=web" {
+						return nil, fmt.Errorf("unexpected selector %q", opts.LabelSelector)
+					}
+					return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{healthPod("web-1", nil)}}, nil
+				},
+			},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"pods": "1"},
+		},
+		{
+			name:         "no pods selected",
+			args:         map[string]any{"metadata": map[string]any{"namespace": "default"}, "selector": "app=web"},
+			client:       &mockClient{listFn: listPods()},
+			wantSuccess:  false,
+			wantErrors:   []string{"found 0 pod(s), want at least 1"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "missing pod",
+			args: map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+				},
+			},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "name and selector combined",
+			args:         map[string]any{"metadata": map[string]any{"namespace": "default", "name": "web-1"}, "selector": "app=web"},
+			client:       &mockClient{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handlePodHealth(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handlePodHealth() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handlePodHealth() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+// getPod returns a Get function serving pod.
+func getPod(pod unstructured.Unstructured) func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+		return &pod, nil
+	}
+}
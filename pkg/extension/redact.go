@@ -0,0 +1,164 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// redactedValue replaces secret strings, matching `kubectl config view`.
+const redactedValue = "REDACTED"
+
+// sensitiveKeys lists field names whose values are credentials, normalized to
+// lower case without dashes or underscores. It covers kubeconfig fields and the
+// configuration keys of auth provider plugins.
+var sensitiveKeys = map[string]bool{
+	"token":                 true,
+	"bearertoken":           true,
+	"accesstoken":           true,
+	"refreshtoken":          true,
+	"idtoken":               true,
+	"password":              true,
+	"clientsecret":          true,
+	"secret":                true,
+	"clientkeydata":         true,
+	"clientcertificatedata": true,
+}
+
+// isSensitiveKey reports whether a field with the given name holds credentials.
+func isSensitiveKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+	return sensitiveKeys[normalized]
+}
+
+// redactConfig replaces credentials in config in place, like `kubectl config view`
+// without --raw: certificate and key data become DATA+OMITTED, while tokens,
+// passwords, exec environment values and auth provider secrets become REDACTED.
+func redactConfig(config *clientcmdapi.Config) {
+	clientcmdapi.ShortenConfig(config)
+
+	for _, authInfo := range config.AuthInfos {
+		if authInfo.Password != "" {
+			authInfo.Password = redactedValue
+		}
+		if authInfo.Exec != nil {
+			for i := range authInfo.Exec.Env {
+				authInfo.Exec.Env[i].Value = redactedValue
+			}
+		}
+		if authInfo.AuthProvider != nil {
+			for key := range authInfo.AuthProvider.Config {
+				if isSensitiveKey(key) {
+					authInfo.AuthProvider.Config[key] = redactedValue
+				}
+			}
+		}
+	}
+}
+
+// redactLogData returns a copy of log data with the values of credential fields
+// replaced, so secrets never reach the mcpchecker log stream.
+func redactLogData(data map[string]any) map[string]any {
+	if data == nil {
+		return nil
+	}
+	redacted := make(map[string]any, len(data))
+	for key, value := range data {
+		if isSensitiveKey(key) {
+			redacted[key] = redactedValue
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			redacted[key] = redactLogData(nested)
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// LogDebug sends a debug log message with credentials in data redacted.
+func (e *Extension) LogDebug(ctx context.Context, message string, data map[string]any) error {
+	return e.log(ctx, "debug", message, redactLogData(data))
+}
+
+// LogInfo sends an info log message with credentials in data redacted.
+func (e *Extension) LogInfo(ctx context.Context, message string, data map[string]any) error {
+	return e.log(ctx, "info", message, redactLogData(data))
+}
+
+// LogWarn sends a warning log message with credentials in data redacted.
+func (e *Extension) LogWarn(ctx context.Context, message string, data map[string]any) error {
+	return e.log(ctx, "warn", message, redactLogData(data))
+}
+
+// LogError sends an error log message with credentials in data redacted.
+func (e *Extension) LogError(ctx context.Context, message string, data map[string]any) error {
+	return e.log(ctx, "error", message, redactLogData(data))
+}
+
+// isSecrets reports whether gvr is the resource of Secrets.
+func isSecrets(gvr schema.GroupVersionResource) bool {
+	return gvr.Group == "" && gvr.Resource == "secrets"
+}
+
+// redactSecret replaces the values of the data and stringData of a Secret, or of a
+// patch to one, in place.
+func redactSecret(content map[string]any) {
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := content[field].(map[string]any)
+		if !ok {
+			continue
+		}
+		for key, value := range values {
+			if value != nil {
+				values[key] = redactedValue
+			}
+		}
+	}
+}
+
+// redactSecretPatch returns a merge or JSON patch to a Secret with the data it sets
+// redacted.
+func redactSecretPatch(data []byte) json.RawMessage {
+	var patch any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil
+	}
+	switch p := patch.(type) {
+	case map[string]any:
+		redactSecret(p)
+	case []any:
+		for _, op := range p {
+			op, ok := op.(map[string]any)
+			if !ok {
+				continue
+			}
+			path, _ := op["path"].(string)
+			if _, ok := op["value"]; ok && (strings.HasPrefix(path, "/data") || strings.HasPrefix(path, "/stringData")) {
+				op["value"] = redactedValue
+			}
+		}
+	}
+	redacted, _ := json.Marshal(patch)
+	return redacted
+}
+
+// redactKubeconfig redacts the credentials of a rendered kubeconfig, like viewConfig
+// without raw. A kubeconfig that cannot be parsed is replaced entirely.
+func redactKubeconfig(text string) string {
+	config, err := clientcmd.Load([]byte(text))
+	if err != nil {
+		return redactedValue
+	}
+	redactConfig(config)
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return redactedValue
+	}
+	return string(data)
+}
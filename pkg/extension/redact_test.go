@@ -0,0 +1,136 @@
+package extension
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestViewConfigRedaction(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client.key"), []byte("key-file-secret"), 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: admin
+clusters:
+- name: cluster
+  cluster:
+    server: https://example.com
+    certificate-authority-data: Y2EtZGF0YQ==
+contexts:
+- name: admin
+  context:
+    cluster: cluster
+    user: admin
+- name: exec
+  context:
+    cluster: cluster
+    user: exec-user
+users:
+- name: admin
+  user:
+    token: bearer-secret
+    username: admin
+    password: password-secret
+    client-key: client.key
+- name: exec-user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: get-token
+      env:
+      - name: API_KEY
+        value: exec-env-secret
+`
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	client := &dynamicClientAdapter{kubeconfigPath: path}
+	secrets := []string{"bearer-secret", "password-secret", "exec-env-secret", "key-file-secret", "a2V5LWZpbGUtc2VjcmV0"}
+
+	tests := []struct {
+		name        string
+		opts        ViewConfigOptions
+		wantPresent []string
+		wantAbsent  []string
+	}{
+		{
+			name:        "redacted by default",
+			opts:        ViewConfigOptions{},
+			wantPresent: []string{"REDACTED", "DATA+OMITTED", "client.key", "username: admin"},
+			wantAbsent:  secrets,
+		},
+		{
+			name:        "flattened content is redacted",
+			opts:        ViewConfigOptions{Flatten: true},
+			wantPresent: []string{"client-key-data: DATA+OMITTED"},
+			wantAbsent:  secrets,
+		},
+		{
+			name:        "raw shows credentials",
+			opts:        ViewConfigOptions{Raw: true},
+			wantPresent: []string{"bearer-secret", "password-secret", "exec-env-secret", "client.key"},
+			wantAbsent:  []string{"REDACTED"},
+		},
+		{
+			name:        "raw and flatten inline files",
+			opts:        ViewConfigOptions{Raw: true, Flatten: true},
+			wantPresent: []string{"client-key-data: a2V5LWZpbGUtc2VjcmV0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := client.ViewConfig(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("ViewConfig() returned error: %v", err)
+			}
+			for _, want := range tt.wantPresent {
+				if !strings.Contains(out, want) {
+					t.Errorf("ViewConfig() output missing %q:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range tt.wantAbsent {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("ViewConfig() output contains %q:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactLogData(t *testing.T) {
+	data := map[string]any{
+		"name":  "admin",
+		"token": "bearer-secret",
+		"user": map[string]any{
+			"client-key-data": "key",
+			"password":        "pw",
+			"username":        "admin",
+		},
+	}
+
+	got := redactLogData(data)
+
+	if got["name"] != "admin" {
+		t.Errorf("redactLogData() name = %v, want admin", got["name"])
+	}
+	if got["token"] != redactedValue {
+		t.Errorf("redactLogData() token = %v, want %s", got["token"], redactedValue)
+	}
+	user := got["user"].(map[string]any)
+	if user["client-key-data"] != redactedValue || user["password"] != redactedValue {
+		t.Errorf("redactLogData() nested credentials not redacted: %v", user)
+	}
+	if user["username"] != "admin" {
+		t.Errorf("redactLogData() username = %v, want admin", user["username"])
+	}
+	if data["token"] != "bearer-secret" {
+		t.Errorf("redactLogData() modified its input")
+	}
+}
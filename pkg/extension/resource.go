@@ -5,6 +5,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // resourceRef holds parsed resource reference information from operation arguments.
@@ -13,6 +14,7 @@ type resourceRef struct {
 	kind       string
 	name       string
 	namespace  string
+	uid        types.UID // set for resources resolved from a list
 }
 
 // parseResourceRef extracts resource reference info from operation arguments.
@@ -0,0 +1,278 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// retryPolicy controls how mutating API calls are retried on transient errors.
+type retryPolicy struct {
+	// attempts is the total number of tries, including the first one.
+	attempts int
+	// backoff is the delay before the first retry. It doubles with every retry.
+	backoff time.Duration
+	// maxBackoff caps the delay between retries.
+	maxBackoff time.Duration
+	// jitter adds up to this fraction of the delay at random.
+	jitter float64
+}
+
+// defaultRetryPolicy is used when the extension config has no retry section.
+var defaultRetryPolicy = retryPolicy{
+	attempts:   5,
+	backoff:    200 * time.Millisecond,
+	maxBackoff: 5 * time.Second,
+	jitter:     0.2,
+}
+
+// parseRetryPolicy reads the retry section of the extension config.
+func parseRetryPolicy(config any) (retryPolicy, error) {
+	policy := defaultRetryPolicy
+	if config == nil {
+		return policy, nil
+	}
+
+	settings, ok := config.(map[string]any)
+	if !ok {
+		return policy, fmt.Errorf("retry must be an object")
+	}
+
+	if value, ok := settings["attempts"]; ok {
+		attempts, ok := toInt64(value)
+		if !ok || attempts < 1 {
+			return policy, fmt.Errorf("retry.attempts must be a positive integer")
+		}
+		policy.attempts = int(attempts)
+	}
+
+	for key, target := range map[string]*time.Duration{
+		"backoff":    &policy.backoff,
+		"maxBackoff": &policy.maxBackoff,
+	} {
+		value, ok := settings[key].(string)
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid retry.%s: %w", key, err)
+		}
+		*target = duration
+	}
+
+	if value, ok := settings["jitter"].(float64); ok {
+		if value < 0 {
+			return policy, fmt.Errorf("retry.jitter must not be negative")
+		}
+		policy.jitter = value
+	}
+
+	return policy, nil
+}
+
+// isRetryable reports whether err is a transient error worth retrying.
+// Errors caused by the request itself, such as Invalid or Forbidden, are not.
+func isRetryable(err error) bool {
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsConflict(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsInternalError(err):
+		return true
+	}
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err means the connection to the API server dropped.
+func isConnectionError(err error) bool {
+	return utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isCreateRetryable reports whether a failed create is safe to retry. A create is not
+// idempotent and may have been applied when the request timed out or the connection
+// dropped, so only errors proving it was not applied qualify: throttling, and a
+// connection refused before the request was sent.
+func isCreateRetryable(err error) bool {
+	return apierrors.IsTooManyRequests(err) || utilnet.IsConnectionRefused(err)
+}
+
+// retryingClient decorates a ResourceClient, retrying mutating calls that fail with
+// transient errors. Reads are not retried, since their callers poll anyway.
+type retryingClient struct {
+	ResourceClient
+	policy retryPolicy
+	// onRetry is called before every retry with the number and error of the failed attempt.
+	onRetry func(ctx context.Context, call string, attempt int, err error)
+}
+
+// newRetryingClient wraps client with policy.
+func newRetryingClient(client ResourceClient, policy retryPolicy, onRetry func(ctx context.Context, call string, attempt int, err error)) *retryingClient {
+	return &retryingClient{ResourceClient: client, policy: policy, onRetry: onRetry}
+}
+
+// do runs fn, retrying it while it fails with an error accepted by retryable.
+func (c *retryingClient) do(ctx context.Context, call string, retryable func(error) bool, fn func() error) error {
+	counter := retryCounterFrom(ctx)
+	if counter != nil {
+		counter.calls.Add(1)
+	}
+
+	delay := c.policy.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.policy.attempts || !retryable(err) {
+			return err
+		}
+
+		if c.onRetry != nil {
+			c.onRetry(ctx, call, attempt, err)
+		}
+		if counter != nil {
+			counter.retries.Add(1)
+		}
+
+		timer := time.NewTimer(wait.Jitter(delay, c.policy.jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if c.policy.maxBackoff > 0 && delay > c.policy.maxBackoff {
+			delay = c.policy.maxBackoff
+		}
+	}
+}
+
+// Create is never retried for generated names, as every attempt that reaches the API
+// server creates another object. Other creates are only retried when the failed attempt
+// was certainly not applied, so a retry that finds the object already existing reports
+// it, leaving the caller's ifExists policy to decide.
+func (c *retryingClient) Create(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	retryable := isCreateRetryable
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		retryable = func(error) bool { return false }
+	}
+
+	var result *unstructured.Unstructured
+	err := c.do(ctx, "create", retryable, func() error {
+		var err error
+		result, err = c.ResourceClient.Create(ctx, gvr, obj, namespace)
+		return err
+	})
+	return result, err
+}
+
+// Update does not retry conflicts: the object carries a stale resourceVersion, so
+// the caller has to read it again, as updateObject does.
+func (c *retryingClient) Update(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+	var result *unstructured.Unstructured
+	err := c.do(ctx, "update", func(err error) bool {
+		return isRetryable(err) && !apierrors.IsConflict(err)
+	}, func() error {
+		var err error
+		result, err = c.ResourceClient.Update(ctx, gvr, obj, namespace, subresources...)
+		return err
+	})
+	return result, err
+}
+
+// Patch retries conflicts too: merge patches without a resourceVersion apply to the
+// current object, so a conflict only means it changed while the patch was applied.
+func (c *retryingClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	var result *unstructured.Unstructured
+	err := c.do(ctx, "patch", isRetryable, func() error {
+		var err error
+		result, err = c.ResourceClient.Patch(ctx, gvr, name, namespace, patchType, data, subresources...)
+		return err
+	})
+	return result, err
+}
+
+// Delete does not retry conflicts when preconditions are set: the API server reports
+// an object that no longer matches them as a conflict. A retry that finds the object
+// gone succeeds, as the failed attempt may have deleted it after all.
+func (c *retryingClient) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+	attempts := 0
+	return c.do(ctx, "delete", func(err error) bool {
+		return isRetryable(err) && (opts.Preconditions == nil || !apierrors.IsConflict(err))
+	}, func() error {
+		attempts++
+		err := c.ResourceClient.Delete(ctx, gvr, name, namespace, opts)
+		if attempts > 1 && apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (c *retryingClient) DeleteCollection(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	return c.do(ctx, "deletecollection", isRetryable, func() error {
+		return c.ResourceClient.DeleteCollection(ctx, gvr, namespace, opts, listOpts)
+	})
+}
+
+func (c *retryingClient) WithKubeconfig(path string) ResourceClient {
+	return newRetryingClient(c.ResourceClient.WithKubeconfig(path), c.policy, c.onRetry)
+}
+
+// retryCounter counts the mutating calls of one operation and how often they were retried.
+type retryCounter struct {
+	calls   atomic.Int64
+	retries atomic.Int64
+}
+
+type retryCounterKey struct{}
+
+// retryCounterFrom returns the counter of the operation running with ctx, if any.
+func retryCounterFrom(ctx context.Context) *retryCounter {
+	counter, _ := ctx.Value(retryCounterKey{}).(*retryCounter)
+	return counter
+}
+
+// withRetryCount wraps handler so that operations making mutating API calls report
+// how often those calls were retried in a retries output.
+func withRetryCount(handler sdk.OperationHandler) sdk.OperationHandler {
+	return func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		counter := &retryCounter{}
+		result, err := handler(context.WithValue(ctx, retryCounterKey{}, counter), req)
+		if err != nil || result == nil || counter.calls.Load() == 0 {
+			return result, err
+		}
+
+		if result.Outputs == nil {
+			result.Outputs = make(map[string]string)
+		}
+		result.Outputs["retries"] = strconv.FormatInt(counter.retries.Load(), 10)
+		return result, err
+	}
+}
+
+// logRetry reports a retried API call.
+func (e *Extension) logRetry(ctx context.Context, call string, attempt int, err error) {
+	e.LogWarn(ctx, "Retrying after transient API error", map[string]any{
+		"call":    call,
+		"attempt": attempt,
+		"error":   err.Error(),
+	})
+}
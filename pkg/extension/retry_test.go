@@ -0,0 +1,374 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testRetryPolicy retries quickly so tests do not wait for real backoff.
+var testRetryPolicy = retryPolicy{attempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+func TestParseRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  any
+		want    retryPolicy
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: nil,
+			want:   defaultRetryPolicy,
+		},
+		{
+			name:   "custom values",
+			config: map[string]any{"attempts": float64(2), "backoff": "1s", "maxBackoff": "10s", "jitter": 0.5},
+			want:   retryPolicy{attempts: 2, backoff: time.Second, maxBackoff: 10 * time.Second, jitter: 0.5},
+		},
+		{
+			name:    "zero attempts",
+			config:  map[string]any{"attempts": float64(0)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid backoff",
+			config:  map[string]any{"backoff": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			config:  "always",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRetryPolicy(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRetryPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseRetryPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	gr := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1), want: true},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "create", 1), want: true},
+		{name: "timeout", err: apierrors.NewTimeoutError("etcd", 1), want: true},
+		{name: "conflict", err: apierrors.NewConflict(gr, "p", fmt.Errorf("changed")), want: true},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("down"), want: true},
+		{name: "internal error", err: apierrors.NewInternalError(fmt.Errorf("boom")), want: true},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "invalid", err: apierrors.NewBadRequest("bad manifest"), want: false},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "p", fmt.Errorf("denied")), want: false},
+		{name: "not found", err: apierrors.NewNotFound(gr, "p"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// connectionRefused is the error of a request that never reached the API server.
+var connectionRefused = &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+func TestRetryingClientCreate(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+	tests := []struct {
+		name        string
+		errs        []error
+		wantSuccess bool
+		wantCalls   int
+		wantRetries string
+	}{
+		{
+			name:        "succeeds first time",
+			wantSuccess: true,
+			wantCalls:   1,
+			wantRetries: "0",
+		},
+		{
+			name:        "recovers from throttling and refused connections",
+			errs:        []error{apierrors.NewTooManyRequests("slow down", 0), connectionRefused},
+			wantSuccess: true,
+			wantCalls:   3,
+			wantRetries: "2",
+		},
+		{
+			name: "gives up after all attempts",
+			errs: []error{
+				apierrors.NewTooManyRequests("slow down", 0),
+				apierrors.NewTooManyRequests("slow down", 0),
+				apierrors.NewTooManyRequests("slow down", 0),
+			},
+			wantSuccess: false,
+			wantCalls:   3,
+			wantRetries: "2",
+		},
+		{
+			name:        "ambiguous timeout not retried",
+			errs:        []error{apierrors.NewServerTimeout(gr, "create", 0)},
+			wantSuccess: false,
+			wantCalls:   1,
+			wantRetries: "0",
+		},
+		{
+			name:        "dropped connection not retried",
+			errs:        []error{fmt.Errorf("read: %w", io.ErrUnexpectedEOF)},
+			wantSuccess: false,
+			wantCalls:   1,
+			wantRetries: "0",
+		},
+		{
+			name:        "existing object after retry fails",
+			errs:        []error{connectionRefused, apierrors.NewAlreadyExists(gr, "settings")},
+			wantSuccess: false,
+			wantCalls:   2,
+			wantRetries: "1",
+		},
+		{
+			name:        "invalid fails immediately",
+			errs:        []error{apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "cm", nil)},
+			wantSuccess: false,
+			wantCalls:   1,
+			wantRetries: "0",
+		},
+		{
+			name:        "forbidden fails immediately",
+			errs:        []error{apierrors.NewForbidden(gr, "cm", fmt.Errorf("denied"))},
+			wantSuccess: false,
+			wantCalls:   1,
+			wantRetries: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mock := &mockClient{
+				createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+					calls++
+					if calls <= len(tt.errs) {
+						return nil, tt.errs[calls-1]
+					}
+					return obj, nil
+				},
+			}
+
+			var retried []int
+			ext := &Extension{Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"})}
+			ext.client = newRetryingClient(mock, testRetryPolicy, func(ctx context.Context, call string, attempt int, err error) {
+				retried = append(retried, attempt)
+			})
+
+			req := &sdk.OperationRequest{Args: configMapArgs(nil)}
+			result, err := withRetryCount(ext.handleCreate)(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleCreate() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("handleCreate() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Create called %d times, want %d", calls, tt.wantCalls)
+			}
+			if got := result.Outputs["retries"]; got != tt.wantRetries {
+				t.Errorf("retries output = %q, want %q", got, tt.wantRetries)
+			}
+			if len(retried) != tt.wantCalls-1 {
+				t.Errorf("onRetry called %d times, want %d", len(retried), tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestRetryingClientCreateGenerateNameNotRetried(t *testing.T) {
+	calls := 0
+	mock := &mockClient{
+		createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+			calls++
+			return nil, apierrors.NewTooManyRequests("slow down", 0)
+		},
+	}
+	client := newRetryingClient(mock, testRetryPolicy, nil)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGenerateName("settings-")
+	if _, err := client.Create(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, obj, "default"); err == nil {
+		t.Fatal("Create() succeeded, want the throttling error")
+	}
+	if calls != 1 {
+		t.Errorf("Create called %d times for a generated name, want 1", calls)
+	}
+}
+
+func TestRetryingClientUpdateSkipsConflicts(t *testing.T) {
+	calls := 0
+	mock := &mockClient{
+		updateFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+			calls++
+			return nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "cm", fmt.Errorf("changed"))
+		},
+	}
+	client := newRetryingClient(mock, testRetryPolicy, nil)
+
+	_, err := client.Update(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, &unstructured.Unstructured{}, "default")
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("Update() error = %v, want conflict", err)
+	}
+	if calls != 1 {
+		t.Errorf("Update called %d times, want 1", calls)
+	}
+}
+
+func TestRetryingClientPatchRetriesConflicts(t *testing.T) {
+	calls := 0
+	mock := &mockClient{
+		patchFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, patchType types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+			calls++
+			if calls == 1 {
+				return nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, name, fmt.Errorf("changed"))
+			}
+			return &unstructured.Unstructured{}, nil
+		},
+	}
+	client := newRetryingClient(mock, testRetryPolicy, nil)
+
+	_, err := client.Patch(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "cm", "default", types.MergePatchType, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Patch() returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Patch called %d times, want 2", calls)
+	}
+}
+
+func TestRetryingClientDelete(t *testing.T) {
+	calls := 0
+	mock := &mockClient{
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			calls++
+			if calls == 1 {
+				return fmt.Errorf("read tcp: %w", io.ErrUnexpectedEOF)
+			}
+			return nil
+		},
+	}
+	client := newRetryingClient(mock, testRetryPolicy, nil)
+
+	err := client.Delete(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "p", "default", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Delete called %d times, want 2", calls)
+	}
+}
+
+func TestRetryingClientDeleteNotFound(t *testing.T) {
+	gr := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "missing object fails",
+			errs:      []error{apierrors.NewNotFound(gr, "p")},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "object gone after retry succeeds",
+			errs:      []error{fmt.Errorf("read tcp: %w", io.ErrUnexpectedEOF), apierrors.NewNotFound(gr, "p")},
+			wantCalls: 2,
+		},
+		{
+			name:      "object gone after several retries succeeds",
+			errs:      []error{apierrors.NewTooManyRequests("slow down", 0), apierrors.NewServerTimeout(gr, "delete", 0), apierrors.NewNotFound(gr, "p")},
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mock := &mockClient{
+				deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+					calls++
+					return tt.errs[calls-1]
+				},
+			}
+			client := newRetryingClient(mock, testRetryPolicy, nil)
+
+			err := client.Delete(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "p", "default", metav1.DeleteOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Delete called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryingClientDeleteSkipsPreconditionConflicts(t *testing.T) {
+	calls := 0
+	mock := &mockClient{
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			calls++
+			return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, name, fmt.Errorf("precondition failed"))
+		},
+	}
+	client := newRetryingClient(mock, testRetryPolicy, nil)
+
+	uid := types.UID("old")
+	opts := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}}
+	err := client.Delete(context.Background(), schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "p", "default", opts)
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("Delete() error = %v, want conflict", err)
+	}
+	if calls != 1 {
+		t.Errorf("Delete called %d times, want 1", calls)
+	}
+}
+
+func TestWithRetryCountSkipsReadOnlyOperations(t *testing.T) {
+	handler := withRetryCount(func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		return sdk.SuccessWithOutputs("ok", map[string]string{"context": "kind"}), nil
+	})
+
+	result, _ := handler(context.Background(), &sdk.OperationRequest{})
+	if _, ok := result.Outputs["retries"]; ok {
+		t.Errorf("retries output set for an operation without mutating calls")
+	}
+}
@@ -0,0 +1,197 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// scaleSubresource is the subresource holding the replicas of scalable resources.
+const scaleSubresource = "scale"
+
+// handleScale reads and optionally sets the replicas of any resource with a scale
+// subresource, such as Deployments, StatefulSets, ReplicaSets and custom resources.
+// It can wait for the status to reach the new replica count and verify the replicas.
+func (e *Extension) handleScale(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	if e.client == nil {
+		return failure(errClientNotInitialized), nil
+	}
+
+	args, ok := req.Args.(map[string]any)
+	if !ok {
+		return failure(fmt.Errorf("args must be an object")), nil
+	}
+
+	ref, err := parseResourceRef(args)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	replicas, setReplicas := int64(0), false
+	if value, ok := args["replicas"]; ok {
+		replicas, ok = toInt64(value)
+		if !ok || replicas < 0 {
+			return failure(fmt.Errorf("replicas must be a non-negative integer")), nil
+		}
+		setReplicas = true
+	}
+
+	waitForReplicas, _ := args["wait"].(bool)
+	if waitForReplicas && !setReplicas {
+		return failure(fmt.Errorf("wait requires replicas")), nil
+	}
+
+	timeoutStr, _ := args["timeout"].(string)
+	if timeoutStr == "" {
+		timeoutStr = "60s"
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+	}
+
+	expect, err := parseExpect(args)
+	if err != nil {
+		return failure(err), nil
+	}
+	// Validate expectations before changing anything
+	for _, key := range []string{"replicas", "statusReplicas"} {
+		if value, ok := expect[key]; ok {
+			if _, ok := toInt64(value); !ok {
+				return failure(fmt.Errorf("expect.%s must be an integer", key)), nil
+			}
+		}
+	}
+
+	gvr, err := e.resourceFor(ref)
+	if err != nil {
+		return failure(err), nil
+	}
+
+	scale, err := e.client.Get(ctx, gvr, ref.name, ref.namespace, scaleSubresource)
+	if err != nil {
+		e.LogError(ctx, "Failed to read scale", map[string]any{
+			"kind":  ref.kind,
+			"name":  ref.name,
+			"error": err.Error(),
+		})
+		return failure(fmt.Errorf("failed to read scale of %s/%s: %w", ref.kind, ref.name, err)), nil
+	}
+	previous := specReplicas(scale)
+
+	if setReplicas {
+		e.LogInfo(ctx, "Scaling resource", map[string]any{
+			"kind":      ref.kind,
+			"name":      ref.name,
+			"namespace": ref.namespace,
+			"from":      previous,
+			"to":        replicas,
+		})
+
+		// Without a resourceVersion the update is unconditional, like kubectl scale
+		scale.SetResourceVersion("")
+		if err := unstructured.SetNestedField(scale.Object, replicas, "spec", "replicas"); err != nil {
+			return failure(fmt.Errorf("failed to set replicas: %w", err)), nil
+		}
+		scale, err = e.client.Update(ctx, gvr, scale, ref.namespace, scaleSubresource)
+		if err != nil {
+			e.LogError(ctx, "Failed to scale resource", map[string]any{
+				"kind":  ref.kind,
+				"name":  ref.name,
+				"error": err.Error(),
+			})
+			return failure(fmt.Errorf("failed to scale %s/%s: %w", ref.kind, ref.name, err)), nil
+		}
+	}
+
+	if waitForReplicas {
+		err := wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			current, err := e.client.Get(ctx, gvr, ref.name, ref.namespace, scaleSubresource)
+			if err != nil {
+				if isPermanentError(err) {
+					return false, err
+				}
+				return false, nil
+			}
+			scale = current
+			return statusReplicas(scale) == replicas, nil
+		})
+		if err != nil {
+			e.LogError(ctx, "Replicas not reached", map[string]any{
+				"kind":           ref.kind,
+				"name":           ref.name,
+				"replicas":       replicas,
+				"statusReplicas": statusReplicas(scale),
+			})
+			result := failureWithCategory(categoryAgent,
+				fmt.Sprintf("%s/%s did not reach %d replica(s)", ref.kind, ref.name, replicas),
+				fmt.Errorf("%s/%s has %d replica(s) after %s, want %d: %w", ref.kind, ref.name, statusReplicas(scale), timeout, replicas, err),
+			)
+			for key, value := range scaleOutputs(scale, previous) {
+				result.Outputs[key] = value
+			}
+			return result, nil
+		}
+	}
+
+	if expect != nil {
+		var result expectationResult
+		if err := result.checkInt(expect, "replicas", specReplicas(scale)); err != nil {
+			return failure(err), nil
+		}
+		if err := result.checkInt(expect, "statusReplicas", statusReplicas(scale)); err != nil {
+			return failure(err), nil
+		}
+		if result.failed() {
+			failed := failureWithCategory(categoryAgent,
+				fmt.Sprintf("scale of %s/%s check failed: %s", ref.kind, ref.name, result.String()),
+				fmt.Errorf("scale expectation not met"),
+			)
+			for key, value := range scaleOutputs(scale, previous) {
+				failed.Outputs[key] = value
+			}
+			return failed, nil
+		}
+	}
+
+	message := fmt.Sprintf("%s/%s has %d replica(s)", ref.kind, ref.name, specReplicas(scale))
+	if setReplicas {
+		message = fmt.Sprintf("Scaled %s/%s from %d to %d replica(s)", ref.kind, ref.name, previous, replicas)
+	}
+
+	e.LogInfo(ctx, "Scale checked", map[string]any{
+		"kind":           ref.kind,
+		"name":           ref.name,
+		"replicas":       specReplicas(scale),
+		"statusReplicas": statusReplicas(scale),
+	})
+
+	return sdk.SuccessWithOutputs(message, scaleOutputs(scale, previous)), nil
+}
+
+// specReplicas returns the desired replicas of a Scale.
+func specReplicas(scale *unstructured.Unstructured) int64 {
+	replicas, _, _ := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+	return replicas
+}
+
+// statusReplicas returns the observed replicas of a Scale.
+func statusReplicas(scale *unstructured.Unstructured) int64 {
+	replicas, _, _ := unstructured.NestedInt64(scale.Object, "status", "replicas")
+	return replicas
+}
+
+// scaleOutputs returns the outputs describing scale.
+func scaleOutputs(scale *unstructured.Unstructured, previous int64) map[string]string {
+	selector, _, _ := unstructured.NestedString(scale.Object, "status", "selector")
+	return map[string]string{
+		"replicas":         fmt.Sprintf("%d", specReplicas(scale)),
+		"statusReplicas":   fmt.Sprintf("%d", statusReplicas(scale)),
+		"previousReplicas": fmt.Sprintf("%d", previous),
+		"selector":         selector,
+	}
+}
@@ -0,0 +1,203 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeScale serves the scale subresource of deployment web. Updates set the desired
+// replicas, and the observed replicas follow them after the given number of reads.
+type fakeScale struct {
+	spec, status int64
+	lag          int
+	reads        int
+	updates      []string
+}
+
+func (f *fakeScale) client() *mockClient {
+	return &mockClient{
+		getSubresourceFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+			if gvr.Resource != "deployments" || len(subresources) != 1 || subresources[0] != "scale" {
+				return nil, fmt.Errorf("unexpected get of %s %v", gvr.Resource, subresources)
+			}
+			f.reads++
+			if f.reads > f.lag {
+				f.status = f.spec
+			}
+			return f.object(), nil
+		},
+		updateFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+			if len(subresources) != 1 || subresources[0] != "scale" {
+				return nil, fmt.Errorf("unexpected update of %v", subresources)
+			}
+			if obj.GetResourceVersion() != "" {
+				return nil, fmt.Errorf("scale update carries resourceVersion %q", obj.GetResourceVersion())
+			}
+			f.spec, _, _ = unstructured.NestedInt64(obj.Object, "spec", "replicas")
+			f.updates = append(f.updates, fmt.Sprintf("%s/%s=%d", namespace, obj.GetName(), f.spec))
+			f.reads = 0
+			return f.object(), nil
+		},
+	}
+}
+
+func (f *fakeScale) object() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "autoscaling/v1",
+		"kind":       "Scale",
+		"metadata":   map[string]any{"name": "web", "namespace": "default", "resourceVersion": "42"},
+		"spec":       map[string]any{"replicas": f.spec},
+		"status":     map[string]any{"replicas": f.status, "selector": "app=web"},
+	}}
+}
+
+func TestHandleScale(t *testing.T) {
+	scaleArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "default"},
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		scale        *fakeScale
+		wantSuccess  bool
+		wantUpdates  []string
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name:        "scale to zero",
+			args:        scaleArgs(map[string]any{"replicas": float64(0)}),
+			scale:       &fakeScale{spec: 3, status: 3},
+			wantSuccess: true,
+			wantUpdates: []string{"default/web=0"},
+			wantOutputs: map[string]string{"replicas": "0", "previousReplicas": "3", "selector": "app=web"},
+		},
+		{
+			name:        "scale and wait",
+			args:        scaleArgs(map[string]any{"replicas": float64(2), "wait": true, "timeout": "5s"}),
+			scale:       &fakeScale{spec: 0, status: 0, lag: 1},
+			wantSuccess: true,
+			wantUpdates: []string{"default/web=2"},
+			wantOutputs: map[string]string{"replicas": "2", "statusReplicas": "2"},
+		},
+		{
+			name:         "wait times out",
+			args:         scaleArgs(map[string]any{"replicas": float64(2), "wait": true, "timeout": "1s"}),
+			scale:        &fakeScale{spec: 0, status: 0, lag: 100},
+			wantSuccess:  false,
+			wantUpdates:  []string{"default/web=2"},
+			wantOutputs:  map[string]string{"replicas": "2", "statusReplicas": "0"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:        "expected replicas",
+			args:        scaleArgs(map[string]any{"expect": map[string]any{"replicas": float64(3), "statusReplicas": float64(3)}}),
+			scale:       &fakeScale{spec: 3, status: 3},
+			wantSuccess: true,
+		},
+		{
+			name:         "unexpected replicas",
+			args:         scaleArgs(map[string]any{"expect": map[string]any{"replicas": float64(3)}}),
+			scale:        &fakeScale{spec: 0, status: 0},
+			wantSuccess:  false,
+			wantOutputs:  map[string]string{"replicas": "0"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "invalid expectation does not scale",
+			args:         scaleArgs(map[string]any{"replicas": float64(1), "expect": map[string]any{"replicas": "one"}}),
+			scale:        &fakeScale{spec: 0},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "negative replicas",
+			args:         scaleArgs(map[string]any{"replicas": float64(-1)}),
+			scale:        &fakeScale{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "wait without replicas",
+			args:         scaleArgs(map[string]any{"wait": true}),
+			scale:        &fakeScale{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.scale.client(),
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleScale(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleScale() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleScale() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if fmt.Sprint(tt.scale.updates) != fmt.Sprint(tt.wantUpdates) {
+				t.Errorf("updates = %v, want %v", tt.scale.updates, tt.wantUpdates)
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleScaleWithoutScaleSubresource(t *testing.T) {
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client: &mockClient{
+			getSubresourceFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, subresources ...string) (*unstructured.Unstructured, error) {
+				return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+			},
+		},
+	}
+
+	req := &sdk.OperationRequest{Args: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "settings", "namespace": "default"},
+		"replicas":   float64(1),
+	}}
+	result, err := ext.handleScale(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleScale() returned error: %v", err)
+	}
+	if result.Success {
+		t.Fatalf("handleScale() succeeded on a resource without scale subresource")
+	}
+	if got := result.Outputs["statusCode"]; got != "404" {
+		t.Errorf("statusCode output = %q, want %q", got, "404")
+	}
+}
@@ -0,0 +1,89 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+// Initialize configures the extension with the same config mcpchecker passes in the
+// initialize request, so it can run operations without a JSON-RPC connection.
+func (e *Extension) Initialize(config map[string]any) error {
+	return e.handleInitialize(config)
+}
+
+// Operations returns the names of the registered operations, sorted.
+func (e *Extension) Operations() []string {
+	names := make([]string, 0, len(e.operations))
+	for name := range e.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute runs an operation directly, as if mcpchecker had requested it. Placeholders
+// in the arguments are resolved and the outputs are recorded for later steps of the
+// same task, exactly as for requests received over JSON-RPC.
+func (e *Extension) Execute(ctx context.Context, operation string, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+	handler, ok := e.operations[operation]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation: %s", operation)
+	}
+	return handler(ctx, req)
+}
+
+// SetLogOutput writes log messages as text lines to w instead of sending them to
+// mcpchecker. It is used when operations run without a JSON-RPC connection.
+func (e *Extension) SetLogOutput(w io.Writer) {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+
+	e.logOutput = w
+}
+
+// log sends a log message to mcpchecker, or writes it to the log output if one is set.
+func (e *Extension) log(ctx context.Context, level, message string, data map[string]any) error {
+	e.logMu.Lock()
+	defer e.logMu.Unlock()
+
+	if e.logOutput == nil {
+		return e.Extension.Log(ctx, level, message, data)
+	}
+	_, err := fmt.Fprintln(e.logOutput, formatLogLine(level, message, data))
+	return err
+}
+
+// formatLogLine formats a log message as "LEVEL message key=value ...", with the
+// keys sorted and values that are not plain words quoted or encoded as JSON.
+func formatLogLine(level, message string, data map[string]any) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-5s %s", strings.ToUpper(level), message)
+	for _, key := range sortedKeys(data) {
+		var value string
+		switch v := data[key].(type) {
+		case string:
+			value = v
+		case fmt.Stringer:
+			value = v.String()
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				encoded = []byte(strconv.Quote(fmt.Sprint(v)))
+			}
+			fmt.Fprintf(&b, " %s=%s", key, encoded)
+			continue
+		}
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
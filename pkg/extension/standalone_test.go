@@ -0,0 +1,51 @@
+package extension
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+func TestExecute(t *testing.T) {
+	ext := New()
+	ext.client = &mockClient{}
+
+	var logs bytes.Buffer
+	ext.SetLogOutput(&logs)
+
+	req := &sdk.OperationRequest{Args: map[string]any{}}
+	result, err := ext.Execute(context.Background(), "getCurrentContext", req)
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if !result.Success || result.Outputs["context"] != "default" {
+		t.Errorf("Execute() = %+v, want current context default", result)
+	}
+	if !strings.Contains(logs.String(), "INFO  Current context retrieved context=default") {
+		t.Errorf("logs = %q, want the current context message", logs.String())
+	}
+
+	if _, err := ext.Execute(context.Background(), "apply", req); err == nil {
+		t.Errorf("Execute() of an unknown operation returned no error")
+	}
+}
+
+func TestFormatLogLine(t *testing.T) {
+	got := formatLogLine("warn", "Retrying", map[string]any{
+		"call":    "create",
+		"attempt": 2,
+		"error":   "connection reset",
+		"after":   1500 * time.Millisecond,
+		"empty":   "",
+		"names":   []string{"a", "b"},
+		"token":   redactedValue,
+	})
+	want := `WARN  Retrying after=1.5s attempt=2 call=create empty="" error="connection reset" names=["a","b"] token=REDACTED`
+	if got != want {
+		t.Errorf("formatLogLine() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package extension
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Phases of a task, as reported in the context of operation requests.
+const (
+	phaseSetup   = "setup"
+	phaseCleanup = "cleanup"
+)
+
+// taskState holds state the extension keeps for a single task between operations.
+// mcpchecker runs every step of a task with the task's directory as workdir, so
+// the workdir is used to tell tasks apart when they share one extension process.
+// Requests carry no run identity, so runs of one task directory must not overlap;
+// beginOperation refuses a run that starts while another one is past its setup.
+type taskState struct {
+	// kubeconfigPath is the task's isolated kubeconfig copy, if one was created.
+	kubeconfigPath string
+	// namespaces lists the namespaces created for the task by createNamespace.
+	namespaces []string
+	// created lists the UIDs of objects created for the task by create operations.
+	created []types.UID
+	// outputs holds the outputs of the latest successful call of each operation.
+	outputs map[string]map[string]string
+	// phase is the phase of the latest operation of the task that reported one.
+	phase string
+}
+
+// taskKey returns the key identifying the task that issued req. Concurrent runs of
+// the same task share the key, see taskState.
+func taskKey(req *sdk.OperationRequest) string {
+	return req.Context.Workdir
+}
+
+// taskStateLocked returns the state for the task that issued req, creating it if needed.
+// The caller must hold stateMu.
+func (e *Extension) taskStateLocked(req *sdk.OperationRequest) *taskState {
+	if e.tasks == nil {
+		e.tasks = make(map[string]*taskState)
+	}
+
+	key := taskKey(req)
+	state, ok := e.tasks[key]
+	if !ok {
+		state = &taskState{}
+		e.tasks[key] = state
+	}
+	return state
+}
+
+// swapKubeconfigPath records path as the isolated kubeconfig of the task that issued req
+// and returns the path of the copy it replaces, if any.
+func (e *Extension) swapKubeconfigPath(req *sdk.OperationRequest, path string) string {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state := e.taskStateLocked(req)
+	previous := state.kubeconfigPath
+	state.kubeconfigPath = path
+	return previous
+}
+
+// recordNamespace remembers a namespace created for the task that issued req.
+func (e *Extension) recordNamespace(req *sdk.OperationRequest, name string) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state := e.taskStateLocked(req)
+	state.namespaces = append(state.namespaces, name)
+}
+
+// activeNamespaces returns the namespaces created for tasks that have not been cleaned up yet.
+func (e *Extension) activeNamespaces() map[string]bool {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	active := make(map[string]bool)
+	for _, state := range e.tasks {
+		for _, name := range state.namespaces {
+			active[name] = true
+		}
+	}
+	return active
+}
+
+// recordCreated remembers the UID of an object created for the task that issued req.
+func (e *Extension) recordCreated(req *sdk.OperationRequest, uid types.UID) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if uid == "" {
+		return
+	}
+	state := e.taskStateLocked(req)
+	state.created = append(state.created, uid)
+}
+
+// isCreated reports whether the object with uid was created for a task that has not
+// been cleaned up yet.
+func (e *Extension) isCreated(uid types.UID) bool {
+	if uid == "" {
+		return false
+	}
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	for _, state := range e.tasks {
+		for _, created := range state.created {
+			if created == uid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// beginOperation updates the state of the task that issued req before one of its
+// operations runs. A setup phase following the cleanup phase starts a new run of the
+// task, so the outputs of the previous run are dropped and cannot leak into it. A setup
+// phase following any other phase means another run of the task is still in progress,
+// and is refused, as both runs would share one state.
+func (e *Extension) beginOperation(req *sdk.OperationRequest) error {
+	phase := req.Context.Phase
+	if phase == "" {
+		return nil
+	}
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state := e.taskStateLocked(req)
+	if phase == phaseSetup && state.phase != "" && state.phase != phaseSetup {
+		if state.phase != phaseCleanup {
+			return fmt.Errorf("task %s is already running in its %s phase; runs of one task directory must not overlap", taskKey(req), state.phase)
+		}
+		state.outputs = nil
+	}
+	state.phase = phase
+	return nil
+}
+
+// recordOutputs remembers the outputs of an operation called by the task that issued req.
+func (e *Extension) recordOutputs(req *sdk.OperationRequest, operation string, outputs map[string]string) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state := e.taskStateLocked(req)
+	if state.outputs == nil {
+		state.outputs = make(map[string]map[string]string)
+	}
+	recorded := make(map[string]string, len(outputs))
+	for key, value := range outputs {
+		recorded[key] = value
+	}
+	state.outputs[operation] = recorded
+}
+
+// recordedOutputs returns the latest outputs of an operation called by the task that issued req.
+func (e *Extension) recordedOutputs(req *sdk.OperationRequest, operation string) (map[string]string, bool) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state, ok := e.tasks[taskKey(req)]
+	if !ok {
+		return nil, false
+	}
+	outputs, ok := state.outputs[operation]
+	return outputs, ok
+}
+
+// releaseTask detaches the resources held for the task that issued req and returns
+// them, so the caller can undo whatever the task left behind. Recorded outputs are
+// kept, so later cleanup steps can still reference them.
+func (e *Extension) releaseTask(req *sdk.OperationRequest) taskState {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	state, ok := e.tasks[taskKey(req)]
+	if !ok {
+		return taskState{}
+	}
+	released := taskState{
+		kubeconfigPath: state.kubeconfigPath,
+		namespaces:     state.namespaces,
+	}
+	state.kubeconfigPath = ""
+	state.namespaces = nil
+	state.created = nil
+	return released
+}
+
+// clientFor returns the client to use for req. Kubeconfig operations of a task
+// with an isolated kubeconfig target the task's copy instead of the shared file.
+func (e *Extension) clientFor(req *sdk.OperationRequest) ResourceClient {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if state, ok := e.tasks[taskKey(req)]; ok && state.kubeconfigPath != "" {
+		return e.client.WithKubeconfig(state.kubeconfigPath)
+	}
+	return e.client
+}
+
+// Close releases state held for all tasks, such as isolated kubeconfig copies, and
+// closes the cassette being recorded.
+// It should be called once the extension has stopped serving requests.
+func (e *Extension) Close() error {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	var firstErr error
+	for key, state := range e.tasks {
+		if state.kubeconfigPath != "" {
+			if err := os.Remove(state.kubeconfigPath); err != nil && !os.IsNotExist(err) && firstErr == nil {
+				firstErr = err
+			}
+		}
+		delete(e.tasks, key)
+	}
+	if e.recorder != nil {
+		if err := e.recorder.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
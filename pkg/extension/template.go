@@ -0,0 +1,221 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+// templatePattern matches ${{ reference }} placeholders in operation arguments, and
+// $${{ ... }}, which escapes them.
+var templatePattern = regexp.MustCompile(`\$?\$\{\{\s*([^}]*?)\s*\}\}`)
+
+// referencePrefixes are the prefixes of placeholder references. Other ${{ ... }}
+// expressions, such as those of GitHub Actions workflows in a manifest, are kept.
+var referencePrefixes = []string{"steps.", "env.", "task.", "run."}
+
+// placeholderRef returns the reference of a templatePattern match, or false if the
+// match is escaped or not a reference of this extension.
+func placeholderRef(match string) (string, bool) {
+	if strings.HasPrefix(match, "$$") {
+		return "", false
+	}
+	ref := templatePattern.FindStringSubmatch(match)[1]
+	for _, prefix := range referencePrefixes {
+		if strings.HasPrefix(ref, prefix) {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+// isWholePlaceholder reports whether s consists of a single placeholder only.
+func isWholePlaceholder(s string) bool {
+	loc := templatePattern.FindStringIndex(s)
+	if loc == nil || loc[0] != 0 || loc[1] != len(s) {
+		return false
+	}
+	_, ok := placeholderRef(s)
+	return ok
+}
+
+// withTemplating wraps handler so that placeholders in its arguments are replaced
+// with earlier outputs and task variables, and its outputs are recorded under name.
+// params is the schema of the arguments, used to type whole-value placeholders.
+// Outputs of an earlier run of the task are dropped when its next setup phase starts,
+// and a run that overlaps another run of the task is refused.
+func (e *Extension) withTemplating(name string, params *jsonschema.Schema, handler sdk.OperationHandler) sdk.OperationHandler {
+	return func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		if err := e.beginOperation(req); err != nil {
+			return failureWithCategory(categoryInfra, "Another run of the task is in progress", err), nil
+		}
+		args, err := resolveTemplates(req.Args, params, func(ref string) (string, error) {
+			return e.lookupReference(req, ref)
+		})
+		if err != nil {
+			return failure(fmt.Errorf("failed to resolve arguments: %w", err)), nil
+		}
+
+		resolved := *req
+		resolved.Args = args
+
+		result, err := handler(ctx, &resolved)
+		if err == nil && result != nil && result.Success && len(result.Outputs) > 0 {
+			e.recordOutputs(req, name, result.Outputs)
+		}
+		return result, err
+	}
+}
+
+// resolveTemplates returns a copy of value with every ${{ reference }} placeholder
+// in its strings replaced by the value lookup returns for the reference, and every
+// $${{ escaped as ${{. A placeholder that makes up a whole value is decoded as a
+// number or boolean when it holds one and schema declares the value a number, integer
+// or boolean.
+func resolveTemplates(value any, schema *jsonschema.Schema, lookup func(ref string) (string, error)) (any, error) {
+	switch v := value.(type) {
+	case string:
+		var firstErr error
+		result := templatePattern.ReplaceAllStringFunc(v, func(match string) string {
+			ref, ok := placeholderRef(match)
+			if !ok {
+				if strings.HasPrefix(match, "$$") {
+					return match[1:]
+				}
+				return match
+			}
+			resolved, err := lookup(ref)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			return resolved
+		})
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		if isWholePlaceholder(v) {
+			if scalar, ok := typedScalar(result, schema); ok {
+				return scalar, nil
+			}
+		}
+		return result, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := resolveTemplates(item, propertySchema(schema, key), lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolved
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		var items *jsonschema.Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		for i, item := range v {
+			resolved, err := resolveTemplates(item, items, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// typedScalar decodes a resolved whole-value placeholder as a number or boolean if
+// schema declares the value one. Values without a declared type, such as the fields of
+// manifests and ConfigMap data, stay strings.
+func typedScalar(resolved string, schema *jsonschema.Schema) (any, bool) {
+	if schema == nil {
+		return nil, false
+	}
+	types := schema.Types
+	if schema.Type != "" {
+		types = []string{schema.Type}
+	}
+	var scalar any
+	if json.Unmarshal([]byte(resolved), &scalar) != nil {
+		return nil, false
+	}
+	switch scalar.(type) {
+	case float64:
+		if slices.Contains(types, "integer") || slices.Contains(types, "number") {
+			return scalar, true
+		}
+	case bool:
+		if slices.Contains(types, "boolean") {
+			return scalar, true
+		}
+	}
+	return nil, false
+}
+
+// propertySchema returns the schema of the key property of an object, or nil.
+func propertySchema(schema *jsonschema.Schema, key string) *jsonschema.Schema {
+	if schema == nil {
+		return nil
+	}
+	if property, ok := schema.Properties[key]; ok {
+		return property
+	}
+	return schema.AdditionalProperties
+}
+
+// lookupReference resolves a placeholder reference for the task that issued req.
+// Supported references are:
+//
+//	steps.<operation>.<output>  output of the latest successful <operation> in the task
+//	env.<NAME>                  task environment variable
+//	task.name, task.workdir     name and directory of the task
+//	run.id                      id of the extension run
+func (e *Extension) lookupReference(req *sdk.OperationRequest, ref string) (string, error) {
+	parts := strings.SplitN(ref, ".", 3)
+	switch {
+	case len(parts) == 3 && parts[0] == "steps":
+		outputs, ok := e.recordedOutputs(req, parts[1])
+		if !ok {
+			return "", fmt.Errorf("%s: no outputs recorded for operation %q in this task", ref, parts[1])
+		}
+		value, ok := outputs[parts[2]]
+		if !ok {
+			return "", fmt.Errorf("%s: operation %q has no output %q (available: %s)", ref, parts[1], parts[2], strings.Join(sortedKeys(outputs), ", "))
+		}
+		return value, nil
+	case len(parts) == 2 && parts[0] == "env":
+		// The extension's own environment is not exposed, as it may hold credentials
+		if value, ok := req.Context.Env[parts[1]]; ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("%s: task environment variable %q is not set", ref, parts[1])
+	case ref == "task.name":
+		return taskName(req, nil), nil
+	case ref == "task.workdir":
+		return req.Context.Workdir, nil
+	case ref == "run.id":
+		return e.runID, nil
+	default:
+		return "", fmt.Errorf("unknown reference %q (expected steps.<operation>.<output>, env.<NAME>, task.name, task.workdir or run.id)", ref)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,322 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/protocol"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLookupReference(t *testing.T) {
+	t.Setenv("TEMPLATE_TEST_PROCESS_VAR", "from-process")
+
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		runID:     "run-1",
+	}
+	req := &sdk.OperationRequest{
+		Context: protocol.ExecuteContext{
+			Workdir: "/evals/tasks/scale-app",
+			Env:     map[string]string{"TARGET": "web"},
+		},
+	}
+	ext.recordOutputs(req, "create", map[string]string{"name": "web-abc12", "uid": "1234"})
+	ext.recordOutputs(req, "scale", map[string]string{"replicas": "3", "wait": "true"})
+
+	tests := []struct {
+		name    string
+		value   any
+		schema  *jsonschema.Schema
+		want    any
+		wantErr bool
+	}{
+		{
+			name:  "step output",
+			value: "${{ steps.create.name }}",
+			want:  "web-abc12",
+		},
+		{
+			name:  "embedded references",
+			value: "${{steps.create.name}}-${{ env.TARGET }}-${{ run.id }}",
+			want:  "web-abc12-web-run-1",
+		},
+		{
+			name:  "nested arguments",
+			value: map[string]any{"metadata": map[string]any{"name": "${{ steps.create.name }}"}, "items": []any{"${{ task.name }}", true}},
+			want:  map[string]any{"metadata": map[string]any{"name": "web-abc12"}, "items": []any{"scale-app", true}},
+		},
+		{
+			name:    "process environment not exposed",
+			value:   "${{ env.TEMPLATE_TEST_PROCESS_VAR }}",
+			wantErr: true,
+		},
+		{
+			name:  "whole placeholders typed",
+			value: map[string]any{"replicas": "${{ steps.scale.replicas }}", "wait": "${{ steps.scale.wait }}", "name": "${{ steps.create.name }}"},
+			schema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+				"replicas": {Type: "integer"},
+				"wait":     {Type: "boolean"},
+				"name":     {Type: "string"},
+			}},
+			want: map[string]any{"replicas": float64(3), "wait": true, "name": "web-abc12"},
+		},
+		{
+			name:  "untyped values keep strings",
+			value: map[string]any{"data": map[string]any{"port": "${{ steps.scale.replicas }}", "enabled": "${{ steps.scale.wait }}"}},
+			schema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+				"data": {Type: "object"},
+			}},
+			want: map[string]any{"data": map[string]any{"port": "3", "enabled": "true"}},
+		},
+		{
+			name:   "number not decoded for a boolean",
+			value:  "${{ steps.scale.replicas }}",
+			schema: &jsonschema.Schema{Type: "boolean"},
+			want:   "3",
+		},
+		{
+			name:  "string arguments keep strings",
+			value: map[string]any{"selector": "${{ steps.scale.replicas }}", "ports": []any{"${{ steps.scale.replicas }}"}},
+			schema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+				"selector": {Type: "string"},
+				"ports":    {Type: "array", Items: &jsonschema.Schema{Types: []string{"integer", "string"}}},
+			}},
+			want: map[string]any{"selector": "3", "ports": []any{float64(3)}},
+		},
+		{
+			name:  "embedded placeholder stays a string",
+			value: "${{ steps.scale.replicas }}0",
+			want:  "30",
+		},
+		{
+			name:  "escaped placeholder",
+			value: "$${{ steps.create.name }} is ${{ steps.create.name }}",
+			want:  "${{ steps.create.name }} is web-abc12",
+		},
+		{
+			name:  "other expressions untouched",
+			value: "echo ${{ secrets.TOKEN }} ${{ github.sha }}",
+			want:  "echo ${{ secrets.TOKEN }} ${{ github.sha }}",
+		},
+		{
+			name:  "plain string untouched",
+			value: "nginx:latest",
+			want:  "nginx:latest",
+		},
+		{
+			name:    "missing output",
+			value:   "${{ steps.create.namespace }}",
+			wantErr: true,
+		},
+		{
+			name:    "operation without outputs",
+			value:   "${{ steps.wait.status }}",
+			wantErr: true,
+		},
+		{
+			name:    "unset environment variable",
+			value:   "${{ env.TEMPLATE_TEST_UNSET_VAR }}",
+			wantErr: true,
+		},
+		{
+			name:    "unknown task reference",
+			value:   "${{ task.id }}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTemplates(tt.value, tt.schema, func(ref string) (string, error) {
+				return ext.lookupReference(req, ref)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveTemplates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTemplatingUsesEarlierOutputs(t *testing.T) {
+	var deletedName, deletedNamespace string
+	client := &mockClient{
+		createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+			result := obj.DeepCopy()
+			result.SetName(obj.GetGenerateName() + "x7k2p")
+			return result, nil
+		},
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, opts metav1.DeleteOptions) error {
+			deletedName, deletedNamespace = name, namespace
+			return nil
+		},
+	}
+	ext := &Extension{
+		Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+		client:    client,
+	}
+	create := ext.withTemplating("createNamespace", nil, ext.handleCreateNamespace)
+	createCM := ext.withTemplating("create", nil, ext.handleCreate)
+	del := ext.withTemplating("delete", nil, ext.handleDelete)
+	task := protocol.ExecuteContext{Workdir: "/tasks/a"}
+
+	result, _ := create(context.Background(), &sdk.OperationRequest{Args: map[string]any{"prefix": "web-", "generateName": true}, Context: task})
+	if !result.Success {
+		t.Fatalf("createNamespace failed: %s", result.Error)
+	}
+
+	result, _ = createCM(context.Background(), &sdk.OperationRequest{
+		Args: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"generateName": "settings-", "namespace": "${{ steps.createNamespace.name }}"},
+		},
+		Context: task,
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %s", result.Error)
+	}
+
+	result, _ = del(context.Background(), &sdk.OperationRequest{
+		Args: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "${{ steps.create.name }}", "namespace": "${{ steps.create.namespace }}"},
+		},
+		Context: task,
+	})
+	if !result.Success {
+		t.Fatalf("delete failed: %s", result.Error)
+	}
+	if deletedName != "settings-x7k2p" || deletedNamespace != "web-x7k2p" {
+		t.Errorf("delete targeted %s/%s, want web-x7k2p/settings-x7k2p", deletedNamespace, deletedName)
+	}
+
+	// Outputs are scoped to the task that recorded them
+	result, _ = del(context.Background(), &sdk.OperationRequest{
+		Args: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "${{ steps.create.name }}"},
+		},
+		Context: protocol.ExecuteContext{Workdir: "/tasks/b"},
+	})
+	if result.Success {
+		t.Errorf("delete in another task resolved outputs of /tasks/a")
+	}
+}
+
+func TestTemplatingKeepsManifestStrings(t *testing.T) {
+	var created *unstructured.Unstructured
+	ext := New()
+	ext.client = &mockClient{
+		createFn: func(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+			created = obj.DeepCopy()
+			return obj, nil
+		},
+	}
+	req := &sdk.OperationRequest{
+		Args: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":      "settings",
+				"namespace": "default",
+				"labels":    map[string]any{"replicas": "${{ steps.scale.replicas }}"},
+			},
+			"data": map[string]any{"port": "${{ steps.scale.replicas }}"},
+		},
+		Context: protocol.ExecuteContext{Workdir: "/tasks/a"},
+	}
+	ext.recordOutputs(req, "scale", map[string]string{"replicas": "8080"})
+
+	result, err := ext.Execute(context.Background(), "create", req)
+	if err != nil || !result.Success {
+		t.Fatalf("create failed: %v %s", err, result.Error)
+	}
+	if port, ok := created.Object["data"].(map[string]any)["port"].(string); !ok || port != "8080" {
+		t.Errorf("data.port = %#v, want the string 8080", created.Object["data"].(map[string]any)["port"])
+	}
+	if label := created.GetLabels()["replicas"]; label != "8080" {
+		t.Errorf("label replicas = %q, want 8080", label)
+	}
+}
+
+func TestOutputsDroppedOnRerun(t *testing.T) {
+	ext := &Extension{Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"})}
+	create := ext.withTemplating("create", nil, func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		return sdk.SuccessWithOutputs("created", map[string]string{"name": "web-1"}), nil
+	})
+	echo := ext.withTemplating("echo", nil, func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		return sdk.Success(fmt.Sprint(req.Args)), nil
+	})
+	run := func(handler sdk.OperationHandler, phase string, args any) *sdk.OperationResult {
+		result, err := handler(context.Background(), &sdk.OperationRequest{Args: args, Context: protocol.ExecuteContext{Workdir: "/tasks/a", Phase: phase}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	reference := map[string]any{"name": "${{ steps.create.name }}"}
+
+	run(create, phaseSetup, nil)
+	for _, phase := range []string{phaseSetup, "verify", phaseCleanup} {
+		if result := run(echo, phase, reference); !result.Success {
+			t.Fatalf("reference in %s failed: %s", phase, result.Error)
+		}
+	}
+
+	// The next run of the task starts with setup again
+	result := run(echo, phaseSetup, reference)
+	if result.Success || !strings.Contains(result.Error, "no outputs recorded") {
+		t.Errorf("reference in the next run = %v, %q, want no outputs recorded", result.Success, result.Error)
+	}
+}
+
+func TestOverlappingRunsRefused(t *testing.T) {
+	ext := &Extension{Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"})}
+	calls := 0
+	step := ext.withTemplating("step", nil, func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		calls++
+		return sdk.Success("done"), nil
+	})
+	run := func(workdir, phase string) *sdk.OperationResult {
+		result, err := step(context.Background(), &sdk.OperationRequest{Context: protocol.ExecuteContext{Workdir: workdir, Phase: phase}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	run("/tasks/a", phaseSetup)
+	run("/tasks/a", "verify")
+
+	result := run("/tasks/a", phaseSetup)
+	if result.Success || !strings.Contains(result.Error, "already running in its verify phase") {
+		t.Errorf("setup during verify = %v, %q, want refused", result.Success, result.Error)
+	}
+	if got := result.Outputs["category"]; got != categoryInfra {
+		t.Errorf("category output = %q, want %q", got, categoryInfra)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+
+	if result := run("/tasks/b", phaseSetup); !result.Success {
+		t.Errorf("setup of another task failed: %s", result.Error)
+	}
+	run("/tasks/a", phaseCleanup)
+	if result := run("/tasks/a", phaseSetup); !result.Success {
+		t.Errorf("setup after cleanup failed: %s", result.Error)
+	}
+}
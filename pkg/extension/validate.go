@@ -0,0 +1,248 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+// withValidation wraps handler so that arguments not matching the schema of op fail
+// the operation before it runs, instead of being silently ignored.
+func withValidation(op operation, handler sdk.OperationHandler) sdk.OperationHandler {
+	return func(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
+		if problems := validateArgs(&op.params, req.Args); len(problems) > 0 {
+			err := fmt.Errorf("invalid arguments for %s:\n%s", op.name, strings.Join(problems, "\n"))
+			return failureWithCategory(categoryTask, "", err), nil
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ValidateStep checks the arguments of a step calling operation against the schema of
+// the operation, without running it. It returns one message per problem found, such
+// as an unknown operation, an unknown or missing property, or a value of the wrong type.
+func (e *Extension) ValidateStep(operation string, args any) []string {
+	schema, ok := e.schemas[operation]
+	if !ok {
+		return []string{fmt.Sprintf("unknown operation %q%s", operation, suggestion(operation, e.Operations()))}
+	}
+	return validateArgs(schema, args)
+}
+
+// validateArgs checks args against schema. Missing arguments are left to the handler,
+// which reports them as it always did.
+func validateArgs(schema *jsonschema.Schema, args any) []string {
+	if args == nil {
+		return nil
+	}
+	var problems []string
+	validateValue(schema, args, "", &problems)
+	return problems
+}
+
+// validateValue checks value against schema and appends the problems found to problems.
+// Objects with declared properties are closed: other keys are reported as unknown unless
+// the schema allows additional properties. Placeholders are checked the way they resolve
+// when the step runs: a whole-value placeholder may become a string, number or boolean,
+// while a placeholder within other text always yields a string.
+func validateValue(schema *jsonschema.Schema, value any, path string, problems *[]string) {
+	report := func(format string, args ...any) {
+		message := fmt.Sprintf(format, args...)
+		if path != "" {
+			message = path + ": " + message
+		}
+		*problems = append(*problems, message)
+	}
+
+	types := schema.Types
+	if schema.Type != "" {
+		types = []string{schema.Type}
+	}
+
+	s, isString := value.(string)
+	if isString && isWholePlaceholder(s) {
+		if len(types) > 0 && !slices.ContainsFunc(types, isScalarType) {
+			report("must be %s, got a placeholder, which resolves to a string, number or boolean", strings.Join(types, " or "))
+		}
+		return
+	}
+	templated := isString && hasPlaceholder(s)
+
+	if len(types) > 0 && !matchesAnyType(value, types) {
+		if templated {
+			report("must be %s, got a string with placeholders; only a placeholder making up the whole value resolves to a number or boolean", strings.Join(types, " or "))
+			return
+		}
+		report("must be %s, got %s", strings.Join(types, " or "), typeName(value))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !templated && !enumContains(schema.Enum, value) {
+		allowed := make([]string, 0, len(schema.Enum))
+		for _, item := range schema.Enum {
+			allowed = append(allowed, fmt.Sprint(item))
+		}
+		report("must be one of %s, got %s", strings.Join(allowed, ", "), formatValue(value))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range schema.Required {
+			if _, ok := v[key]; !ok {
+				*problems = append(*problems, joinPath(path, key)+": required property is missing")
+			}
+		}
+		if schema.Properties == nil {
+			return
+		}
+		for _, key := range sortedKeys(v) {
+			property, ok := schema.Properties[key]
+			if !ok {
+				if schema.AdditionalProperties == nil {
+					*problems = append(*problems, joinPath(path, key)+": unknown property"+suggestion(key, sortedKeys(schema.Properties)))
+				}
+				continue
+			}
+			validateValue(property, v[key], joinPath(path, key), problems)
+		}
+	case []any:
+		if schema.Items == nil {
+			return
+		}
+		for i, item := range v {
+			validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// matchesAnyType reports whether value is of one of the JSON schema types.
+func matchesAnyType(value any, types []string) bool {
+	for _, typ := range types {
+		switch typ {
+		case "integer":
+			if _, ok := toInt64(value); ok {
+				return true
+			}
+		case "number":
+			switch value.(type) {
+			case int, int32, int64, float64:
+				return true
+			}
+		default:
+			if typeName(value) == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isScalarType reports whether a whole-value placeholder can resolve to a value of typ.
+func isScalarType(typ string) bool {
+	switch typ {
+	case "string", "number", "integer", "boolean":
+		return true
+	}
+	return false
+}
+
+// hasPlaceholder reports whether s contains a placeholder that is resolved when the step runs.
+func hasPlaceholder(s string) bool {
+	for _, match := range templatePattern.FindAllString(s, -1) {
+		if _, ok := placeholderRef(match); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON schema type of a decoded JSON value.
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64:
+		return "integer"
+	case float64:
+		if _, ok := toInt64(value); ok {
+			return "integer"
+		}
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, item := range enum {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue formats a value for messages, quoting strings.
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggestion returns ` (did you mean "x"?)` for the candidate closest to name, or an
+// empty string if no candidate is close enough to be a likely typo.
+func suggestion(name string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if best == "" || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,222 @@
+package extension
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+)
+
+func TestValidateStep(t *testing.T) {
+	ext := New()
+
+	tests := []struct {
+		name      string
+		operation string
+		args      any
+		want      []string
+	}{
+		{
+			name:      "valid arguments",
+			operation: "delete",
+			args: map[string]any{
+				"apiVersion":         "v1",
+				"kind":               "Namespace",
+				"metadata":           map[string]any{"name": "demo", "labels": map[string]any{"app": "web"}},
+				"ignoreNotFound":     true,
+				"gracePeriodSeconds": float64(0),
+				"preconditions":      map[string]any{"uid": "1234"},
+			},
+		},
+		{
+			name:      "no arguments",
+			operation: "listContexts",
+		},
+		{
+			name:      "manifest fields of create",
+			operation: "create",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "settings"},
+				"data":       map[string]any{"mode": "fast"},
+			},
+		},
+		{
+			name:      "unknown property with suggestion",
+			operation: "delete",
+			args:      map[string]any{"apiVersion": "v1", "kind": "Pod", "ignoreNotfound": true},
+			want:      []string{`ignoreNotfound: unknown property (did you mean "ignoreNotFound"?)`},
+		},
+		{
+			name:      "unknown property without suggestion",
+			operation: "getContext",
+			args:      map[string]any{"cluster": "dev"},
+			want:      []string{"cluster: unknown property"},
+		},
+		{
+			name:      "unknown nested property",
+			operation: "authCanI",
+			args: map[string]any{
+				"verb":     "get",
+				"resource": "pods",
+				"as":       "system:serviceaccount:demo:app",
+				"expect":   map[string]any{"alowed": true},
+			},
+			want: []string{`expect.alowed: unknown property (did you mean "allowed"?)`},
+		},
+		{
+			name:      "wrong types",
+			operation: "delete",
+			args: map[string]any{
+				"apiVersion":         "v1",
+				"kind":               "Pod",
+				"ignoreNotFound":     "yes",
+				"gracePeriodSeconds": 1.5,
+				"preconditions":      map[string]any{"uid": float64(1)},
+			},
+			want: []string{
+				"gracePeriodSeconds: must be integer, got number",
+				"ignoreNotFound: must be boolean, got string",
+				"preconditions.uid: must be string, got integer",
+			},
+		},
+		{
+			name:      "enum",
+			operation: "delete",
+			args:      map[string]any{"apiVersion": "v1", "kind": "Pod", "propagationPolicy": "Cascade"},
+			want:      []string{`propagationPolicy: must be one of Foreground, Background, Orphan, got "Cascade"`},
+		},
+		{
+			name:      "array items",
+			operation: "createConfigMap",
+			args: map[string]any{
+				"metadata":    map[string]any{"name": "settings"},
+				"fromLiteral": []any{"a=1", float64(2)},
+			},
+			want: []string{"fromLiteral[1]: must be string, got integer"},
+		},
+		{
+			name:      "multiple types",
+			operation: "serviceEndpoints",
+			args: map[string]any{
+				"metadata": map[string]any{"name": "web"},
+				"ports":    []any{float64(80), "http", true},
+			},
+			want: []string{"ports[2]: must be integer or string, got boolean"},
+		},
+		{
+			name:      "missing required property",
+			operation: "authCanI",
+			args:      map[string]any{"verb": "get"},
+			want:      []string{"resource: required property is missing", "as: required property is missing"},
+		},
+		{
+			name:      "whole placeholders match scalar types",
+			operation: "scale",
+			args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "${{ steps.create.name }}"},
+				"replicas":   "${{ steps.scale.replicas }}",
+				"wait":       "${{ env.WAIT }}",
+			},
+		},
+		{
+			name:      "placeholders within text are strings",
+			operation: "scale",
+			args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web-${{ steps.create.name }}"},
+				"replicas":   "${{ steps.scale.replicas }}0",
+			},
+			want: []string{"replicas: must be integer, got a string with placeholders; only a placeholder making up the whole value resolves to a number or boolean"},
+		},
+		{
+			name:      "placeholder for an object",
+			operation: "wait",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   "${{ steps.create.metadata }}",
+				"condition":  "Ready",
+			},
+			want: []string{"metadata: must be object, got a placeholder, which resolves to a string, number or boolean"},
+		},
+		{
+			name:      "escaped placeholder is a plain string",
+			operation: "scale",
+			args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web"},
+				"replicas":   "$${{ steps.scale.replicas }}",
+			},
+			want: []string{"replicas: must be integer, got string"},
+		},
+		{
+			name:      "arguments not an object",
+			operation: "wait",
+			args:      []any{"pods"},
+			want:      []string{"must be object, got array"},
+		},
+		{
+			name:      "unknown operation",
+			operation: "wiat",
+			args:      map[string]any{},
+			want:      []string{`unknown operation "wiat" (did you mean "wait"?)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ext.ValidateStep(tt.operation, tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateStep() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteRejectsInvalidArguments(t *testing.T) {
+	ext := New()
+	req := &sdk.OperationRequest{Args: map[string]any{"timout": "10s"}}
+	req.Context.Workdir = t.TempDir()
+
+	result, err := ext.Execute(context.Background(), "waitForAPI", req)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Success {
+		t.Fatal("Execute() succeeded with an unknown argument")
+	}
+	for _, want := range []string{"invalid arguments for waitForAPI", `timout: unknown property (did you mean "timeout"?)`} {
+		if !strings.Contains(result.Error, want) {
+			t.Errorf("error %q does not contain %q", result.Error, want)
+		}
+	}
+	if result.Outputs["category"] != categoryTask {
+		t.Errorf("category = %q, want %q", result.Outputs["category"], categoryTask)
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	candidates := []string{"timeout", "ignoreNotFound", "interval"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "timout", want: ` (did you mean "timeout"?)`},
+		{name: "Timeout", want: ` (did you mean "timeout"?)`},
+		{name: "ignorenotfound", want: ` (did you mean "ignoreNotFound"?)`},
+		{name: "replicas", want: ""},
+	}
+	for _, tt := range tests {
+		if got := suggestion(tt.name, candidates); got != tt.want {
+			t.Errorf("suggestion(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
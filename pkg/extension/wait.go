@@ -2,37 +2,31 @@ package extension
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func (e *Extension) handleWait(ctx context.Context, req *sdk.OperationRequest) (*sdk.OperationResult, error) {
 	if e.client == nil {
-		return sdk.Failure(fmt.Errorf("kubernetes client not initialized")), nil
+		return failure(errClientNotInitialized), nil
 	}
 
 	args, ok := req.Args.(map[string]any)
 	if !ok {
-		return sdk.Failure(fmt.Errorf("args must be an object")), nil
+		return failure(fmt.Errorf("args must be an object")), nil
 	}
 
-	ref, err := parseResourceRef(args)
+	check, err := parseObjectCheck(args)
 	if err != nil {
-		return sdk.Failure(err), nil
-	}
-
-	condition, _ := args["condition"].(string)
-	if condition == "" {
-		return sdk.Failure(fmt.Errorf("condition is required")), nil
-	}
-
-	status, _ := args["status"].(string)
-	if status == "" {
-		status = "True"
+		return failure(err), nil
 	}
 
 	timeoutStr, _ := args["timeout"].(string)
@@ -42,74 +36,251 @@ func (e *Extension) handleWait(ctx context.Context, req *sdk.OperationRequest) (
 
 	timeout, err := time.ParseDuration(timeoutStr)
 	if err != nil {
-		return sdk.Failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+		return failure(fmt.Errorf("invalid timeout format: %w", err)), nil
+	}
+
+	// Selectors and lists of references wait on several objects at once
+	_, hasSelector := args["selector"]
+	_, hasResources := args["resources"]
+	if hasSelector || hasResources {
+		return e.waitForMany(ctx, args, check, timeout), nil
+	}
+
+	ref, err := parseResourceRef(args)
+	if err != nil {
+		return failure(err), nil
 	}
 
-	gvr, err := ref.gvr()
+	gvr, err := e.resourceFor(ref)
 	if err != nil {
-		return sdk.Failure(err), nil
+		return failure(err), nil
 	}
 
 	e.LogInfo(ctx, "Waiting for condition", map[string]any{
 		"kind":      ref.kind,
 		"name":      ref.name,
 		"namespace": ref.namespace,
-		"condition": condition,
-		"status":    status,
+		"check":     check.String(),
 		"timeout":   timeoutStr,
 	})
 
 	var lastStatus string
+	var lastErr error
+	var lastObj *unstructured.Unstructured
 	err = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
 		obj, getErr := e.client.Get(ctx, gvr, ref.name, ref.namespace)
 		if getErr != nil {
-			return false, nil // Keep polling on transient errors
-		}
-
-		conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
-		if err != nil || !found {
-			lastStatus = "NoConditions"
-			return false, nil
-		}
-
-		for _, c := range conditions {
-			cond, ok := c.(map[string]any)
-			if !ok {
-				continue
-			}
-
-			condType, _, _ := unstructured.NestedString(cond, "type")
-			condStatus, _, _ := unstructured.NestedString(cond, "status")
-
-			if condType == condition {
-				lastStatus = condStatus
-				return condStatus == status, nil
+			lastErr = getErr
+			lastStatus = getErrorStatus(getErr)
+			if isPermanentError(getErr) {
+				return false, getErr
 			}
+			return false, nil // Keep polling on transient errors and until the object exists
 		}
+		lastErr = nil
+		lastObj = obj
 
-		lastStatus = "ConditionNotFound"
-		return false, nil
+		var met bool
+		lastStatus, met = check.evaluate(obj)
+		return met, nil
 	})
 
+	if err != nil && lastErr != nil && isPermanentError(lastErr) {
+		e.LogError(ctx, "Condition wait failed", map[string]any{
+			"kind":  ref.kind,
+			"name":  ref.name,
+			"error": lastErr.Error(),
+		})
+		return failure(fmt.Errorf("failed to get %s/%s: %w", ref.kind, ref.name, lastErr)), nil
+	}
+
 	if err != nil {
 		e.LogError(ctx, "Condition wait timed out", map[string]any{
 			"kind":       ref.kind,
 			"name":       ref.name,
-			"condition":  condition,
+			"check":      check.String(),
 			"lastStatus": lastStatus,
 		})
-		return sdk.FailureWithMessage(
-			fmt.Sprintf("Condition %s=%s not met", condition, status),
-			fmt.Errorf("timed out waiting for %s/%s: last status was %s", ref.kind, ref.name, lastStatus),
-		), nil
+
+		details := []string{fmt.Sprintf("timed out waiting for %s/%s: last status was %s", ref.kind, ref.name, lastStatus)}
+		if lastErr != nil {
+			details = append(details, "last error: "+lastErr.Error())
+		}
+		if lastObj != nil {
+			details = append(details, "object: "+summarizeObject(lastObj))
+			details = append(details, "conditions: "+formatConditions(lastObj))
+		}
+
+		// The agent failed if the object exists but never reached the condition;
+		// if it could never be read, the cluster is the more likely culprit.
+		category := categoryInfra
+		if lastObj != nil {
+			category = categoryAgent
+		}
+		result := failureWithCategory(category,
+			fmt.Sprintf("Condition %s not met", check),
+			errors.New(strings.Join(details, "\n")),
+		)
+		result.Outputs["lastStatus"] = lastStatus
+		if lastErr != nil {
+			result.Outputs["lastError"] = lastErr.Error()
+		}
+		if lastObj != nil {
+			result.Outputs["object"] = summarizeObject(lastObj)
+			result.Outputs["conditions"] = conditionsJSON(lastObj)
+		}
+		return result, nil
 	}
 
 	e.LogInfo(ctx, "Condition met", map[string]any{
-		"kind":      ref.kind,
-		"name":      ref.name,
-		"condition": condition,
-		"status":    status,
+		"kind":  ref.kind,
+		"name":  ref.name,
+		"check": check.String(),
 	})
 
-	return sdk.Success(fmt.Sprintf("%s/%s condition %s=%s", ref.kind, ref.name, condition, status)), nil
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%s/%s condition %s", ref.kind, ref.name, check),
+		map[string]string{
+			"value": lastStatus,
+		},
+	), nil
+}
+
+// findCondition returns the status of the condition with the given type from status.conditions.
+// When the condition is missing, it returns NoConditions or ConditionNotFound and false.
+func findCondition(obj *unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return "NoConditions", false
+	}
+
+	for _, c := range conditions {
+		cond, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		condType, _, _ := unstructured.NestedString(cond, "type")
+		condStatus, _, _ := unstructured.NestedString(cond, "status")
+
+		if condType == conditionType {
+			return condStatus, true
+		}
+	}
+
+	return "ConditionNotFound", false
+}
+
+// getErrorStatus describes a failed Get for the last status of a wait.
+func getErrorStatus(err error) string {
+	switch {
+	case apierrors.IsNotFound(err) && !isUnknownResource(err):
+		return "NotFound"
+	case apierrors.IsForbidden(err):
+		return "Forbidden"
+	case isUnknownResource(err):
+		return "UnknownResource"
+	case isConnectionError(err), classifyError(err) == categoryInfra:
+		return "Unreachable"
+	default:
+		return "GetFailed"
+	}
+}
+
+// isPermanentError reports whether a failed Get will not succeed by waiting longer,
+// so a wait can fail right away instead of running into its timeout.
+func isPermanentError(err error) bool {
+	return apierrors.IsForbidden(err) ||
+		apierrors.IsUnauthorized(err) ||
+		apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err) ||
+		isUnknownResource(err)
+}
+
+// conditions returns the entries of status.conditions that are objects.
+func conditions(obj *unstructured.Unstructured) []map[string]any {
+	items, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	result := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if cond, ok := item.(map[string]any); ok {
+			result = append(result, cond)
+		}
+	}
+	return result
+}
+
+// formatConditions renders status.conditions as type=status (reason): message entries.
+func formatConditions(obj *unstructured.Unstructured) string {
+	conds := conditions(obj)
+	if len(conds) == 0 {
+		return "none"
+	}
+
+	parts := make([]string, 0, len(conds))
+	for _, cond := range conds {
+		condType, _, _ := unstructured.NestedString(cond, "type")
+		condStatus, _, _ := unstructured.NestedString(cond, "status")
+		reason, _, _ := unstructured.NestedString(cond, "reason")
+		message, _, _ := unstructured.NestedString(cond, "message")
+
+		part := condType + "=" + condStatus
+		if reason != "" {
+			part += " (" + reason + ")"
+		}
+		if message != "" {
+			part += ": " + message
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// conditionsJSON returns status.conditions as JSON, with type, status, reason and message only.
+func conditionsJSON(obj *unstructured.Unstructured) string {
+	type condition struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Reason  string `json:"reason,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	result := []condition{}
+	for _, cond := range conditions(obj) {
+		var c condition
+		c.Type, _, _ = unstructured.NestedString(cond, "type")
+		c.Status, _, _ = unstructured.NestedString(cond, "status")
+		c.Reason, _, _ = unstructured.NestedString(cond, "reason")
+		c.Message, _, _ = unstructured.NestedString(cond, "message")
+		result = append(result, c)
+	}
+	data, _ := json.Marshal(result)
+	return string(data)
+}
+
+// summarizeObject returns a one-line summary of obj: its name, whether it is being
+// deleted, how far its controller has caught up, and its phase and replica counts.
+func summarizeObject(obj *unstructured.Unstructured) string {
+	name := obj.GetName()
+	if obj.GetNamespace() != "" {
+		name = obj.GetNamespace() + "/" + name
+	}
+	parts := []string{obj.GetKind() + " " + name}
+
+	if obj.GetDeletionTimestamp() != nil {
+		parts = append(parts, "terminating")
+	}
+	if generation := obj.GetGeneration(); generation != 0 {
+		parts = append(parts, fmt.Sprintf("generation=%d", generation))
+		if observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found {
+			parts = append(parts, fmt.Sprintf("observedGeneration=%d", observed))
+		}
+	}
+	if phase, found, _ := unstructured.NestedString(obj.Object, "status", "phase"); found {
+		parts = append(parts, "phase="+phase)
+	}
+	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		parts = append(parts, fmt.Sprintf("ready=%d/%d", ready, replicas))
+	}
+	return strings.Join(parts, " ")
 }
@@ -2,9 +2,12 @@ package extension
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -64,6 +67,27 @@ func TestHandleWait(t *testing.T) {
 			},
 			wantSuccess: false,
 		},
+		{
+			name: "jsonPath value reached",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]any{"name": "test", "namespace": "default"},
+				"jsonPath":   "{.status.phase}",
+				"value":      "Running",
+				"timeout":    "1s",
+			},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					return &unstructured.Unstructured{
+						Object: map[string]any{
+							"status": map[string]any{"phase": "Running"},
+						},
+					}, nil
+				},
+			},
+			wantSuccess: true,
+		},
 		{
 			name: "missing condition field",
 			args: map[string]any{
@@ -95,3 +119,198 @@ func TestHandleWait(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleWaitTimeoutCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		getFn        func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error)
+		wantCategory string
+	}{
+		{
+			name: "object exists but condition never met",
+			getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+				return &unstructured.Unstructured{Object: map[string]any{}}, nil
+			},
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "object never read",
+			getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+				return nil, apierrors.NewServiceUnavailable("down")
+			},
+			wantCategory: categoryInfra,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    &mockClient{getFn: tt.getFn},
+			}
+
+			req := &sdk.OperationRequest{Args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "nginx", "namespace": "default"},
+				"condition":  "Available",
+				"timeout":    "1s",
+			}}
+			result, err := ext.handleWait(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleWait() returned error: %v", err)
+			}
+			if result.Success {
+				t.Fatalf("handleWait() succeeded, want timeout")
+			}
+			if got := result.Outputs["category"]; got != tt.wantCategory {
+				t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestHandleWaitFailsFastOnPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+	}{
+		{
+			name:       "forbidden",
+			err:        apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "nginx", fmt.Errorf("denied")),
+			wantReason: "Forbidden",
+		},
+		{
+			name:       "unknown resource type",
+			err:        apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deploymentz"}, ""),
+			wantReason: "NotFound",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client: &mockClient{
+					getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+						calls++
+						return nil, tt.err
+					},
+				},
+			}
+
+			req := &sdk.OperationRequest{Args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "nginx", "namespace": "default"},
+				"condition":  "Available",
+				"timeout":    "30s",
+			}}
+			result, err := ext.handleWait(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleWait() returned error: %v", err)
+			}
+			if result.Success {
+				t.Fatalf("handleWait() succeeded, want failure")
+			}
+			if calls != 1 {
+				t.Errorf("Get called %d times, want 1", calls)
+			}
+			if got := result.Outputs["reason"]; got != tt.wantReason {
+				t.Errorf("reason output = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestHandleWaitTimeoutDetails(t *testing.T) {
+	tests := []struct {
+		name           string
+		getFn          func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error)
+		wantLastStatus string
+		wantOutputs    map[string]string
+		wantInError    []string
+	}{
+		{
+			name: "condition false",
+			getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+				return &unstructured.Unstructured{Object: map[string]any{
+					"kind":     "Deployment",
+					"metadata": map[string]any{"name": "nginx", "namespace": "default", "generation": int64(2)},
+					"spec":     map[string]any{"replicas": int64(3)},
+					"status": map[string]any{
+						"observedGeneration": int64(2),
+						"readyReplicas":      int64(1),
+						"conditions": []any{
+							map[string]any{
+								"type":    "Available",
+								"status":  "False",
+								"reason":  "MinimumReplicasUnavailable",
+								"message": "Deployment does not have minimum availability.",
+							},
+						},
+					},
+				}}, nil
+			},
+			wantLastStatus: "False",
+			wantOutputs: map[string]string{
+				"object":     "Deployment default/nginx generation=2 observedGeneration=2 ready=1/3",
+				"conditions": `[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable","message":"Deployment does not have minimum availability."}]`,
+			},
+			wantInError: []string{"Available=False (MinimumReplicasUnavailable): Deployment does not have minimum availability."},
+		},
+		{
+			name: "object not found",
+			getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+				return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, name)
+			},
+			wantLastStatus: "NotFound",
+			wantOutputs: map[string]string{
+				"lastError": `deployments.apps "nginx" not found`,
+			},
+			wantInError: []string{"last error: deployments.apps \"nginx\" not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    &mockClient{getFn: tt.getFn},
+			}
+
+			req := &sdk.OperationRequest{Args: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "nginx", "namespace": "default"},
+				"condition":  "Available",
+				"timeout":    "1s",
+			}}
+			result, err := ext.handleWait(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleWait() returned error: %v", err)
+			}
+			if result.Success {
+				t.Fatalf("handleWait() succeeded, want timeout")
+			}
+			if got := result.Outputs["lastStatus"]; got != tt.wantLastStatus {
+				t.Errorf("lastStatus output = %q, want %q", got, tt.wantLastStatus)
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			for _, want := range tt.wantInError {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Modes for waiting on several objects.
+const (
+	waitModeAll = "all"
+	waitModeAny = "any"
+)
+
+// waitTargets selects the objects a wait observes, either by a list of references or
+// by label and field selectors on one kind.
+type waitTargets struct {
+	// refs lists the referenced objects, if the wait is on a list of references.
+	refs []*resourceRef
+
+	// kind, namespace and the selectors describe the objects of a selector wait.
+	apiVersion    string
+	kind          string
+	namespace     string
+	labelSelector string
+	fieldSelector string
+}
+
+// targetObservation is the state of one object at one poll.
+type targetObservation struct {
+	// name identifies the object as Kind namespace/name.
+	name string
+	// obj is the object, or nil if it could not be read.
+	obj *unstructured.Unstructured
+	// status is the current condition status, or why the object could not be read.
+	status string
+	// met reports whether the object meets the condition.
+	met bool
+	// err is the error reading the object, if any.
+	err error
+}
+
+// parseWaitTargets reads the resources list, the selector or a single resource reference
+// from wait arguments. Entries of the resources list default to the top-level apiVersion,
+// kind and namespace.
+func parseWaitTargets(args map[string]any) (*waitTargets, error) {
+	apiVersion, _ := args["apiVersion"].(string)
+	kind, _ := args["kind"].(string)
+	namespace := ""
+	if metadata, ok := args["metadata"].(map[string]any); ok {
+		namespace, _ = metadata["namespace"].(string)
+	}
+
+	if _, ok := args["selector"]; ok && args["resources"] != nil {
+		return nil, fmt.Errorf("selector and resources cannot be combined")
+	}
+
+	if items, ok := args["resources"]; ok {
+		list, ok := items.([]any)
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("resources must be a non-empty list of resource references")
+		}
+
+		targets := &waitTargets{}
+		for i, item := range list {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("resources[%d] must be an object", i)
+			}
+			withDefaults := map[string]any{"apiVersion": apiVersion, "kind": kind}
+			for key, value := range entry {
+				withDefaults[key] = value
+			}
+			ref, err := parseResourceRef(withDefaults)
+			if err != nil {
+				return nil, fmt.Errorf("resources[%d]: %w", i, err)
+			}
+			if ref.namespace == "" {
+				ref.namespace = namespace
+			}
+			targets.refs = append(targets.refs, ref)
+		}
+		return targets, nil
+	}
+
+	if _, ok := args["selector"]; !ok {
+		ref, err := parseResourceRef(args)
+		if err != nil {
+			return nil, err
+		}
+		return &waitTargets{refs: []*resourceRef{ref}}, nil
+	}
+
+	if apiVersion == "" {
+		return nil, fmt.Errorf("apiVersion is required")
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("kind is required")
+	}
+
+	targets := &waitTargets{apiVersion: apiVersion, kind: kind, namespace: namespace}
+	switch selector := args["selector"].(type) {
+	case string:
+		targets.labelSelector = selector
+	case map[string]any:
+		targets.labelSelector, _ = selector["labels"].(string)
+		targets.fieldSelector, _ = selector["fields"].(string)
+	default:
+		return nil, fmt.Errorf("selector must be a label selector string or an object with labels and fields")
+	}
+	if targets.labelSelector == "" && targets.fieldSelector == "" {
+		return nil, fmt.Errorf("selector must not be empty")
+	}
+	return targets, nil
+}
+
+// String describes the targets for messages.
+func (t *waitTargets) String() string {
+	if len(t.refs) == 1 {
+		return refName(t.refs[0])
+	}
+	if t.refs != nil {
+		return fmt.Sprintf("%d resources", len(t.refs))
+	}
+	selectors := make([]string, 0, 2)
+	for _, selector := range []string{t.labelSelector, t.fieldSelector} {
+		if selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	return fmt.Sprintf("%s matching %s", t.kind, strings.Join(selectors, ","))
+}
+
+// observe reads every target and checks the condition on it. An error is returned
+// only when waiting longer cannot help, such as a forbidden or unknown resource type.
+func (e *Extension) observe(ctx context.Context, t *waitTargets, check *objectCheck) ([]targetObservation, error) {
+	if t.refs == nil {
+		return e.observeSelector(ctx, t, check)
+	}
+
+	observations := make([]targetObservation, 0, len(t.refs))
+	for _, ref := range t.refs {
+		name := refName(ref)
+
+		gvr, err := e.resourceFor(ref)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := e.client.Get(ctx, gvr, ref.name, ref.namespace)
+		if err != nil {
+			if isPermanentError(err) {
+				return nil, fmt.Errorf("failed to get %s: %w", name, err)
+			}
+			observations = append(observations, targetObservation{name: name, status: getErrorStatus(err), err: err})
+			continue
+		}
+
+		status, met := check.evaluate(obj)
+		observations = append(observations, targetObservation{name: name, obj: obj, status: status, met: met})
+	}
+	return observations, nil
+}
+
+// refName identifies a referenced object as Kind namespace/name.
+func refName(ref *resourceRef) string {
+	if ref.namespace == "" {
+		return ref.kind + " " + ref.name
+	}
+	return ref.kind + " " + ref.namespace + "/" + ref.name
+}
+
+// observeSelector lists the objects matching the selectors and checks the condition on each.
+func (e *Extension) observeSelector(ctx context.Context, t *waitTargets, check *objectCheck) ([]targetObservation, error) {
+	gv, err := schema.ParseGroupVersion(t.apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion: %w", err)
+	}
+
+	list, err := e.client.List(ctx, e.gvrFor(gv.WithKind(t.kind)), t.namespace, metav1.ListOptions{
+		LabelSelector: t.labelSelector,
+		FieldSelector: t.fieldSelector,
+	})
+	if err != nil {
+		if isPermanentError(err) {
+			return nil, fmt.Errorf("failed to list %s: %w", t, err)
+		}
+		// Report the failed list as a single observation, so the wait keeps polling
+		return []targetObservation{{name: t.String(), status: getErrorStatus(err), err: err}}, nil
+	}
+
+	observations := make([]targetObservation, 0, len(list.Items))
+	for i := range list.Items {
+		obj := &list.Items[i]
+		name := t.kind + " " + obj.GetName()
+		if obj.GetNamespace() != "" {
+			name = t.kind + " " + obj.GetNamespace() + "/" + obj.GetName()
+		}
+		status, met := check.evaluate(obj)
+		observations = append(observations, targetObservation{name: name, obj: obj, status: status, met: met})
+	}
+	return observations, nil
+}
+
+// parseWaitMode reads the mode and minCount arguments of a wait on several objects.
+func parseWaitMode(args map[string]any) (string, int, error) {
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = waitModeAll
+	}
+	if mode != waitModeAll && mode != waitModeAny {
+		return "", 0, fmt.Errorf("invalid mode %q: must be all or any", mode)
+	}
+
+	minCount := 1
+	if value, ok := args["minCount"]; ok {
+		n, ok := toInt64(value)
+		if !ok || n < 0 {
+			return "", 0, fmt.Errorf("minCount must be a non-negative integer")
+		}
+		minCount = int(n)
+	}
+	return mode, minCount, nil
+}
+
+// satisfied reports whether the observations satisfy the wait. In mode all every
+// object must meet the condition, in mode any a single one is enough; in both modes
+// at least minCount objects must meet it.
+func satisfied(observations []targetObservation, mode string, minCount int) bool {
+	met := countMet(observations)
+	if met < minCount {
+		return false
+	}
+	if mode == waitModeAny {
+		return met > 0
+	}
+	return met == len(observations)
+}
+
+// countMet returns how many observations meet the condition.
+func countMet(observations []targetObservation) int {
+	met := 0
+	for _, o := range observations {
+		if o.met {
+			met++
+		}
+	}
+	return met
+}
+
+// observationsJSON returns the per-object status of observations as JSON.
+func observationsJSON(observations []targetObservation) string {
+	type objectStatus struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Met    bool   `json:"met"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	result := make([]objectStatus, 0, len(observations))
+	for _, o := range observations {
+		s := objectStatus{Name: o.name, Status: o.status, Met: o.met}
+		if o.err != nil {
+			s.Error = o.err.Error()
+		}
+		result = append(result, s)
+	}
+	data, _ := json.Marshal(result)
+	return string(data)
+}
+
+// waitForMany waits until the objects selected by args meet the condition, sharing one timeout.
+func (e *Extension) waitForMany(ctx context.Context, args map[string]any, check *objectCheck, timeout time.Duration) *sdk.OperationResult {
+	targets, err := parseWaitTargets(args)
+	if err != nil {
+		return failure(err)
+	}
+
+	mode, minCount, err := parseWaitMode(args)
+	if err != nil {
+		return failure(err)
+	}
+
+	e.LogInfo(ctx, "Waiting for condition on multiple resources", map[string]any{
+		"targets":  targets.String(),
+		"check":    check.String(),
+		"mode":     mode,
+		"minCount": minCount,
+		"timeout":  timeout.String(),
+	})
+
+	var observations []targetObservation
+	var permanentErr error
+	err = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		observations, permanentErr = e.observe(ctx, targets, check)
+		if permanentErr != nil {
+			return false, permanentErr
+		}
+		return satisfied(observations, mode, minCount), nil
+	})
+
+	if permanentErr != nil {
+		e.LogError(ctx, "Condition wait failed", map[string]any{
+			"targets": targets.String(),
+			"error":   permanentErr.Error(),
+		})
+		return failure(permanentErr)
+	}
+
+	met := countMet(observations)
+	if err != nil {
+		e.LogError(ctx, "Condition wait timed out", map[string]any{
+			"targets": targets.String(),
+			"met":     met,
+			"count":   len(observations),
+		})
+
+		details := []string{fmt.Sprintf("timed out waiting for %s: %d of %d met %s (mode %s, minCount %d)",
+			targets, met, len(observations), check, mode, minCount)}
+		category := categoryInfra
+		for _, o := range observations {
+			line := fmt.Sprintf("%s: %s", o.name, o.status)
+			if o.err != nil {
+				line += " (" + o.err.Error() + ")"
+			}
+			if o.obj != nil {
+				line += "; conditions: " + formatConditions(o.obj)
+				category = categoryAgent
+			}
+			details = append(details, line)
+		}
+		// Having too few objects means the agent did not create them
+		if targets.refs == nil && len(observations) < minCount && !hasErrors(observations) {
+			category = categoryAgent
+		}
+
+		result := failureWithCategory(category,
+			fmt.Sprintf("Condition %s not met on %s", check, targets),
+			errors.New(strings.Join(details, "\n")),
+		)
+		result.Outputs["count"] = fmt.Sprintf("%d", len(observations))
+		result.Outputs["met"] = fmt.Sprintf("%d", met)
+		result.Outputs["objects"] = observationsJSON(observations)
+		return result
+	}
+
+	e.LogInfo(ctx, "Condition met", map[string]any{
+		"targets": targets.String(),
+		"met":     met,
+		"count":   len(observations),
+	})
+
+	return sdk.SuccessWithOutputs(
+		fmt.Sprintf("%d of %d %s meet condition %s", met, len(observations), targets, check),
+		map[string]string{
+			"count":   fmt.Sprintf("%d", len(observations)),
+			"met":     fmt.Sprintf("%d", met),
+			"objects": observationsJSON(observations),
+		},
+	)
+}
+
+// hasErrors reports whether any object could not be read.
+func hasErrors(observations []targetObservation) bool {
+	for _, o := range observations {
+		if o.err != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mcpchecker/mcpchecker/pkg/extension/sdk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podWithReady returns a pod in default with the given Ready condition status.
+func podWithReady(name, ready string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": "default"},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": ready},
+			},
+		},
+	}}
+}
+
+// listPods returns a List function serving pods.
+func listPods(pods ...unstructured.Unstructured) func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		return &unstructured.UnstructuredList{Items: pods}, nil
+	}
+}
+
+func TestHandleWaitMany(t *testing.T) {
+	selectorArgs := func(extra map[string]any) map[string]any {
+		args := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"namespace": "default"},
+			"selector":   "app=web",
+			"condition":  "Ready",
+			"timeout":    "1s",
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+		return args
+	}
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		client       *mockClient
+		wantSuccess  bool
+		wantOutputs  map[string]string
+		wantCategory string
+	}{
+		{
+			name:        "all selected pods ready",
+			args:        selectorArgs(nil),
+			client:      &mockClient{listFn: listPods(podWithReady("web-1", "True"), podWithReady("web-2", "True"))},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "2", "met": "2"},
+		},
+		{
+			name:        "one selected pod not ready",
+			args:        selectorArgs(nil),
+			client:      &mockClient{listFn: listPods(podWithReady("web-1", "True"), podWithReady("web-2", "False"))},
+			wantSuccess: false,
+			wantOutputs: map[string]string{
+				"count":   "2",
+				"met":     "1",
+				"objects": `[{"name":"Pod default/web-1","status":"True","met":true},{"name":"Pod default/web-2","status":"False","met":false}]`,
+			},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:        "any mode with one pod ready",
+			args:        selectorArgs(map[string]any{"mode": "any"}),
+			client:      &mockClient{listFn: listPods(podWithReady("web-1", "True"), podWithReady("web-2", "False"))},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "2", "met": "1"},
+		},
+		{
+			name:         "no pods selected",
+			args:         selectorArgs(nil),
+			client:       &mockClient{listFn: listPods()},
+			wantSuccess:  false,
+			wantOutputs:  map[string]string{"count": "0", "met": "0"},
+			wantCategory: categoryAgent,
+		},
+		{
+			name:         "fewer pods ready than minCount",
+			args:         selectorArgs(map[string]any{"minCount": float64(3)}),
+			client:       &mockClient{listFn: listPods(podWithReady("web-1", "True"), podWithReady("web-2", "True"))},
+			wantSuccess:  false,
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "field and label selectors",
+			args: selectorArgs(map[string]any{"selector": map[string]any{"labels": "app=web", "fields": "status.phase=Running"}}),
+			client: &mockClient{
+				listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+					if opts.LabelSelector != "app=web" || opts.FieldSelector != "status.phase=Running" || namespace != "default" {
+						return nil, fmt.Errorf("unexpected list options %+v in %q", opts, namespace)
+					}
+					return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{podWithReady("web-1", "True")}}, nil
+				},
+			},
+			wantSuccess: true,
+		},
+		{
+			name: "list of resources",
+			args: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]any{"namespace": "default"},
+				"resources": []any{
+					map[string]any{"metadata": map[string]any{"name": "web-1"}},
+					map[string]any{"metadata": map[string]any{"name": "web-2"}},
+				},
+				"condition": "Ready",
+				"timeout":   "1s",
+			},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					pod := podWithReady(name, "True")
+					return &pod, nil
+				},
+			},
+			wantSuccess: true,
+			wantOutputs: map[string]string{"count": "2", "met": "2"},
+		},
+		{
+			name: "missing resource in list",
+			args: map[string]any{
+				"resources": []any{
+					map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "web-1", "namespace": "default"}},
+					map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "web-2", "namespace": "default"}},
+				},
+				"condition": "Ready",
+				"timeout":   "1s",
+			},
+			client: &mockClient{
+				getFn: func(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+					if name == "web-2" {
+						return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+					}
+					pod := podWithReady(name, "True")
+					return &pod, nil
+				},
+			},
+			wantSuccess: false,
+			wantOutputs: map[string]string{
+				"objects": `[{"name":"Pod default/web-1","status":"True","met":true},{"name":"Pod default/web-2","status":"NotFound","met":false,"error":"pods \"web-2\" not found"}]`,
+			},
+			wantCategory: categoryAgent,
+		},
+		{
+			name: "forbidden list fails fast",
+			args: selectorArgs(map[string]any{"timeout": "30s"}),
+			client: &mockClient{
+				listFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+					return nil, apierrors.NewForbidden(gvr.GroupResource(), "", fmt.Errorf("denied"))
+				},
+			},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "selector and resources combined",
+			args:         selectorArgs(map[string]any{"resources": []any{}}),
+			client:       &mockClient{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+		{
+			name:         "invalid mode",
+			args:         selectorArgs(map[string]any{"mode": "most"}),
+			client:       &mockClient{},
+			wantSuccess:  false,
+			wantCategory: categoryTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &Extension{
+				Extension: sdk.NewExtension(sdk.ExtensionInfo{Name: "test"}),
+				client:    tt.client,
+			}
+
+			req := &sdk.OperationRequest{Args: tt.args}
+			result, err := ext.handleWait(context.Background(), req)
+
+			if err != nil {
+				t.Fatalf("handleWait() returned error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("handleWait() success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			for key, want := range tt.wantOutputs {
+				if got := result.Outputs[key]; got != want {
+					t.Errorf("%s output = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantCategory != "" {
+				if got := result.Outputs["category"]; got != tt.wantCategory {
+					t.Errorf("category output = %q, want %q", got, tt.wantCategory)
+				}
+			}
+		})
+	}
+}
+
+func TestSatisfied(t *testing.T) {
+	met := targetObservation{met: true}
+	unmet := targetObservation{}
+
+	tests := []struct {
+		name         string
+		observations []targetObservation
+		mode         string
+		minCount     int
+		want         bool
+	}{
+		{name: "all met", observations: []targetObservation{met, met}, mode: waitModeAll, minCount: 1, want: true},
+		{name: "all with one unmet", observations: []targetObservation{met, unmet}, mode: waitModeAll, minCount: 1, want: false},
+		{name: "any with one met", observations: []targetObservation{met, unmet}, mode: waitModeAny, minCount: 1, want: true},
+		{name: "any with none met", observations: []targetObservation{unmet, unmet}, mode: waitModeAny, minCount: 0, want: false},
+		{name: "empty with minCount", observations: nil, mode: waitModeAll, minCount: 1, want: false},
+		{name: "empty without minCount", observations: nil, mode: waitModeAll, minCount: 0, want: true},
+		{name: "below minCount", observations: []targetObservation{met, met}, mode: waitModeAll, minCount: 3, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := satisfied(tt.observations, tt.mode, tt.minCount); got != tt.want {
+				t.Errorf("satisfied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}